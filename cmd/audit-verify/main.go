@@ -0,0 +1,64 @@
+// Command audit-verify walks the hash chain of the audit trail and reports the first broken link. It exits with
+// status 1 when the chain is broken and 2 when it could not be verified.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/db"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+func main() {
+	// Parse flags
+	cfgPath := flag.String("config", "resources/config.toml", "Path to the configuration file")
+	sinceCheckpoint := flag.Bool("since-checkpoint", false, "Trust the latest valid checkpoint and only verify the events after it")
+	flag.Parse()
+
+	// Load config
+	myConfig, err := config.LoadConfigurations(*cfgPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error loading configurations")
+	}
+
+	// Setup logger
+	logger.MustConfigure(myConfig.Logger)
+
+	myDB := db.MustCreatePooledConnection(myConfig.Database)
+
+	// The retired keys of the keyring verify the events hashed before a rotation
+	keyring, err := hasher.NewHmacSHA256KeyringFromConfig(myConfig.Hasher)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating hashing keyring")
+	}
+	auditManager, ok := audit.NewManagerWithPgSQLStorage(myDB.Conn, keyring)
+	if !ok {
+		log.Fatal().Msg("Error creating audit manager")
+	}
+
+	verification, err := auditManager.Verify(context.Background(), *sinceCheckpoint)
+	myDB.Close()
+	if err != nil {
+		log.Error().Err(err).Msg("Error verifying the audit trail")
+		os.Exit(2)
+	}
+
+	report, _ := json.MarshalIndent(verification, "", "  ")
+	fmt.Println(string(report))
+
+	if !verification.OK() {
+		log.Error().Int64("sequence", verification.Broken.Sequence).Str("event", verification.Broken.EventID).
+			Msgf("audit trail is broken: %s", verification.Broken.Reason)
+		os.Exit(1)
+	}
+
+	log.Info().Msgf("audit trail verified, %d events up to sequence %d", verification.Verified, verification.To)
+}
@@ -3,20 +3,35 @@ package main
 import (
 	"context"
 	"flag"
-	"os"
-	"os/signal"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/zeusito/toci/internal/actions"
+	"github.com/zeusito/toci/internal/admin"
 	"github.com/zeusito/toci/internal/healthcheck/handlers"
 	"github.com/zeusito/toci/internal/signin"
+	"github.com/zeusito/toci/internal/webhooks"
+	"github.com/zeusito/toci/pkg/app"
+	"github.com/zeusito/toci/pkg/audit"
 	"github.com/zeusito/toci/pkg/config"
 	"github.com/zeusito/toci/pkg/db"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/health"
+	"github.com/zeusito/toci/pkg/jobs"
 	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/mailer"
+	"github.com/zeusito/toci/pkg/metrics"
 	"github.com/zeusito/toci/pkg/router"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/oauth"
+	"github.com/zeusito/toci/pkg/security/oidc"
 	"github.com/zeusito/toci/pkg/security/otp"
+	"github.com/zeusito/toci/pkg/security/passwords"
 	"github.com/zeusito/toci/pkg/security/sessions"
+	"github.com/zeusito/toci/pkg/security/webauthn"
+	"github.com/zeusito/toci/pkg/toolbox/crypto"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+	"github.com/zeusito/toci/pkg/tracing"
 )
 
 func main() {
@@ -24,56 +39,147 @@ func main() {
 	cfgPath := flag.String("config", "resources/config.toml", "Path to the configuration file")
 	flag.Parse()
 
-	// Setup logger
-	logger.MustConfigure()
-
 	// Load config
 	myConfig, err := config.LoadConfigurations(*cfgPath)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error loading configurations")
 	}
 
+	// Setup logger
+	logger.MustConfigure(myConfig.Logger)
+
+	// Setup tracing
+	tracingProvider, err := tracing.NewProvider(context.Background(), myConfig.Tracing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating tracing provider")
+	}
+
 	// Init DB
 	myDB := db.MustCreatePooledConnection(myConfig.Database)
+	myDB.RegisterMetrics(metrics.Registry)
 
 	// Init router
 	myRouter := router.NewHTTPRouter(myConfig.Server)
+	metricsServer := metrics.NewServer(myConfig.Metrics, metrics.Registry)
 
-	// Init shared services
-	otpManager, ok := otp.NewManagerWithPgSQLStorage(myDB.Conn, myConfig.Hasher.SHASecret)
+	// Init shared services, the dev hashing key is for local environments only
+	if myConfig.Hasher.PrimaryKey == "dev" && !myConfig.Auth.DevMode {
+		log.Fatal().Msg("The dev hashing key cannot be the primary key outside of auth dev mode")
+	}
+	keyring, err := hasher.NewHmacSHA256KeyringFromConfig(myConfig.Hasher)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating hashing keyring")
+	}
+	if len(myConfig.Encryption.Keys) > 0 {
+		encryptionKeyring, err := crypto.NewKeyringFromConfig(myConfig.Encryption)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Error creating encryption keyring")
+		}
+		crypto.UseKeyring(encryptionKeyring)
+	}
+	otpManager, ok := otp.NewManagerWithPgSQLStorage(myDB.Conn, keyring)
 	if !ok {
 		log.Fatal().Msg("Error creating OTP manager")
 	}
-	sessionManager, ok := sessions.NewManagerWithPgSQLStorage(myDB.Conn, myConfig.Hasher.SHASecret)
+	outbox := events.NewOutboxWithPgSQLStorage(myDB.Conn)
+	auditManager, ok := audit.NewManagerWithPgSQLStorage(myDB.Conn, keyring)
+	if !ok {
+		log.Fatal().Msg("Error creating audit manager")
+	}
+	sessionManager, ok := sessions.NewManagerWithPgSQLStorage(myDB.Conn, keyring, outbox, auditManager)
 	if !ok {
 		log.Fatal().Msg("Error creating session manager")
 	}
+	passkeyManager, ok := webauthn.NewManagerWithPgSQLStorage(myDB.Conn, otpManager, myConfig.WebAuthn)
+	if !ok {
+		log.Fatal().Msg("Error creating passkey manager")
+	}
+	oidcVerifier, ok := oidc.NewVerifier(myConfig.OIDC)
+	if !ok {
+		log.Fatal().Msg("Error creating OIDC verifier")
+	}
+	oauthManager, ok := oauth.NewManagerWithPgSQLStorage(myDB.Conn, keyring, oidcVerifier, myConfig.OIDC, myConfig.OAuth)
+	if !ok {
+		log.Fatal().Msg("Error creating OAuth manager")
+	}
+	passwordManager, ok := passwords.NewManagerWithPgSQLStorage(myDB.Conn, myConfig.Password, myConfig.Hasher.Argon2)
+	if !ok {
+		log.Fatal().Msg("Error creating password manager")
+	}
 
-	// Health Controller
-	_ = handlers.NewHealthController(myRouter.Mux)
+	mailSender, err := mailer.NewSenderFromConfig(myConfig.Email)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating mail sender")
+	}
+	myMailer, err := mailer.NewMailer(mailSender, myConfig.Email.FromEmail, myConfig.Email.AppName)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating mailer")
+	}
 
-	// Modules
-	signin.InitModule(myRouter.Mux, myDB.Conn, otpManager, sessionManager, actions.NewDefaultActions())
+	// Background jobs
+	jobQueue := jobs.NewQueueWithPgSQLStorage(myDB.Conn, myConfig.Jobs)
+	jobPool := jobs.NewPoolWithPgSQLStorage(myDB.Conn, myConfig.Jobs)
+	actions.RegisterJobHandlers(jobPool, myMailer)
 
-	// Start server in background
-	go myRouter.Start()
+	// Events of the outbox, in-process subscribers register on the bus
+	eventBus := events.NewBus()
+	eventRelay := events.NewRelayWithPgSQLStorage(myDB.Conn, myConfig.Events, events.NewSinksFromConfig(myConfig.Events)...)
+	eventRelay.AddSink(eventBus)
+	auditCheckpointer := audit.NewCheckpointer(auditManager, myConfig.Audit)
 
-	// Graceful shutdown
-	gracefulShutdown(myRouter, myDB)
-}
+	// Health Controller, the instance is not ready while the database is unreachable
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register(health.Check{Name: "email", Check: myMailer.Ping, Timeout: 3 * time.Second})
+	if myConfig.Database.Enabled {
+		healthRegistry.Register(health.Check{Name: "postgres", Check: myDB.Ping, Timeout: 2 * time.Second, Critical: true})
+		healthRegistry.Register(health.Check{Name: "jobs", Check: jobPool.Ping})
+	}
+	_ = handlers.NewHealthController(myRouter.Mux, healthRegistry)
 
-func gracefulShutdown(myRouter *router.HTTPRouter, myDB *db.DatabaseConnection) {
-	// Wait for the interrupt signal to gracefully shut down the server with a timeout of 10 seconds.
-	// Use a buffered channel to avoid missing signals as recommended for signal.Notify
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
-	// Signal acquired, starting to shut down all systems
-	log.Warn().Msg("Shutting down gracefully...")
+	// Modules
+	signin.InitModule(myRouter.Mux, myDB.Conn, otpManager, sessionManager, passkeyManager, oidcVerifier, oauthManager,
+		passwordManager, actions.NewDefaultActions(jobQueue), outbox, auditManager,
+		security.NewSessionCookies(myConfig.Auth.SessionCookie))
+	webhooks.InitModule(myRouter.Mux, myDB.Conn, sessionManager, jobQueue, jobPool, eventRelay, auditManager, myConfig.Webhooks)
+	admin.InitModule(myRouter.Mux, sessionManager, auditManager)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Components start in dependency order and stop in reverse order: the readiness fails first, then the
+	// in-flight requests and jobs are drained while the database is still open
+	myApp := app.New()
+	myApp.Add(
+		app.Component{Name: "tracing", Stop: tracingProvider.Shutdown},
+		app.Component{Name: "database", Stop: func(context.Context) error {
+			myDB.Close()
+			return nil
+		}},
+		app.Component{Name: "metrics server", Run: metricsServer.Start, Stop: metricsServer.Shutdown},
+	)
+	// The workers poll the database, they do not run without it
+	if myConfig.Database.Enabled {
+		myApp.Add(
+			app.NewWorker("job workers", jobPool),
+			app.NewWorker("event relay", eventRelay),
+			app.NewWorker("audit checkpointer", auditCheckpointer),
+		)
+	} else {
+		log.Warn().Msg("Database is disabled, the job workers, event relay and audit checkpointer are not started")
+	}
+	myApp.Add(
+		// The in-flight requests are drained for as long as they are allowed to run
+		app.Component{Name: "http server", Run: myRouter.Start, Stop: myRouter.Shutdown, StopTimeout: myRouter.ShutdownTimeout()},
+		app.Component{Name: "readiness", StopTimeout: myConfig.Health.ShutdownDelay + time.Second,
+			Stop: func(ctx context.Context) error {
+				// The load balancers stop routing new requests once the readiness fails
+				healthRegistry.BeginShutdown()
+				select {
+				case <-time.After(myConfig.Health.ShutdownDelay):
+				case <-ctx.Done():
+				}
+				return nil
+			}},
+	)
 
-	myDB.Close()
-	myRouter.Shutdown(ctx)
+	if err := myApp.Run(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Application stopped with errors")
+	}
 }
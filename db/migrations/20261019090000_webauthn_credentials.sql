@@ -0,0 +1,16 @@
+-- migrate:up
+create table if not exists webauthn_credentials (
+    id bytea not null,
+    identity_id varchar(50) not null references identities (id) on delete cascade,
+    -- COSE encoded public key
+    public_key bytea not null,
+    sign_count bigint not null default 0,
+    transports text[] not null default '{}',
+    aaguid bytea,
+    last_used_at timestamp not null default now(),
+    created_at timestamp not null default now(),
+    primary key (id)
+);
+create index if not exists webauthn_credentials_identity_id_idx on webauthn_credentials (identity_id);
+-- migrate:down
+drop table if exists webauthn_credentials;
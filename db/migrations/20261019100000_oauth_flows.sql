@@ -0,0 +1,14 @@
+-- migrate:up
+create table if not exists oauth_flows (
+    -- hashed state parameter
+    id varchar(255) not null,
+    provider varchar(50) not null,
+    nonce varchar(255) not null default '',
+    code_verifier varchar(255) not null,
+    expires_at timestamp not null,
+    created_at timestamp not null default now(),
+    primary key (id)
+);
+create index if not exists oauth_flows_expires_at_idx on oauth_flows (expires_at);
+-- migrate:down
+drop table if exists oauth_flows;
@@ -0,0 +1,15 @@
+-- migrate:up
+create table if not exists identity_providers (
+    id varchar(50) not null,
+    identity_id varchar(50) not null references identities (id) on delete cascade,
+    provider varchar(50) not null,
+    -- the stable user identifier at the provider
+    subject varchar(255) not null,
+    email varchar(255) not null,
+    linked_at timestamp not null default now(),
+    primary key (id),
+    unique (provider, subject)
+);
+create index if not exists identity_providers_identity_id_idx on identity_providers (identity_id);
+-- migrate:down
+drop table if exists identity_providers;
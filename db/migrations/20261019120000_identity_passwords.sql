@@ -0,0 +1,19 @@
+-- migrate:up
+create table if not exists identity_passwords (
+    identity_id varchar(50) not null references identities (id) on delete cascade,
+    -- argon2id PHC string
+    hashed_password varchar(255) not null,
+    updated_at timestamp not null default now(),
+    primary key (identity_id)
+);
+create table if not exists identity_password_history (
+    id bigserial not null,
+    identity_id varchar(50) not null references identities (id) on delete cascade,
+    hashed_password varchar(255) not null,
+    created_at timestamp not null default now(),
+    primary key (id)
+);
+create index if not exists identity_password_history_identity_id_idx on identity_password_history (identity_id);
+-- migrate:down
+drop table if exists identity_password_history;
+drop table if exists identity_passwords;
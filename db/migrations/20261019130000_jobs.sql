@@ -0,0 +1,20 @@
+-- migrate:up
+create table if not exists jobs (
+    id bigserial not null,
+    kind varchar(100) not null,
+    payload jsonb not null default '{}',
+    -- pending, running or dead
+    status varchar(20) not null default 'pending',
+    attempts int not null default 0,
+    max_attempts int not null,
+    last_error text not null default '',
+    run_at timestamp not null default now(),
+    locked_until timestamp,
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now(),
+    primary key (id)
+);
+-- claims look for due pending jobs and expired leases
+create index if not exists jobs_claim_idx on jobs (status, run_at) where status in ('pending', 'running');
+-- migrate:down
+drop table if exists jobs;
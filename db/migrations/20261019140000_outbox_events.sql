@@ -0,0 +1,22 @@
+-- migrate:up
+-- events waiting to be delivered, they are deleted once every sink received them
+create table if not exists outbox_events (
+    -- commit order of the events, the order of delivery within an aggregate
+    sequence bigserial not null,
+    id varchar(50) not null,
+    type varchar(100) not null,
+    aggregate_type varchar(50) not null,
+    aggregate_id varchar(100) not null,
+    payload jsonb not null default '{}',
+    occurred_at timestamp not null,
+    attempts int not null default 0,
+    last_error text not null default '',
+    next_attempt_at timestamp not null,
+    locked_until timestamp,
+    primary key (sequence),
+    unique (id)
+);
+create index if not exists outbox_events_next_attempt_at_idx on outbox_events (next_attempt_at);
+create index if not exists outbox_events_aggregate_idx on outbox_events (aggregate_type, aggregate_id, sequence);
+-- migrate:down
+drop table if exists outbox_events;
@@ -0,0 +1,48 @@
+-- migrate:up
+create table if not exists organization_members (
+    organization_id varchar(50) not null references organizations (id) on delete cascade,
+    identity_id varchar(50) not null references identities (id) on delete cascade,
+    -- owner, admin or member, owners and admins manage the organization
+    role varchar(20) not null default 'member',
+    created_at timestamp not null default now(),
+    primary key (organization_id, identity_id)
+);
+create index if not exists organization_members_identity_id_idx on organization_members (identity_id);
+create table if not exists webhook_endpoints (
+    id varchar(50) not null,
+    organization_id varchar(50) not null references organizations (id) on delete cascade,
+    url varchar(2048) not null,
+    -- signing secret, encrypted with the id of the endpoint as associated data
+    secret text not null,
+    -- subscribed event types, empty for all of them
+    event_types text[] not null default '{}',
+    status varchar(20) not null default 'active',
+    consecutive_failures int not null default 0,
+    disabled_at timestamp,
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now(),
+    primary key (id)
+);
+create index if not exists webhook_endpoints_organization_id_idx on webhook_endpoints (organization_id);
+-- one delivery per event and endpoint, with the outcome of its last attempt
+create table if not exists webhook_deliveries (
+    id varchar(50) not null,
+    endpoint_id varchar(50) not null references webhook_endpoints (id) on delete cascade,
+    event_id varchar(50) not null,
+    event_type varchar(100) not null,
+    payload jsonb not null,
+    status varchar(20) not null default 'pending',
+    attempts int not null default 0,
+    response_status int not null default 0,
+    last_error text not null default '',
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now(),
+    delivered_at timestamp,
+    primary key (id),
+    unique (endpoint_id, event_id)
+);
+create index if not exists webhook_deliveries_endpoint_id_idx on webhook_deliveries (endpoint_id, created_at desc);
+-- migrate:down
+drop table if exists webhook_deliveries;
+drop table if exists webhook_endpoints;
+drop table if exists organization_members;
@@ -0,0 +1,30 @@
+-- migrate:up
+-- append only trail of the authentication and administrative actions
+create table if not exists audit_events (
+    -- order of the trail, the cursor of the searches
+    sequence bigserial not null,
+    id varchar(50) not null,
+    occurred_at timestamp not null,
+    actor_id varchar(50) not null default '',
+    organization_id varchar(50) not null default '',
+    action varchar(100) not null,
+    target_type varchar(50) not null default '',
+    target_id varchar(255) not null default '',
+    ip_address varchar(50) not null default '',
+    user_agent varchar(512) not null default '',
+    request_id varchar(100) not null default '',
+    result varchar(20) not null,
+    metadata jsonb not null default '{}',
+    primary key (sequence),
+    unique (id)
+);
+create index if not exists audit_events_actor_id_idx on audit_events (actor_id, sequence);
+create index if not exists audit_events_organization_id_idx on audit_events (organization_id, sequence);
+create index if not exists audit_events_action_idx on audit_events (action, sequence);
+create index if not exists audit_events_target_idx on audit_events (target_type, target_id, sequence);
+create index if not exists audit_events_occurred_at_idx on audit_events (occurred_at);
+-- roles granted to the sessions of an identity, admins search the audit trail
+alter table identities add column if not exists roles text[] not null default '{user}';
+-- migrate:down
+alter table identities drop column if exists roles;
+drop table if exists audit_events;
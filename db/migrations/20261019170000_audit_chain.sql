@@ -0,0 +1,17 @@
+-- migrate:up
+-- every event holds the HMAC of its content chained to the hash of the previous event, the events recorded
+-- before have no hash and precede the chain
+alter table audit_events add column if not exists prev_hash varchar(100) not null default '';
+alter table audit_events add column if not exists hash varchar(100) not null default '';
+-- signed heads of the chain, a verification can start from the latest one
+create table if not exists audit_checkpoints (
+    sequence bigint not null,
+    event_hash varchar(100) not null,
+    created_at timestamp not null,
+    signature varchar(100) not null,
+    primary key (sequence)
+);
+-- migrate:down
+drop table if exists audit_checkpoints;
+alter table audit_events drop column if exists hash;
+alter table audit_events drop column if exists prev_hash;
@@ -0,0 +1,5 @@
+-- migrate:up
+-- the payloads of sensitive jobs, e.g. one time passwords, are scrubbed when they die
+alter table jobs add column if not exists sensitive boolean not null default false;
+-- migrate:down
+alter table jobs drop column if exists sensitive;
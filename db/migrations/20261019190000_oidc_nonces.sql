@@ -0,0 +1,12 @@
+-- migrate:up
+create table if not exists oidc_nonces (
+    -- hashed nonce
+    id varchar(255) not null,
+    provider varchar(50) not null,
+    expires_at timestamp not null,
+    created_at timestamp not null default now(),
+    primary key (id)
+);
+create index if not exists oidc_nonces_expires_at_idx on oidc_nonces (expires_at);
+-- migrate:down
+drop table if exists oidc_nonces;
@@ -0,0 +1,8 @@
+-- migrate:up
+-- dead events exhausted their attempts, they no longer block the next events of their aggregate
+alter table outbox_events add column if not exists status varchar(20) not null default 'pending';
+-- the sinks that received the event, a retry only delivers it to the others
+alter table outbox_events add column if not exists delivered_sinks text[] not null default '{}';
+-- migrate:down
+alter table outbox_events drop column if exists delivered_sinks;
+alter table outbox_events drop column if exists status;
@@ -1,14 +1,43 @@
 package actions
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/zeusito/toci/pkg/jobs"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/security/otp"
+)
+
+// otpEmailAttempts is low on purpose, the code is useless once expired
+const otpEmailAttempts = 3
 
 type DefaultActions struct {
+	queue jobs.Queue
 }
 
-func NewDefaultActions() Service {
-	return &DefaultActions{}
+func NewDefaultActions(queue jobs.Queue) Service {
+	return &DefaultActions{queue: queue}
 }
 
+// SendOTPByEmail enqueues the delivery of the sign-in code, it is sent by the job workers
 func (s *DefaultActions) SendOTPByEmail(ctx context.Context, code, toEmail string) {
+	if err := s.enqueueCode(ctx, JobKindOTPEmail, code, toEmail); err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to enqueue one time password email")
+	}
+}
+
+// SendPasswordResetByEmail enqueues the delivery of the password reset code, it is sent by the job workers
+func (s *DefaultActions) SendPasswordResetByEmail(ctx context.Context, code, toEmail string) {
+	if err := s.enqueueCode(ctx, JobKindPasswordResetEmail, code, toEmail); err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to enqueue password reset email")
+	}
+}
 
+func (s *DefaultActions) enqueueCode(ctx context.Context, kind, code, toEmail string) error {
+	return s.queue.Enqueue(ctx, kind, &OTPEmailPayload{
+		Email:     toEmail,
+		Code:      code,
+		ExpiresAt: time.Now().UTC().Add(otp.DefaultExpiration),
+	}, jobs.WithMaxAttempts(otpEmailAttempts), jobs.WithSensitivePayload())
 }
@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zeusito/toci/pkg/jobs"
+	"github.com/zeusito/toci/pkg/mailer"
+)
+
+const (
+	JobKindOTPEmail           = "email.otp"
+	JobKindPasswordResetEmail = "email.password_reset"
+)
+
+// OTPEmailPayload the payload of the jobs sending a code, to sign in or to reset a password
+type OTPEmailPayload struct {
+	Email     string    `json:"email"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RegisterJobHandlers registers the handlers of the jobs enqueued by the actions
+func RegisterJobHandlers(pool *jobs.Pool, theMailer *mailer.Mailer) {
+	jobs.Register(pool, JobKindOTPEmail, func(ctx context.Context, payload OTPEmailPayload) error {
+		expiresIn, err := payload.expiresIn()
+		if err != nil {
+			return err
+		}
+
+		return theMailer.SendOTP(ctx, payload.Email, payload.Code, expiresIn)
+	})
+
+	jobs.Register(pool, JobKindPasswordResetEmail, func(ctx context.Context, payload OTPEmailPayload) error {
+		expiresIn, err := payload.expiresIn()
+		if err != nil {
+			return err
+		}
+
+		return theMailer.SendPasswordReset(ctx, payload.Email, payload.Code, expiresIn)
+	})
+}
+
+// expiresIn returns how long the code stays valid, an expired code is not worth sending
+func (p *OTPEmailPayload) expiresIn() (time.Duration, error) {
+	expiresIn := time.Until(p.ExpiresAt)
+	if expiresIn <= 0 {
+		return 0, jobs.Permanent(errors.New("one time password expired before it could be sent"))
+	}
+
+	return expiresIn, nil
+}
@@ -88,3 +88,55 @@ func (_c *MockService_SendOTPByEmail_Call) RunAndReturn(run func(ctx context.Con
 	_c.Run(run)
 	return _c
 }
+
+// SendPasswordResetByEmail provides a mock function for the type MockService
+func (_mock *MockService) SendPasswordResetByEmail(ctx context.Context, code string, toEmail string) {
+	_mock.Called(ctx, code, toEmail)
+	return
+}
+
+// MockService_SendPasswordResetByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendPasswordResetByEmail'
+type MockService_SendPasswordResetByEmail_Call struct {
+	*mock.Call
+}
+
+// SendPasswordResetByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - code string
+//   - toEmail string
+func (_e *MockService_Expecter) SendPasswordResetByEmail(ctx interface{}, code interface{}, toEmail interface{}) *MockService_SendPasswordResetByEmail_Call {
+	return &MockService_SendPasswordResetByEmail_Call{Call: _e.mock.On("SendPasswordResetByEmail", ctx, code, toEmail)}
+}
+
+func (_c *MockService_SendPasswordResetByEmail_Call) Run(run func(ctx context.Context, code string, toEmail string)) *MockService_SendPasswordResetByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_SendPasswordResetByEmail_Call) Return() *MockService_SendPasswordResetByEmail_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockService_SendPasswordResetByEmail_Call) RunAndReturn(run func(ctx context.Context, code string, toEmail string)) *MockService_SendPasswordResetByEmail_Call {
+	_c.Run(run)
+	return _c
+}
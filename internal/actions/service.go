@@ -4,4 +4,5 @@ import "context"
 
 type Service interface {
 	SendOTPByEmail(ctx context.Context, code, toEmail string)
+	SendPasswordResetByEmail(ctx context.Context, code, toEmail string)
 }
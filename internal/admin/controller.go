@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/zeusito/toci/pkg/router"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/sessions"
+)
+
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+type Controller struct {
+	svc Service
+}
+
+func NewController(mux *chi.Mux, svc Service, sessionManager sessions.Manager) *Controller {
+	c := &Controller{svc: svc}
+
+	mux.Group(func(r chi.Router) {
+		r.Use(security.AuthenticationFilter(sessionManager))
+		r.Use(security.AuthorizationFilter(sessions.RoleAdmin))
+		r.Get("/v1/admin/audit-events", c.handleSearchAuditEvents)
+		r.Get("/v1/admin/audit-events/export", c.handleExportAuditEvents)
+	})
+
+	return c
+}
+
+func (c *Controller) handleSearchAuditEvents(w http.ResponseWriter, req *http.Request) {
+	var body SearchAuditEventsRequest
+	if err := router.BindQuery(req, &body); err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.SearchAuditEvents(req.Context(), body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handleExportAuditEvents(w http.ResponseWriter, req *http.Request) {
+	var body SearchAuditEventsRequest
+	if err := router.BindQuery(req, &body); err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(req.Context()))
+	w.Header().Set("Content-Type", MIMEApplicationNDJSON)
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-events.ndjson"`)
+
+	exported, err := c.svc.ExportAuditEvents(req.Context(), claims.PrincipalID, body, w)
+	if err != nil && exported == 0 {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+	if err != nil {
+		// The status is sent already, aborting tells the client the export is truncated
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,12 @@
+package admin
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/security/sessions"
+)
+
+func InitModule(mux *chi.Mux, sessionManager sessions.Manager, auditManager audit.Manager) {
+	svc := NewDefaultService(auditManager)
+	_ = NewController(mux, svc, sessionManager)
+}
@@ -0,0 +1,16 @@
+package admin
+
+// SearchAuditEventsRequest the query parameters of a search of the audit trail, empty ones match everything. The
+// times are RFC 3339.
+type SearchAuditEventsRequest struct {
+	ActorID        string `query:"actor"`
+	OrganizationID string `query:"organization"`
+	Action         string `query:"action"`
+	TargetType     string `query:"targetType"`
+	TargetID       string `query:"targetId"`
+	Result         string `query:"result"`
+	From           string `query:"from"`
+	To             string `query:"to"`
+	Cursor         string `query:"cursor"`
+	Limit          int    `query:"limit" validate:"omitempty,gt=0"`
+}
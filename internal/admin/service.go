@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"context"
+	"io"
+
+	"github.com/zeusito/toci/pkg/audit"
+)
+
+// Service the administration of the platform, reserved to the principals with the admin role
+type Service interface {
+	SearchAuditEvents(ctx context.Context, req SearchAuditEventsRequest) (*audit.Page, error)
+	// ExportAuditEvents writes the matching events to w as NDJSON, newest first. It returns how many events were
+	// written, the response can no longer be changed once there is one.
+	ExportAuditEvents(ctx context.Context, principalID string, req SearchAuditEventsRequest, w io.Writer) (int, error)
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+type DefaultService struct {
+	auditManager audit.Manager
+}
+
+func NewDefaultService(auditManager audit.Manager) Service {
+	return &DefaultService{auditManager: auditManager}
+}
+
+func (s *DefaultService) SearchAuditEvents(ctx context.Context, req SearchAuditEventsRequest) (*audit.Page, error) {
+	filter, err := toFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.auditManager.Search(ctx, filter, req.Cursor, req.Limit)
+	if errors.Is(err, audit.ErrInvalidCursor) {
+		return nil, terrors.PreconditionFailed("invalid cursor")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to search audit events")
+		return nil, terrors.Unknown("failed to search audit events")
+	}
+
+	return page, nil
+}
+
+func (s *DefaultService) ExportAuditEvents(ctx context.Context, principalID string, req SearchAuditEventsRequest, w io.Writer) (int, error) {
+	logger.Ctx(ctx).Info().Msgf("export audit events by: %s", principalID)
+
+	filter, err := toFilter(req)
+	if err != nil {
+		return 0, err
+	}
+
+	// The encoder terminates every event with a new line
+	encoder := json.NewEncoder(w)
+	exported := 0
+	err = s.auditManager.Export(ctx, filter, func(event *audit.Event) error {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+
+	result := audit.ResultSuccess
+	if err != nil {
+		result = audit.ResultFailure
+	}
+	s.auditManager.Record(ctx, audit.Event{
+		Action:     audit.ActionAuditExport,
+		ActorID:    principalID,
+		TargetType: audit.TargetAuditEvents,
+		Result:     result,
+		Metadata:   map[string]string{"exported": strconv.Itoa(exported)},
+	})
+
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to export audit events after %d events", exported)
+		return exported, terrors.Unknown("failed to export audit events")
+	}
+
+	return exported, nil
+}
+
+// toFilter validates the search parameters
+func toFilter(req SearchAuditEventsRequest) (audit.Filter, error) {
+	filter := audit.Filter{
+		ActorID:        req.ActorID,
+		OrganizationID: req.OrganizationID,
+		Action:         req.Action,
+		TargetType:     req.TargetType,
+		TargetID:       req.TargetID,
+		Result:         audit.Result(req.Result),
+	}
+
+	switch filter.Result {
+	case "", audit.ResultSuccess, audit.ResultFailure, audit.ResultDenied:
+	default:
+		return filter, terrors.PreconditionFailed("unknown result: " + req.Result)
+	}
+
+	var err error
+	if req.From != "" {
+		if filter.From, err = time.Parse(time.RFC3339, req.From); err != nil {
+			return filter, terrors.PreconditionFailed("from must be an RFC 3339 time")
+		}
+	}
+	if req.To != "" {
+		if filter.To, err = time.Parse(time.RFC3339, req.To); err != nil {
+			return filter, terrors.PreconditionFailed("to must be an RFC 3339 time")
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, terrors.PreconditionFailed("to must not be before from")
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/audit"
+)
+
+func TestSearchAuditEventsBuildsFilter(t *testing.T) {
+	ctx := context.Background()
+	auditManager := audit.NewMockManager(t)
+	svc := NewDefaultService(auditManager)
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	expectedFilter := audit.Filter{
+		ActorID: "1",
+		Action:  audit.ActionLoginPassword,
+		Result:  audit.ResultFailure,
+		From:    from,
+		To:      to,
+	}
+
+	// Expectations
+	auditManager.EXPECT().Search(ctx, expectedFilter, "cursor", 10).
+		Return(&audit.Page{Events: []*audit.Event{{ID: "event-1"}}, NextCursor: "next"}, nil)
+
+	page, err := svc.SearchAuditEvents(ctx, SearchAuditEventsRequest{
+		ActorID: "1",
+		Action:  audit.ActionLoginPassword,
+		Result:  "failure",
+		From:    "2026-10-01T00:00:00Z",
+		To:      "2026-10-02T00:00:00Z",
+		Cursor:  "cursor",
+		Limit:   10,
+	})
+	require.NoError(t, err, "expected no error for a valid search")
+	assert.Len(t, page.Events, 1)
+	assert.Equal(t, "next", page.NextCursor)
+}
+
+func TestSearchAuditEventsInvalidParameters(t *testing.T) {
+	ctx := context.Background()
+	auditManager := audit.NewMockManager(t)
+	svc := NewDefaultService(auditManager)
+
+	requests := []SearchAuditEventsRequest{
+		{Result: "maybe"},
+		{From: "yesterday"},
+		{To: "2026-10-02"},
+		{From: "2026-10-02T00:00:00Z", To: "2026-10-01T00:00:00Z"},
+	}
+
+	for _, req := range requests {
+		page, err := svc.SearchAuditEvents(ctx, req)
+		assert.Error(t, err, "expected error for %+v", req)
+		assert.Nil(t, page)
+	}
+}
+
+func TestSearchAuditEventsInvalidCursor(t *testing.T) {
+	ctx := context.Background()
+	auditManager := audit.NewMockManager(t)
+	svc := NewDefaultService(auditManager)
+
+	// Expectations
+	auditManager.EXPECT().Search(ctx, audit.Filter{}, "garbage", 0).Return(nil, audit.ErrInvalidCursor)
+
+	page, err := svc.SearchAuditEvents(ctx, SearchAuditEventsRequest{Cursor: "garbage"})
+	assert.ErrorContains(t, err, "invalid cursor")
+	assert.Nil(t, page)
+}
+
+func TestExportAuditEventsWritesNDJSON(t *testing.T) {
+	ctx := context.Background()
+	auditManager := audit.NewMockManager(t)
+	svc := NewDefaultService(auditManager)
+
+	// Expectations
+	auditManager.EXPECT().Export(ctx, audit.Filter{OrganizationID: "org-1"}, mock.Anything).
+		RunAndReturn(func(ctx context.Context, filter audit.Filter, fn func(*audit.Event) error) error {
+			for _, id := range []string{"event-2", "event-1"} {
+				if err := fn(&audit.Event{ID: id, Action: audit.ActionLogout, Result: audit.ResultSuccess}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	auditManager.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionAuditExport && event.ActorID == "admin-1" &&
+			event.Result == audit.ResultSuccess && event.Metadata["exported"] == "2"
+	})).Return()
+
+	var out bytes.Buffer
+	exported, err := svc.ExportAuditEvents(ctx, "admin-1", SearchAuditEventsRequest{OrganizationID: "org-1"}, &out)
+	require.NoError(t, err, "expected no error for an export")
+	assert.Equal(t, 2, exported)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var event audit.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "event-2", event.ID)
+}
+
+func TestExportAuditEventsFailureIsRecorded(t *testing.T) {
+	ctx := context.Background()
+	auditManager := audit.NewMockManager(t)
+	svc := NewDefaultService(auditManager)
+
+	// Expectations
+	auditManager.EXPECT().Export(ctx, audit.Filter{}, mock.Anything).Return(errors.New("connection reset"))
+	auditManager.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionAuditExport && event.Result == audit.ResultFailure
+	})).Return()
+
+	var out bytes.Buffer
+	exported, err := svc.ExportAuditEvents(ctx, "admin-1", SearchAuditEventsRequest{}, &out)
+	assert.Error(t, err, "expected error when the export fails")
+	assert.Zero(t, exported)
+}
@@ -26,6 +26,7 @@ type IdentityRecord struct {
 	FailedLoginAttempts int            `bun:"failed_login_attempts"`
 	LockExpiresAt       time.Time      `bun:"lock_expires_at"`
 	LastLoginAt         time.Time      `bun:"last_login_at"`
+	Roles               []string       `bun:"roles,array,nullzero"`
 	CreatedAt           time.Time      `bun:"created_at"`
 	UpdatedAt           time.Time      `bun:"updated_at"`
 }
@@ -0,0 +1,18 @@
+package dbmodels
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// IdentityProviderRecord an external provider account linked to an identity
+type IdentityProviderRecord struct {
+	bun.BaseModel `bun:"table:identity_providers,alias:ip"`
+	ID            string    `bun:"id,pk"`
+	IdentityID    string    `bun:"identity_id"`
+	Provider      string    `bun:"provider"`
+	Subject       string    `bun:"subject"`
+	Email         string    `bun:"email"`
+	LinkedAt      time.Time `bun:"linked_at"`
+}
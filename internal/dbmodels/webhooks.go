@@ -0,0 +1,81 @@
+package dbmodels
+
+import (
+	"context"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/toolbox/crypto"
+)
+
+type OrganizationRole string
+
+const (
+	OrganizationRoleOwner  OrganizationRole = "owner"
+	OrganizationRoleAdmin  OrganizationRole = "admin"
+	OrganizationRoleMember OrganizationRole = "member"
+)
+
+// OrganizationMemberRecord the membership of an identity in an organization
+type OrganizationMemberRecord struct {
+	bun.BaseModel  `bun:"table:organization_members,alias:om"`
+	OrganizationID string           `bun:"organization_id,pk"`
+	IdentityID     string           `bun:"identity_id,pk"`
+	Role           OrganizationRole `bun:"role"`
+	CreatedAt      time.Time        `bun:"created_at"`
+}
+
+type WebhookEndpointStatus string
+
+const (
+	WebhookEndpointStatusActive WebhookEndpointStatus = "active"
+	// WebhookEndpointStatusDisabled endpoints failed persistently, they receive nothing until enabled again
+	WebhookEndpointStatusDisabled WebhookEndpointStatus = "disabled"
+)
+
+// WebhookEndpointRecord a URL of an organization receiving the events
+type WebhookEndpointRecord struct {
+	bun.BaseModel       `bun:"table:webhook_endpoints,alias:we"`
+	ID                  string                 `bun:"id,pk"`
+	OrganizationID      string                 `bun:"organization_id"`
+	URL                 string                 `bun:"url"`
+	Secret              crypto.EncryptedString `bun:"secret"`
+	EventTypes          []string               `bun:"event_types,array"`
+	Status              WebhookEndpointStatus  `bun:"status"`
+	ConsecutiveFailures int                    `bun:"consecutive_failures"`
+	DisabledAt          *time.Time             `bun:"disabled_at"`
+	CreatedAt           time.Time              `bun:"created_at"`
+	UpdatedAt           time.Time              `bun:"updated_at"`
+}
+
+// AfterScanRow decrypts the secret, the ID is only known once the row is scanned
+func (r *WebhookEndpointRecord) AfterScanRow(ctx context.Context) error {
+	return r.Secret.Bind(r.ID)
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryStatusFailed deliveries exhausted their attempts, they can be replayed
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDeliveryRecord the delivery of an event to an endpoint, with the outcome of its last attempt
+type WebhookDeliveryRecord struct {
+	bun.BaseModel  `bun:"table:webhook_deliveries,alias:wd"`
+	ID             string                `bun:"id,pk"`
+	EndpointID     string                `bun:"endpoint_id"`
+	EventID        string                `bun:"event_id"`
+	EventType      string                `bun:"event_type"`
+	Payload        json.RawMessage       `bun:"payload,type:jsonb"`
+	Status         WebhookDeliveryStatus `bun:"status"`
+	Attempts       int                   `bun:"attempts"`
+	ResponseStatus int                   `bun:"response_status"`
+	LastError      string                `bun:"last_error"`
+	CreatedAt      time.Time             `bun:"created_at"`
+	UpdatedAt      time.Time             `bun:"updated_at"`
+	DeliveredAt    *time.Time            `bun:"delivered_at"`
+}
@@ -3,15 +3,18 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/zeusito/toci/pkg/health"
 	"github.com/zeusito/toci/pkg/router"
 
 	"github.com/go-chi/chi/v5"
 )
 
-type HealthController struct{}
+type HealthController struct {
+	registry *health.Registry
+}
 
-func NewHealthController(mux *chi.Mux) *HealthController {
-	c := &HealthController{}
+func NewHealthController(mux *chi.Mux, registry *health.Registry) *HealthController {
+	c := &HealthController{registry: registry}
 
 	mux.Get("/health/readiness", c.handleReadiness)
 	mux.Get("/health/liveness", c.handleLiveness)
@@ -19,10 +22,18 @@ func NewHealthController(mux *chi.Mux) *HealthController {
 	return c
 }
 
+// handleReadiness reports the checks of the dependencies, it fails when a critical one does or once the shutdown began
 func (c *HealthController) handleReadiness(w http.ResponseWriter, req *http.Request) {
-	router.RenderJSON(req.Context(), w, http.StatusOK, router.SimpleSuccessResponseBody())
+	report := c.registry.Check(req.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	router.Render(w, req, status, report)
 }
 
 func (c *HealthController) handleLiveness(w http.ResponseWriter, req *http.Request) {
-	router.RenderJSON(req.Context(), w, http.StatusOK, router.SimpleSuccessResponseBody())
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
 }
@@ -1,22 +1,60 @@
 package signin
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/zeusito/toci/pkg/router"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/sessions"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+const (
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/v1/auth/oauth"
+	oauthStateCookieTTL  = 10 * time.Minute
+	// the token type of the sessions issued in cookies
+	sessionCookieTokenType = "Cookie"
+	// the source of the browser clients, the other clients always receive the token
+	sourceWeb = "web"
 )
 
 type Controller struct {
-	svc Service
+	svc     Service
+	cookies *security.SessionCookies
 }
 
-func NewController(mux *chi.Mux, svc Service) *Controller {
-	c := &Controller{svc: svc}
+func NewController(mux *chi.Mux, svc Service, sessionManager sessions.Manager, cookies *security.SessionCookies) *Controller {
+	c := &Controller{svc: svc, cookies: cookies}
 
 	mux.Post("/v1/auth/otp/login", c.handleLogin)
 	mux.Post("/v1/auth/otp/verify", c.handleVerifyOTP)
+	mux.Post("/v1/auth/password/login", c.handlePasswordLogin)
+	mux.Post("/v1/auth/password/forgot", c.handleForgotPassword)
+	mux.Post("/v1/auth/password/reset", c.handleResetPassword)
+	mux.Post("/v1/auth/oidc/nonce", c.handleOIDCNonce)
 	mux.Post("/v1/auth/oidc/callback", c.handleOIDCLogin)
+	mux.Get("/v1/auth/oauth/{provider}/start", c.handleOAuthStart)
+	mux.Get("/v1/auth/oauth/{provider}/callback", c.handleOAuthCallback)
+	// The providers configured with response_mode=form_post submit the callback as a form
+	mux.Post("/v1/auth/oauth/{provider}/callback", c.handleOAuthCallback)
+	mux.Post("/v1/auth/passkey/login/start", c.handlePasskeyLoginStart)
+	mux.Post("/v1/auth/passkey/login/finish", c.handlePasskeyLoginFinish)
+
+	// Managing the login methods requires an authenticated session
+	mux.Group(func(r chi.Router) {
+		r.Use(security.AuthenticationFilter(sessionManager))
+		r.Post("/v1/auth/passkey/register/start", c.handlePasskeyRegistrationStart)
+		r.Post("/v1/auth/passkey/register/finish", c.handlePasskeyRegistrationFinish)
+		r.Post("/v1/auth/password/change", c.handleChangePassword)
+		r.Post("/v1/auth/logout", c.handleLogout)
+		r.Get("/v1/auth/providers", c.handleListProviders)
+		r.Post("/v1/auth/providers", c.handleLinkProvider)
+		r.Delete("/v1/auth/providers/{id}", c.handleUnlinkProvider)
+	})
 
 	return c
 }
@@ -25,51 +63,335 @@ func (c *Controller) handleLogin(w http.ResponseWriter, req *http.Request) {
 	var body LoginWithEmailOTPRequest
 	err := router.BindBody(req, &body)
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
 		return
 	}
 
 	err = c.svc.SignInWithEmailOTP(req.Context(), body.Email, body.Source)
 
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
 		return
 	}
 
-	router.RenderJSON(req.Context(), w, http.StatusOK, router.SimpleSuccessResponseBody())
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
 }
 
 func (c *Controller) handleVerifyOTP(w http.ResponseWriter, req *http.Request) {
 	var body VerifyEmailOTPRequest
 	err := router.BindBody(req, &body)
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
 		return
 	}
 
 	resp, err := c.svc.VerifyEmailOTP(req.Context(), body.Code, body.Email)
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	c.renderSession(w, req, resp, body.Source)
+}
+
+func (c *Controller) handlePasswordLogin(w http.ResponseWriter, req *http.Request) {
+	var body PasswordLoginRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.SignInWithPassword(req.Context(), body.Email, body.Password, body.Source)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	c.renderSession(w, req, resp, body.Source)
+}
+
+func (c *Controller) handleForgotPassword(w http.ResponseWriter, req *http.Request) {
+	var body ForgotPasswordRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	err = c.svc.ForgotPassword(req.Context(), body.Email)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleResetPassword(w http.ResponseWriter, req *http.Request) {
+	var body ResetPasswordRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	err = c.svc.ResetPassword(req.Context(), body.Email, body.Code, body.Password)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleChangePassword(w http.ResponseWriter, req *http.Request) {
+	var body ChangePasswordRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
 		return
 	}
 
-	router.RenderJSON(req.Context(), w, http.StatusOK, resp)
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err = c.svc.ChangePassword(req.Context(), claims.PrincipalID, body.CurrentPassword, body.NewPassword)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleLogout(w http.ResponseWriter, req *http.Request) {
+	token, fromCookie := security.SessionToken(req)
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err := c.svc.SignOut(req.Context(), claims.PrincipalID, token)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	if fromCookie {
+		c.cookies.Clear(w)
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleOIDCNonce(w http.ResponseWriter, req *http.Request) {
+	var body OIDCNonceRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.IssueOpenIDNonce(req.Context(), body.Provider)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
 }
 
 func (c *Controller) handleOIDCLogin(w http.ResponseWriter, req *http.Request) {
 	var body OIDCLoginRequest
 	err := router.BindBody(req, &body)
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.SignInWithOpenID(req.Context(), body.Provider, body.Token, body.Nonce, body.Source)
+
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
 		return
 	}
 
-	resp, err := c.svc.SignInWithOpenID(req.Context(), body.Provider, body.Token, body.Source)
+	c.renderSession(w, req, resp, body.Source)
+}
 
+func (c *Controller) handleOAuthStart(w http.ResponseWriter, req *http.Request) {
+	resp, err := c.svc.StartOAuth(req.Context(), chi.URLParam(req, "provider"))
 	if err != nil {
-		router.RenderError(req.Context(), w, err)
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	// Bind the state to the user agent, the callback only accepts the state it was given. A form_post callback is
+	// a cross-site POST, the cookie must not be SameSite=Lax for the browser to send it along.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    resp.State,
+		Path:     oauthStateCookiePath,
+		MaxAge:   int(oauthStateCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+
+	http.Redirect(w, req, resp.AuthorizeURL, http.StatusFound)
+}
+
+func (c *Controller) handleOAuthCallback(w http.ResponseWriter, req *http.Request) {
+	var body OAuthCallbackRequest
+	if err := router.BindRequest(req, &body); err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	cookie, err := req.Cookie(oauthStateCookie)
+	if err != nil || body.State == "" || body.Code == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(body.State)) != 1 {
+		router.RenderErrorFor(w, req, terrors.UnAuthorized("credentials are invalid"))
+		return
+	}
+
+	// The state is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     oauthStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+
+	resp, err := c.svc.SignInWithOAuth(req.Context(), body.Provider, body.State, body.Code)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	// The flow is bound to the browser by the state cookie
+	c.renderSession(w, req, resp, sourceWeb)
+}
+
+func (c *Controller) handleListProviders(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.ListProviders(req.Context(), claims.PrincipalID)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handleLinkProvider(w http.ResponseWriter, req *http.Request) {
+	var body LinkProviderRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.LinkProvider(req.Context(), claims.PrincipalID, body.Provider, body.Token, body.Nonce)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handleUnlinkProvider(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err := c.svc.UnlinkProvider(req.Context(), claims.PrincipalID, chi.URLParam(req, "id"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handlePasskeyRegistrationStart(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.BeginPasskeyRegistration(req.Context(), claims.PrincipalID)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handlePasskeyRegistrationFinish(w http.ResponseWriter, req *http.Request) {
+	var body PasskeyRegistrationRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err = c.svc.FinishPasskeyRegistration(req.Context(), claims.PrincipalID, body.Credential)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handlePasskeyLoginStart(w http.ResponseWriter, req *http.Request) {
+	var body PasskeyLoginStartRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.BeginPasskeyLogin(req.Context(), body.Email)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handlePasskeyLoginFinish(w http.ResponseWriter, req *http.Request) {
+	var body PasskeyLoginFinishRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	resp, err := c.svc.FinishPasskeyLogin(req.Context(), body.Email, body.Credential)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	c.renderSession(w, req, resp, body.Source)
+}
+
+// renderSession the browser clients get the session token in a cookie, it is left out of the body so that scripts
+// never read it. The other clients, e.g. mobile apps, keep receiving it in the body.
+func (c *Controller) renderSession(w http.ResponseWriter, req *http.Request, resp *SignInResponse, source string) {
+	if !c.cookies.Enabled() || source != sourceWeb {
+		router.Render(w, req, http.StatusOK, resp)
+		return
+	}
+
+	if err := c.cookies.Set(w, resp.AccessToken, resp.ExpiresAt); err != nil {
+		router.RenderErrorFor(w, req, terrors.Unknown("failed to create the session"))
 		return
 	}
 
-	router.RenderJSON(req.Context(), w, http.StatusOK, resp)
+	router.Render(w, req, http.StatusOK, &SignInResponse{TokenType: sessionCookieTokenType, ExpiresAt: resp.ExpiresAt})
 }
@@ -4,12 +4,22 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/uptrace/bun"
 	"github.com/zeusito/toci/internal/actions"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/oauth"
+	"github.com/zeusito/toci/pkg/security/oidc"
 	"github.com/zeusito/toci/pkg/security/otp"
+	"github.com/zeusito/toci/pkg/security/passwords"
 	"github.com/zeusito/toci/pkg/security/sessions"
+	"github.com/zeusito/toci/pkg/security/webauthn"
 )
 
-func InitModule(mux *chi.Mux, db *bun.DB, optManager otp.Manager, sessionManager sessions.Manager, asyncActions actions.Service) {
-	repo := NewDefaultRepo(db)
-	svc := NewDefaultService(repo, optManager, sessionManager, asyncActions)
-	_ = NewController(mux, svc)
+func InitModule(mux *chi.Mux, db *bun.DB, optManager otp.Manager, sessionManager sessions.Manager, passkeyManager webauthn.Manager,
+	oidcVerifier oidc.Verifier, oauthManager oauth.Manager, passwordManager passwords.Manager, asyncActions actions.Service,
+	outbox events.Outbox, recorder audit.Recorder, cookies *security.SessionCookies) {
+	repo := NewDefaultRepo(db, outbox)
+	svc := NewDefaultService(repo, optManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager,
+		asyncActions, recorder)
+	_ = NewController(mux, svc, sessionManager, cookies)
 }
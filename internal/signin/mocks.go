@@ -6,9 +6,11 @@ package signin
 
 import (
 	"context"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/security/webauthn"
 )
 
 // NewMockRepo creates a new instance of MockRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -38,47 +40,1554 @@ func (_m *MockRepo) EXPECT() *MockRepo_Expecter {
 	return &MockRepo_Expecter{mock: &_m.Mock}
 }
 
+// CountPasskeys provides a mock function for the type MockRepo
+func (_mock *MockRepo) CountPasskeys(ctx context.Context, identityID string) (int, error) {
+	ret := _mock.Called(ctx, identityID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPasskeys")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, identityID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, identityID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, identityID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_CountPasskeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPasskeys'
+type MockRepo_CountPasskeys_Call struct {
+	*mock.Call
+}
+
+// CountPasskeys is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+func (_e *MockRepo_Expecter) CountPasskeys(ctx interface{}, identityID interface{}) *MockRepo_CountPasskeys_Call {
+	return &MockRepo_CountPasskeys_Call{Call: _e.mock.On("CountPasskeys", ctx, identityID)}
+}
+
+func (_c *MockRepo_CountPasskeys_Call) Run(run func(ctx context.Context, identityID string)) *MockRepo_CountPasskeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_CountPasskeys_Call) Return(n int, err error) *MockRepo_CountPasskeys_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockRepo_CountPasskeys_Call) RunAndReturn(run func(ctx context.Context, identityID string) (int, error)) *MockRepo_CountPasskeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateProviderLink provides a mock function for the type MockRepo
+func (_mock *MockRepo) CreateProviderLink(ctx context.Context, link *dbmodels.IdentityProviderRecord) error {
+	ret := _mock.Called(ctx, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateProviderLink")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dbmodels.IdentityProviderRecord) error); ok {
+		r0 = returnFunc(ctx, link)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepo_CreateProviderLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProviderLink'
+type MockRepo_CreateProviderLink_Call struct {
+	*mock.Call
+}
+
+// CreateProviderLink is a helper method to define mock.On call
+//   - ctx context.Context
+//   - link *dbmodels.IdentityProviderRecord
+func (_e *MockRepo_Expecter) CreateProviderLink(ctx interface{}, link interface{}) *MockRepo_CreateProviderLink_Call {
+	return &MockRepo_CreateProviderLink_Call{Call: _e.mock.On("CreateProviderLink", ctx, link)}
+}
+
+func (_c *MockRepo_CreateProviderLink_Call) Run(run func(ctx context.Context, link *dbmodels.IdentityProviderRecord)) *MockRepo_CreateProviderLink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *dbmodels.IdentityProviderRecord
+		if args[1] != nil {
+			arg1 = args[1].(*dbmodels.IdentityProviderRecord)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_CreateProviderLink_Call) Return(err error) *MockRepo_CreateProviderLink_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepo_CreateProviderLink_Call) RunAndReturn(run func(ctx context.Context, link *dbmodels.IdentityProviderRecord) error) *MockRepo_CreateProviderLink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWithProvider provides a mock function for the type MockRepo
+func (_mock *MockRepo) CreateWithProvider(ctx context.Context, record *dbmodels.IdentityRecord, link *dbmodels.IdentityProviderRecord) error {
+	ret := _mock.Called(ctx, record, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWithProvider")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dbmodels.IdentityRecord, *dbmodels.IdentityProviderRecord) error); ok {
+		r0 = returnFunc(ctx, record, link)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepo_CreateWithProvider_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWithProvider'
+type MockRepo_CreateWithProvider_Call struct {
+	*mock.Call
+}
+
+// CreateWithProvider is a helper method to define mock.On call
+//   - ctx context.Context
+//   - record *dbmodels.IdentityRecord
+//   - link *dbmodels.IdentityProviderRecord
+func (_e *MockRepo_Expecter) CreateWithProvider(ctx interface{}, record interface{}, link interface{}) *MockRepo_CreateWithProvider_Call {
+	return &MockRepo_CreateWithProvider_Call{Call: _e.mock.On("CreateWithProvider", ctx, record, link)}
+}
+
+func (_c *MockRepo_CreateWithProvider_Call) Run(run func(ctx context.Context, record *dbmodels.IdentityRecord, link *dbmodels.IdentityProviderRecord)) *MockRepo_CreateWithProvider_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *dbmodels.IdentityRecord
+		if args[1] != nil {
+			arg1 = args[1].(*dbmodels.IdentityRecord)
+		}
+		var arg2 *dbmodels.IdentityProviderRecord
+		if args[2] != nil {
+			arg2 = args[2].(*dbmodels.IdentityProviderRecord)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_CreateWithProvider_Call) Return(err error) *MockRepo_CreateWithProvider_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepo_CreateWithProvider_Call) RunAndReturn(run func(ctx context.Context, record *dbmodels.IdentityRecord, link *dbmodels.IdentityProviderRecord) error) *MockRepo_CreateWithProvider_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteProviderLink provides a mock function for the type MockRepo
+func (_mock *MockRepo) DeleteProviderLink(ctx context.Context, identityID string, linkID string) error {
+	ret := _mock.Called(ctx, identityID, linkID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteProviderLink")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, identityID, linkID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepo_DeleteProviderLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteProviderLink'
+type MockRepo_DeleteProviderLink_Call struct {
+	*mock.Call
+}
+
+// DeleteProviderLink is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+//   - linkID string
+func (_e *MockRepo_Expecter) DeleteProviderLink(ctx interface{}, identityID interface{}, linkID interface{}) *MockRepo_DeleteProviderLink_Call {
+	return &MockRepo_DeleteProviderLink_Call{Call: _e.mock.On("DeleteProviderLink", ctx, identityID, linkID)}
+}
+
+func (_c *MockRepo_DeleteProviderLink_Call) Run(run func(ctx context.Context, identityID string, linkID string)) *MockRepo_DeleteProviderLink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_DeleteProviderLink_Call) Return(err error) *MockRepo_DeleteProviderLink_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepo_DeleteProviderLink_Call) RunAndReturn(run func(ctx context.Context, identityID string, linkID string) error) *MockRepo_DeleteProviderLink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindOneByEmail provides a mock function for the type MockRepo
 func (_mock *MockRepo) FindOneByEmail(ctx context.Context, email string) (*dbmodels.IdentityRecord, error) {
 	ret := _mock.Called(ctx, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindOneByEmail")
+		panic("no return value specified for FindOneByEmail")
+	}
+
+	var r0 *dbmodels.IdentityRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*dbmodels.IdentityRecord, error)); ok {
+		return returnFunc(ctx, email)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *dbmodels.IdentityRecord); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dbmodels.IdentityRecord)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_FindOneByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindOneByEmail'
+type MockRepo_FindOneByEmail_Call struct {
+	*mock.Call
+}
+
+// FindOneByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *MockRepo_Expecter) FindOneByEmail(ctx interface{}, email interface{}) *MockRepo_FindOneByEmail_Call {
+	return &MockRepo_FindOneByEmail_Call{Call: _e.mock.On("FindOneByEmail", ctx, email)}
+}
+
+func (_c *MockRepo_FindOneByEmail_Call) Run(run func(ctx context.Context, email string)) *MockRepo_FindOneByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_FindOneByEmail_Call) Return(identityRecord *dbmodels.IdentityRecord, err error) *MockRepo_FindOneByEmail_Call {
+	_c.Call.Return(identityRecord, err)
+	return _c
+}
+
+func (_c *MockRepo_FindOneByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*dbmodels.IdentityRecord, error)) *MockRepo_FindOneByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindOneByID provides a mock function for the type MockRepo
+func (_mock *MockRepo) FindOneByID(ctx context.Context, id string) (*dbmodels.IdentityRecord, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOneByID")
+	}
+
+	var r0 *dbmodels.IdentityRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*dbmodels.IdentityRecord, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *dbmodels.IdentityRecord); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dbmodels.IdentityRecord)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_FindOneByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindOneByID'
+type MockRepo_FindOneByID_Call struct {
+	*mock.Call
+}
+
+// FindOneByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepo_Expecter) FindOneByID(ctx interface{}, id interface{}) *MockRepo_FindOneByID_Call {
+	return &MockRepo_FindOneByID_Call{Call: _e.mock.On("FindOneByID", ctx, id)}
+}
+
+func (_c *MockRepo_FindOneByID_Call) Run(run func(ctx context.Context, id string)) *MockRepo_FindOneByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_FindOneByID_Call) Return(identityRecord *dbmodels.IdentityRecord, err error) *MockRepo_FindOneByID_Call {
+	_c.Call.Return(identityRecord, err)
+	return _c
+}
+
+func (_c *MockRepo_FindOneByID_Call) RunAndReturn(run func(ctx context.Context, id string) (*dbmodels.IdentityRecord, error)) *MockRepo_FindOneByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindProviderLink provides a mock function for the type MockRepo
+func (_mock *MockRepo) FindProviderLink(ctx context.Context, provider string, subject string) (*dbmodels.IdentityProviderRecord, error) {
+	ret := _mock.Called(ctx, provider, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindProviderLink")
+	}
+
+	var r0 *dbmodels.IdentityProviderRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*dbmodels.IdentityProviderRecord, error)); ok {
+		return returnFunc(ctx, provider, subject)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *dbmodels.IdentityProviderRecord); ok {
+		r0 = returnFunc(ctx, provider, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dbmodels.IdentityProviderRecord)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, provider, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_FindProviderLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindProviderLink'
+type MockRepo_FindProviderLink_Call struct {
+	*mock.Call
+}
+
+// FindProviderLink is a helper method to define mock.On call
+//   - ctx context.Context
+//   - provider string
+//   - subject string
+func (_e *MockRepo_Expecter) FindProviderLink(ctx interface{}, provider interface{}, subject interface{}) *MockRepo_FindProviderLink_Call {
+	return &MockRepo_FindProviderLink_Call{Call: _e.mock.On("FindProviderLink", ctx, provider, subject)}
+}
+
+func (_c *MockRepo_FindProviderLink_Call) Run(run func(ctx context.Context, provider string, subject string)) *MockRepo_FindProviderLink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_FindProviderLink_Call) Return(identityProviderRecord *dbmodels.IdentityProviderRecord, err error) *MockRepo_FindProviderLink_Call {
+	_c.Call.Return(identityProviderRecord, err)
+	return _c
+}
+
+func (_c *MockRepo_FindProviderLink_Call) RunAndReturn(run func(ctx context.Context, provider string, subject string) (*dbmodels.IdentityProviderRecord, error)) *MockRepo_FindProviderLink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProviderLinks provides a mock function for the type MockRepo
+func (_mock *MockRepo) ListProviderLinks(ctx context.Context, identityID string) ([]dbmodels.IdentityProviderRecord, error) {
+	ret := _mock.Called(ctx, identityID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProviderLinks")
+	}
+
+	var r0 []dbmodels.IdentityProviderRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]dbmodels.IdentityProviderRecord, error)); ok {
+		return returnFunc(ctx, identityID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []dbmodels.IdentityProviderRecord); ok {
+		r0 = returnFunc(ctx, identityID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dbmodels.IdentityProviderRecord)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, identityID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_ListProviderLinks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProviderLinks'
+type MockRepo_ListProviderLinks_Call struct {
+	*mock.Call
+}
+
+// ListProviderLinks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+func (_e *MockRepo_Expecter) ListProviderLinks(ctx interface{}, identityID interface{}) *MockRepo_ListProviderLinks_Call {
+	return &MockRepo_ListProviderLinks_Call{Call: _e.mock.On("ListProviderLinks", ctx, identityID)}
+}
+
+func (_c *MockRepo_ListProviderLinks_Call) Run(run func(ctx context.Context, identityID string)) *MockRepo_ListProviderLinks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_ListProviderLinks_Call) Return(identityProviderRecords []dbmodels.IdentityProviderRecord, err error) *MockRepo_ListProviderLinks_Call {
+	_c.Call.Return(identityProviderRecords, err)
+	return _c
+}
+
+func (_c *MockRepo_ListProviderLinks_Call) RunAndReturn(run func(ctx context.Context, identityID string) ([]dbmodels.IdentityProviderRecord, error)) *MockRepo_ListProviderLinks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkEmailVerified provides a mock function for the type MockRepo
+func (_mock *MockRepo) MarkEmailVerified(ctx context.Context, identityID string, verifiedAt time.Time) error {
+	ret := _mock.Called(ctx, identityID, verifiedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkEmailVerified")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = returnFunc(ctx, identityID, verifiedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepo_MarkEmailVerified_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkEmailVerified'
+type MockRepo_MarkEmailVerified_Call struct {
+	*mock.Call
+}
+
+// MarkEmailVerified is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+//   - verifiedAt time.Time
+func (_e *MockRepo_Expecter) MarkEmailVerified(ctx interface{}, identityID interface{}, verifiedAt interface{}) *MockRepo_MarkEmailVerified_Call {
+	return &MockRepo_MarkEmailVerified_Call{Call: _e.mock.On("MarkEmailVerified", ctx, identityID, verifiedAt)}
+}
+
+func (_c *MockRepo_MarkEmailVerified_Call) Run(run func(ctx context.Context, identityID string, verifiedAt time.Time)) *MockRepo_MarkEmailVerified_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_MarkEmailVerified_Call) Return(err error) *MockRepo_MarkEmailVerified_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepo_MarkEmailVerified_Call) RunAndReturn(run func(ctx context.Context, identityID string, verifiedAt time.Time) error) *MockRepo_MarkEmailVerified_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordFailedLogin provides a mock function for the type MockRepo
+func (_mock *MockRepo) RecordFailedLogin(ctx context.Context, identityID string, maxAttempts int, lockFor time.Duration) (bool, error) {
+	ret := _mock.Called(ctx, identityID, maxAttempts, lockFor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordFailedLogin")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, time.Duration) (bool, error)); ok {
+		return returnFunc(ctx, identityID, maxAttempts, lockFor)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, time.Duration) bool); ok {
+		r0 = returnFunc(ctx, identityID, maxAttempts, lockFor)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, time.Duration) error); ok {
+		r1 = returnFunc(ctx, identityID, maxAttempts, lockFor)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepo_RecordFailedLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordFailedLogin'
+type MockRepo_RecordFailedLogin_Call struct {
+	*mock.Call
+}
+
+// RecordFailedLogin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+//   - maxAttempts int
+//   - lockFor time.Duration
+func (_e *MockRepo_Expecter) RecordFailedLogin(ctx interface{}, identityID interface{}, maxAttempts interface{}, lockFor interface{}) *MockRepo_RecordFailedLogin_Call {
+	return &MockRepo_RecordFailedLogin_Call{Call: _e.mock.On("RecordFailedLogin", ctx, identityID, maxAttempts, lockFor)}
+}
+
+func (_c *MockRepo_RecordFailedLogin_Call) Run(run func(ctx context.Context, identityID string, maxAttempts int, lockFor time.Duration)) *MockRepo_RecordFailedLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_RecordFailedLogin_Call) Return(b bool, err error) *MockRepo_RecordFailedLogin_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockRepo_RecordFailedLogin_Call) RunAndReturn(run func(ctx context.Context, identityID string, maxAttempts int, lockFor time.Duration) (bool, error)) *MockRepo_RecordFailedLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetFailedLogins provides a mock function for the type MockRepo
+func (_mock *MockRepo) ResetFailedLogins(ctx context.Context, identityID string) error {
+	ret := _mock.Called(ctx, identityID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetFailedLogins")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, identityID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepo_ResetFailedLogins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetFailedLogins'
+type MockRepo_ResetFailedLogins_Call struct {
+	*mock.Call
+}
+
+// ResetFailedLogins is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identityID string
+func (_e *MockRepo_Expecter) ResetFailedLogins(ctx interface{}, identityID interface{}) *MockRepo_ResetFailedLogins_Call {
+	return &MockRepo_ResetFailedLogins_Call{Call: _e.mock.On("ResetFailedLogins", ctx, identityID)}
+}
+
+func (_c *MockRepo_ResetFailedLogins_Call) Run(run func(ctx context.Context, identityID string)) *MockRepo_ResetFailedLogins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepo_ResetFailedLogins_Call) Return(err error) *MockRepo_ResetFailedLogins_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepo_ResetFailedLogins_Call) RunAndReturn(run func(ctx context.Context, identityID string) error) *MockRepo_ResetFailedLogins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockService {
+	mock := &MockService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockService is an autogenerated mock type for the Service type
+type MockService struct {
+	mock.Mock
+}
+
+type MockService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockService) EXPECT() *MockService_Expecter {
+	return &MockService_Expecter{mock: &_m.Mock}
+}
+
+// BeginPasskeyLogin provides a mock function for the type MockService
+func (_mock *MockService) BeginPasskeyLogin(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginPasskeyLogin")
+	}
+
+	var r0 *webauthn.CredentialRequestOptions
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*webauthn.CredentialRequestOptions, error)); ok {
+		return returnFunc(ctx, email)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *webauthn.CredentialRequestOptions); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.CredentialRequestOptions)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_BeginPasskeyLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BeginPasskeyLogin'
+type MockService_BeginPasskeyLogin_Call struct {
+	*mock.Call
+}
+
+// BeginPasskeyLogin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *MockService_Expecter) BeginPasskeyLogin(ctx interface{}, email interface{}) *MockService_BeginPasskeyLogin_Call {
+	return &MockService_BeginPasskeyLogin_Call{Call: _e.mock.On("BeginPasskeyLogin", ctx, email)}
+}
+
+func (_c *MockService_BeginPasskeyLogin_Call) Run(run func(ctx context.Context, email string)) *MockService_BeginPasskeyLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_BeginPasskeyLogin_Call) Return(credentialRequestOptions *webauthn.CredentialRequestOptions, err error) *MockService_BeginPasskeyLogin_Call {
+	_c.Call.Return(credentialRequestOptions, err)
+	return _c
+}
+
+func (_c *MockService_BeginPasskeyLogin_Call) RunAndReturn(run func(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error)) *MockService_BeginPasskeyLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BeginPasskeyRegistration provides a mock function for the type MockService
+func (_mock *MockService) BeginPasskeyRegistration(ctx context.Context, principalID string) (*webauthn.CredentialCreationOptions, error) {
+	ret := _mock.Called(ctx, principalID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginPasskeyRegistration")
+	}
+
+	var r0 *webauthn.CredentialCreationOptions
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*webauthn.CredentialCreationOptions, error)); ok {
+		return returnFunc(ctx, principalID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *webauthn.CredentialCreationOptions); ok {
+		r0 = returnFunc(ctx, principalID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.CredentialCreationOptions)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, principalID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_BeginPasskeyRegistration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BeginPasskeyRegistration'
+type MockService_BeginPasskeyRegistration_Call struct {
+	*mock.Call
+}
+
+// BeginPasskeyRegistration is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+func (_e *MockService_Expecter) BeginPasskeyRegistration(ctx interface{}, principalID interface{}) *MockService_BeginPasskeyRegistration_Call {
+	return &MockService_BeginPasskeyRegistration_Call{Call: _e.mock.On("BeginPasskeyRegistration", ctx, principalID)}
+}
+
+func (_c *MockService_BeginPasskeyRegistration_Call) Run(run func(ctx context.Context, principalID string)) *MockService_BeginPasskeyRegistration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_BeginPasskeyRegistration_Call) Return(credentialCreationOptions *webauthn.CredentialCreationOptions, err error) *MockService_BeginPasskeyRegistration_Call {
+	_c.Call.Return(credentialCreationOptions, err)
+	return _c
+}
+
+func (_c *MockService_BeginPasskeyRegistration_Call) RunAndReturn(run func(ctx context.Context, principalID string) (*webauthn.CredentialCreationOptions, error)) *MockService_BeginPasskeyRegistration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ChangePassword provides a mock function for the type MockService
+func (_mock *MockService) ChangePassword(ctx context.Context, principalID string, currentPassword string, newPassword string) error {
+	ret := _mock.Called(ctx, principalID, currentPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = returnFunc(ctx, principalID, currentPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_ChangePassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChangePassword'
+type MockService_ChangePassword_Call struct {
+	*mock.Call
+}
+
+// ChangePassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+//   - currentPassword string
+//   - newPassword string
+func (_e *MockService_Expecter) ChangePassword(ctx interface{}, principalID interface{}, currentPassword interface{}, newPassword interface{}) *MockService_ChangePassword_Call {
+	return &MockService_ChangePassword_Call{Call: _e.mock.On("ChangePassword", ctx, principalID, currentPassword, newPassword)}
+}
+
+func (_c *MockService_ChangePassword_Call) Run(run func(ctx context.Context, principalID string, currentPassword string, newPassword string)) *MockService_ChangePassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_ChangePassword_Call) Return(err error) *MockService_ChangePassword_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockService_ChangePassword_Call) RunAndReturn(run func(ctx context.Context, principalID string, currentPassword string, newPassword string) error) *MockService_ChangePassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FinishPasskeyLogin provides a mock function for the type MockService
+func (_mock *MockService) FinishPasskeyLogin(ctx context.Context, email string, credential webauthn.AssertionResponse) (*SignInResponse, error) {
+	ret := _mock.Called(ctx, email, credential)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishPasskeyLogin")
+	}
+
+	var r0 *SignInResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, webauthn.AssertionResponse) (*SignInResponse, error)); ok {
+		return returnFunc(ctx, email, credential)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, webauthn.AssertionResponse) *SignInResponse); ok {
+		r0 = returnFunc(ctx, email, credential)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*SignInResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, webauthn.AssertionResponse) error); ok {
+		r1 = returnFunc(ctx, email, credential)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_FinishPasskeyLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishPasskeyLogin'
+type MockService_FinishPasskeyLogin_Call struct {
+	*mock.Call
+}
+
+// FinishPasskeyLogin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - credential webauthn.AssertionResponse
+func (_e *MockService_Expecter) FinishPasskeyLogin(ctx interface{}, email interface{}, credential interface{}) *MockService_FinishPasskeyLogin_Call {
+	return &MockService_FinishPasskeyLogin_Call{Call: _e.mock.On("FinishPasskeyLogin", ctx, email, credential)}
+}
+
+func (_c *MockService_FinishPasskeyLogin_Call) Run(run func(ctx context.Context, email string, credential webauthn.AssertionResponse)) *MockService_FinishPasskeyLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 webauthn.AssertionResponse
+		if args[2] != nil {
+			arg2 = args[2].(webauthn.AssertionResponse)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_FinishPasskeyLogin_Call) Return(signInResponse *SignInResponse, err error) *MockService_FinishPasskeyLogin_Call {
+	_c.Call.Return(signInResponse, err)
+	return _c
+}
+
+func (_c *MockService_FinishPasskeyLogin_Call) RunAndReturn(run func(ctx context.Context, email string, credential webauthn.AssertionResponse) (*SignInResponse, error)) *MockService_FinishPasskeyLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FinishPasskeyRegistration provides a mock function for the type MockService
+func (_mock *MockService) FinishPasskeyRegistration(ctx context.Context, principalID string, credential webauthn.RegistrationResponse) error {
+	ret := _mock.Called(ctx, principalID, credential)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishPasskeyRegistration")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, webauthn.RegistrationResponse) error); ok {
+		r0 = returnFunc(ctx, principalID, credential)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_FinishPasskeyRegistration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishPasskeyRegistration'
+type MockService_FinishPasskeyRegistration_Call struct {
+	*mock.Call
+}
+
+// FinishPasskeyRegistration is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+//   - credential webauthn.RegistrationResponse
+func (_e *MockService_Expecter) FinishPasskeyRegistration(ctx interface{}, principalID interface{}, credential interface{}) *MockService_FinishPasskeyRegistration_Call {
+	return &MockService_FinishPasskeyRegistration_Call{Call: _e.mock.On("FinishPasskeyRegistration", ctx, principalID, credential)}
+}
+
+func (_c *MockService_FinishPasskeyRegistration_Call) Run(run func(ctx context.Context, principalID string, credential webauthn.RegistrationResponse)) *MockService_FinishPasskeyRegistration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 webauthn.RegistrationResponse
+		if args[2] != nil {
+			arg2 = args[2].(webauthn.RegistrationResponse)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_FinishPasskeyRegistration_Call) Return(err error) *MockService_FinishPasskeyRegistration_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockService_FinishPasskeyRegistration_Call) RunAndReturn(run func(ctx context.Context, principalID string, credential webauthn.RegistrationResponse) error) *MockService_FinishPasskeyRegistration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ForgotPassword provides a mock function for the type MockService
+func (_mock *MockService) ForgotPassword(ctx context.Context, email string) error {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForgotPassword")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_ForgotPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForgotPassword'
+type MockService_ForgotPassword_Call struct {
+	*mock.Call
+}
+
+// ForgotPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *MockService_Expecter) ForgotPassword(ctx interface{}, email interface{}) *MockService_ForgotPassword_Call {
+	return &MockService_ForgotPassword_Call{Call: _e.mock.On("ForgotPassword", ctx, email)}
+}
+
+func (_c *MockService_ForgotPassword_Call) Run(run func(ctx context.Context, email string)) *MockService_ForgotPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_ForgotPassword_Call) Return(err error) *MockService_ForgotPassword_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockService_ForgotPassword_Call) RunAndReturn(run func(ctx context.Context, email string) error) *MockService_ForgotPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IssueOpenIDNonce provides a mock function for the type MockService
+func (_mock *MockService) IssueOpenIDNonce(ctx context.Context, provider string) (*OIDCNonceResponse, error) {
+	ret := _mock.Called(ctx, provider)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueOpenIDNonce")
+	}
+
+	var r0 *OIDCNonceResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*OIDCNonceResponse, error)); ok {
+		return returnFunc(ctx, provider)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *OIDCNonceResponse); ok {
+		r0 = returnFunc(ctx, provider)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*OIDCNonceResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, provider)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_IssueOpenIDNonce_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueOpenIDNonce'
+type MockService_IssueOpenIDNonce_Call struct {
+	*mock.Call
+}
+
+// IssueOpenIDNonce is a helper method to define mock.On call
+//   - ctx context.Context
+//   - provider string
+func (_e *MockService_Expecter) IssueOpenIDNonce(ctx interface{}, provider interface{}) *MockService_IssueOpenIDNonce_Call {
+	return &MockService_IssueOpenIDNonce_Call{Call: _e.mock.On("IssueOpenIDNonce", ctx, provider)}
+}
+
+func (_c *MockService_IssueOpenIDNonce_Call) Run(run func(ctx context.Context, provider string)) *MockService_IssueOpenIDNonce_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_IssueOpenIDNonce_Call) Return(oIDCNonceResponse *OIDCNonceResponse, err error) *MockService_IssueOpenIDNonce_Call {
+	_c.Call.Return(oIDCNonceResponse, err)
+	return _c
+}
+
+func (_c *MockService_IssueOpenIDNonce_Call) RunAndReturn(run func(ctx context.Context, provider string) (*OIDCNonceResponse, error)) *MockService_IssueOpenIDNonce_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LinkProvider provides a mock function for the type MockService
+func (_mock *MockService) LinkProvider(ctx context.Context, principalID string, provider string, token string, nonce string) (*LinkedProviderResponse, error) {
+	ret := _mock.Called(ctx, principalID, provider, token, nonce)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkProvider")
+	}
+
+	var r0 *LinkedProviderResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*LinkedProviderResponse, error)); ok {
+		return returnFunc(ctx, principalID, provider, token, nonce)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) *LinkedProviderResponse); ok {
+		r0 = returnFunc(ctx, principalID, provider, token, nonce)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*LinkedProviderResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = returnFunc(ctx, principalID, provider, token, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_LinkProvider_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LinkProvider'
+type MockService_LinkProvider_Call struct {
+	*mock.Call
+}
+
+// LinkProvider is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+//   - provider string
+//   - token string
+//   - nonce string
+func (_e *MockService_Expecter) LinkProvider(ctx interface{}, principalID interface{}, provider interface{}, token interface{}, nonce interface{}) *MockService_LinkProvider_Call {
+	return &MockService_LinkProvider_Call{Call: _e.mock.On("LinkProvider", ctx, principalID, provider, token, nonce)}
+}
+
+func (_c *MockService_LinkProvider_Call) Run(run func(ctx context.Context, principalID string, provider string, token string, nonce string)) *MockService_LinkProvider_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_LinkProvider_Call) Return(linkedProviderResponse *LinkedProviderResponse, err error) *MockService_LinkProvider_Call {
+	_c.Call.Return(linkedProviderResponse, err)
+	return _c
+}
+
+func (_c *MockService_LinkProvider_Call) RunAndReturn(run func(ctx context.Context, principalID string, provider string, token string, nonce string) (*LinkedProviderResponse, error)) *MockService_LinkProvider_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProviders provides a mock function for the type MockService
+func (_mock *MockService) ListProviders(ctx context.Context, principalID string) ([]LinkedProviderResponse, error) {
+	ret := _mock.Called(ctx, principalID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProviders")
+	}
+
+	var r0 []LinkedProviderResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]LinkedProviderResponse, error)); ok {
+		return returnFunc(ctx, principalID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []LinkedProviderResponse); ok {
+		r0 = returnFunc(ctx, principalID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]LinkedProviderResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, principalID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_ListProviders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProviders'
+type MockService_ListProviders_Call struct {
+	*mock.Call
+}
+
+// ListProviders is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+func (_e *MockService_Expecter) ListProviders(ctx interface{}, principalID interface{}) *MockService_ListProviders_Call {
+	return &MockService_ListProviders_Call{Call: _e.mock.On("ListProviders", ctx, principalID)}
+}
+
+func (_c *MockService_ListProviders_Call) Run(run func(ctx context.Context, principalID string)) *MockService_ListProviders_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_ListProviders_Call) Return(linkedProviderResponses []LinkedProviderResponse, err error) *MockService_ListProviders_Call {
+	_c.Call.Return(linkedProviderResponses, err)
+	return _c
+}
+
+func (_c *MockService_ListProviders_Call) RunAndReturn(run func(ctx context.Context, principalID string) ([]LinkedProviderResponse, error)) *MockService_ListProviders_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetPassword provides a mock function for the type MockService
+func (_mock *MockService) ResetPassword(ctx context.Context, email string, code string, password string) error {
+	ret := _mock.Called(ctx, email, code, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = returnFunc(ctx, email, code, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_ResetPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetPassword'
+type MockService_ResetPassword_Call struct {
+	*mock.Call
+}
+
+// ResetPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - code string
+//   - password string
+func (_e *MockService_Expecter) ResetPassword(ctx interface{}, email interface{}, code interface{}, password interface{}) *MockService_ResetPassword_Call {
+	return &MockService_ResetPassword_Call{Call: _e.mock.On("ResetPassword", ctx, email, code, password)}
+}
+
+func (_c *MockService_ResetPassword_Call) Run(run func(ctx context.Context, email string, code string, password string)) *MockService_ResetPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_ResetPassword_Call) Return(err error) *MockService_ResetPassword_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockService_ResetPassword_Call) RunAndReturn(run func(ctx context.Context, email string, code string, password string) error) *MockService_ResetPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SignInWithEmailOTP provides a mock function for the type MockService
+func (_mock *MockService) SignInWithEmailOTP(ctx context.Context, email string, source string) error {
+	ret := _mock.Called(ctx, email, source)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignInWithEmailOTP")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, email, source)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_SignInWithEmailOTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithEmailOTP'
+type MockService_SignInWithEmailOTP_Call struct {
+	*mock.Call
+}
+
+// SignInWithEmailOTP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - source string
+func (_e *MockService_Expecter) SignInWithEmailOTP(ctx interface{}, email interface{}, source interface{}) *MockService_SignInWithEmailOTP_Call {
+	return &MockService_SignInWithEmailOTP_Call{Call: _e.mock.On("SignInWithEmailOTP", ctx, email, source)}
+}
+
+func (_c *MockService_SignInWithEmailOTP_Call) Run(run func(ctx context.Context, email string, source string)) *MockService_SignInWithEmailOTP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_SignInWithEmailOTP_Call) Return(err error) *MockService_SignInWithEmailOTP_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockService_SignInWithEmailOTP_Call) RunAndReturn(run func(ctx context.Context, email string, source string) error) *MockService_SignInWithEmailOTP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SignInWithOAuth provides a mock function for the type MockService
+func (_mock *MockService) SignInWithOAuth(ctx context.Context, provider string, state string, code string) (*SignInResponse, error) {
+	ret := _mock.Called(ctx, provider, state, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignInWithOAuth")
 	}
 
-	var r0 *dbmodels.IdentityRecord
+	var r0 *SignInResponse
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*dbmodels.IdentityRecord, error)); ok {
-		return returnFunc(ctx, email)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (*SignInResponse, error)); ok {
+		return returnFunc(ctx, provider, state, code)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *dbmodels.IdentityRecord); ok {
-		r0 = returnFunc(ctx, email)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) *SignInResponse); ok {
+		r0 = returnFunc(ctx, provider, state, code)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*dbmodels.IdentityRecord)
+			r0 = ret.Get(0).(*SignInResponse)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, email)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, provider, state, code)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockRepo_FindOneByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindOneByEmail'
-type MockRepo_FindOneByEmail_Call struct {
+// MockService_SignInWithOAuth_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithOAuth'
+type MockService_SignInWithOAuth_Call struct {
 	*mock.Call
 }
 
-// FindOneByEmail is a helper method to define mock.On call
+// SignInWithOAuth is a helper method to define mock.On call
 //   - ctx context.Context
-//   - email string
-func (_e *MockRepo_Expecter) FindOneByEmail(ctx interface{}, email interface{}) *MockRepo_FindOneByEmail_Call {
-	return &MockRepo_FindOneByEmail_Call{Call: _e.mock.On("FindOneByEmail", ctx, email)}
+//   - provider string
+//   - state string
+//   - code string
+func (_e *MockService_Expecter) SignInWithOAuth(ctx interface{}, provider interface{}, state interface{}, code interface{}) *MockService_SignInWithOAuth_Call {
+	return &MockService_SignInWithOAuth_Call{Call: _e.mock.On("SignInWithOAuth", ctx, provider, state, code)}
 }
 
-func (_c *MockRepo_FindOneByEmail_Call) Run(run func(ctx context.Context, email string)) *MockRepo_FindOneByEmail_Call {
+func (_c *MockService_SignInWithOAuth_Call) Run(run func(ctx context.Context, provider string, state string, code string)) *MockService_SignInWithOAuth_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -88,82 +1597,231 @@ func (_c *MockRepo_FindOneByEmail_Call) Run(run func(ctx context.Context, email
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockRepo_FindOneByEmail_Call) Return(identityRecord *dbmodels.IdentityRecord, err error) *MockRepo_FindOneByEmail_Call {
-	_c.Call.Return(identityRecord, err)
+func (_c *MockService_SignInWithOAuth_Call) Return(signInResponse *SignInResponse, err error) *MockService_SignInWithOAuth_Call {
+	_c.Call.Return(signInResponse, err)
 	return _c
 }
 
-func (_c *MockRepo_FindOneByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*dbmodels.IdentityRecord, error)) *MockRepo_FindOneByEmail_Call {
+func (_c *MockService_SignInWithOAuth_Call) RunAndReturn(run func(ctx context.Context, provider string, state string, code string) (*SignInResponse, error)) *MockService_SignInWithOAuth_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockService(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockService {
-	mock := &MockService{}
-	mock.Mock.Test(t)
+// SignInWithOpenID provides a mock function for the type MockService
+func (_mock *MockService) SignInWithOpenID(ctx context.Context, provider string, token string, nonce string, source string) (*SignInResponse, error) {
+	ret := _mock.Called(ctx, provider, token, nonce, source)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	if len(ret) == 0 {
+		panic("no return value specified for SignInWithOpenID")
+	}
 
-	return mock
+	var r0 *SignInResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*SignInResponse, error)); ok {
+		return returnFunc(ctx, provider, token, nonce, source)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) *SignInResponse); ok {
+		r0 = returnFunc(ctx, provider, token, nonce, source)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*SignInResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = returnFunc(ctx, provider, token, nonce, source)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockService is an autogenerated mock type for the Service type
-type MockService struct {
-	mock.Mock
+// MockService_SignInWithOpenID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithOpenID'
+type MockService_SignInWithOpenID_Call struct {
+	*mock.Call
 }
 
-type MockService_Expecter struct {
-	mock *mock.Mock
+// SignInWithOpenID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - provider string
+//   - token string
+//   - nonce string
+//   - source string
+func (_e *MockService_Expecter) SignInWithOpenID(ctx interface{}, provider interface{}, token interface{}, nonce interface{}, source interface{}) *MockService_SignInWithOpenID_Call {
+	return &MockService_SignInWithOpenID_Call{Call: _e.mock.On("SignInWithOpenID", ctx, provider, token, nonce, source)}
 }
 
-func (_m *MockService) EXPECT() *MockService_Expecter {
-	return &MockService_Expecter{mock: &_m.Mock}
+func (_c *MockService_SignInWithOpenID_Call) Run(run func(ctx context.Context, provider string, token string, nonce string, source string)) *MockService_SignInWithOpenID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
 }
 
-// SignInWithEmailOTP provides a mock function for the type MockService
-func (_mock *MockService) SignInWithEmailOTP(ctx context.Context, email string, source string) error {
-	ret := _mock.Called(ctx, email, source)
+func (_c *MockService_SignInWithOpenID_Call) Return(signInResponse *SignInResponse, err error) *MockService_SignInWithOpenID_Call {
+	_c.Call.Return(signInResponse, err)
+	return _c
+}
+
+func (_c *MockService_SignInWithOpenID_Call) RunAndReturn(run func(ctx context.Context, provider string, token string, nonce string, source string) (*SignInResponse, error)) *MockService_SignInWithOpenID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SignInWithPassword provides a mock function for the type MockService
+func (_mock *MockService) SignInWithPassword(ctx context.Context, email string, password string, source string) (*SignInResponse, error) {
+	ret := _mock.Called(ctx, email, password, source)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SignInWithEmailOTP")
+		panic("no return value specified for SignInWithPassword")
+	}
+
+	var r0 *SignInResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (*SignInResponse, error)); ok {
+		return returnFunc(ctx, email, password, source)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) *SignInResponse); ok {
+		r0 = returnFunc(ctx, email, password, source)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*SignInResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, email, password, source)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockService_SignInWithPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithPassword'
+type MockService_SignInWithPassword_Call struct {
+	*mock.Call
+}
+
+// SignInWithPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - password string
+//   - source string
+func (_e *MockService_Expecter) SignInWithPassword(ctx interface{}, email interface{}, password interface{}, source interface{}) *MockService_SignInWithPassword_Call {
+	return &MockService_SignInWithPassword_Call{Call: _e.mock.On("SignInWithPassword", ctx, email, password, source)}
+}
+
+func (_c *MockService_SignInWithPassword_Call) Run(run func(ctx context.Context, email string, password string, source string)) *MockService_SignInWithPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_SignInWithPassword_Call) Return(signInResponse *SignInResponse, err error) *MockService_SignInWithPassword_Call {
+	_c.Call.Return(signInResponse, err)
+	return _c
+}
+
+func (_c *MockService_SignInWithPassword_Call) RunAndReturn(run func(ctx context.Context, email string, password string, source string) (*SignInResponse, error)) *MockService_SignInWithPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SignOut provides a mock function for the type MockService
+func (_mock *MockService) SignOut(ctx context.Context, principalID string, token string) error {
+	ret := _mock.Called(ctx, principalID, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignOut")
 	}
 
 	var r0 error
 	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = returnFunc(ctx, email, source)
+		r0 = returnFunc(ctx, principalID, token)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockService_SignInWithEmailOTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithEmailOTP'
-type MockService_SignInWithEmailOTP_Call struct {
+// MockService_SignOut_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignOut'
+type MockService_SignOut_Call struct {
 	*mock.Call
 }
 
-// SignInWithEmailOTP is a helper method to define mock.On call
+// SignOut is a helper method to define mock.On call
 //   - ctx context.Context
-//   - email string
-//   - source string
-func (_e *MockService_Expecter) SignInWithEmailOTP(ctx interface{}, email interface{}, source interface{}) *MockService_SignInWithEmailOTP_Call {
-	return &MockService_SignInWithEmailOTP_Call{Call: _e.mock.On("SignInWithEmailOTP", ctx, email, source)}
+//   - principalID string
+//   - token string
+func (_e *MockService_Expecter) SignOut(ctx interface{}, principalID interface{}, token interface{}) *MockService_SignOut_Call {
+	return &MockService_SignOut_Call{Call: _e.mock.On("SignOut", ctx, principalID, token)}
 }
 
-func (_c *MockService_SignInWithEmailOTP_Call) Run(run func(ctx context.Context, email string, source string)) *MockService_SignInWithEmailOTP_Call {
+func (_c *MockService_SignOut_Call) Run(run func(ctx context.Context, principalID string, token string)) *MockService_SignOut_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -186,59 +1844,115 @@ func (_c *MockService_SignInWithEmailOTP_Call) Run(run func(ctx context.Context,
 	return _c
 }
 
-func (_c *MockService_SignInWithEmailOTP_Call) Return(err error) *MockService_SignInWithEmailOTP_Call {
+func (_c *MockService_SignOut_Call) Return(err error) *MockService_SignOut_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockService_SignInWithEmailOTP_Call) RunAndReturn(run func(ctx context.Context, email string, source string) error) *MockService_SignInWithEmailOTP_Call {
+func (_c *MockService_SignOut_Call) RunAndReturn(run func(ctx context.Context, principalID string, token string) error) *MockService_SignOut_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SignInWithOpenID provides a mock function for the type MockService
-func (_mock *MockService) SignInWithOpenID(ctx context.Context, provider string, token string, source string) (*SignInResponse, error) {
-	ret := _mock.Called(ctx, provider, token, source)
+// StartOAuth provides a mock function for the type MockService
+func (_mock *MockService) StartOAuth(ctx context.Context, provider string) (*OAuthStartResponse, error) {
+	ret := _mock.Called(ctx, provider)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SignInWithOpenID")
+		panic("no return value specified for StartOAuth")
 	}
 
-	var r0 *SignInResponse
+	var r0 *OAuthStartResponse
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (*SignInResponse, error)); ok {
-		return returnFunc(ctx, provider, token, source)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*OAuthStartResponse, error)); ok {
+		return returnFunc(ctx, provider)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) *SignInResponse); ok {
-		r0 = returnFunc(ctx, provider, token, source)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *OAuthStartResponse); ok {
+		r0 = returnFunc(ctx, provider)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*SignInResponse)
+			r0 = ret.Get(0).(*OAuthStartResponse)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = returnFunc(ctx, provider, token, source)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, provider)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockService_SignInWithOpenID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignInWithOpenID'
-type MockService_SignInWithOpenID_Call struct {
+// MockService_StartOAuth_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartOAuth'
+type MockService_StartOAuth_Call struct {
 	*mock.Call
 }
 
-// SignInWithOpenID is a helper method to define mock.On call
+// StartOAuth is a helper method to define mock.On call
 //   - ctx context.Context
 //   - provider string
-//   - token string
-//   - source string
-func (_e *MockService_Expecter) SignInWithOpenID(ctx interface{}, provider interface{}, token interface{}, source interface{}) *MockService_SignInWithOpenID_Call {
-	return &MockService_SignInWithOpenID_Call{Call: _e.mock.On("SignInWithOpenID", ctx, provider, token, source)}
+func (_e *MockService_Expecter) StartOAuth(ctx interface{}, provider interface{}) *MockService_StartOAuth_Call {
+	return &MockService_StartOAuth_Call{Call: _e.mock.On("StartOAuth", ctx, provider)}
+}
+
+func (_c *MockService_StartOAuth_Call) Run(run func(ctx context.Context, provider string)) *MockService_StartOAuth_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockService_StartOAuth_Call) Return(oAuthStartResponse *OAuthStartResponse, err error) *MockService_StartOAuth_Call {
+	_c.Call.Return(oAuthStartResponse, err)
+	return _c
+}
+
+func (_c *MockService_StartOAuth_Call) RunAndReturn(run func(ctx context.Context, provider string) (*OAuthStartResponse, error)) *MockService_StartOAuth_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UnlinkProvider provides a mock function for the type MockService
+func (_mock *MockService) UnlinkProvider(ctx context.Context, principalID string, linkID string) error {
+	ret := _mock.Called(ctx, principalID, linkID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlinkProvider")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, principalID, linkID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockService_UnlinkProvider_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnlinkProvider'
+type MockService_UnlinkProvider_Call struct {
+	*mock.Call
+}
+
+// UnlinkProvider is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+//   - linkID string
+func (_e *MockService_Expecter) UnlinkProvider(ctx interface{}, principalID interface{}, linkID interface{}) *MockService_UnlinkProvider_Call {
+	return &MockService_UnlinkProvider_Call{Call: _e.mock.On("UnlinkProvider", ctx, principalID, linkID)}
 }
 
-func (_c *MockService_SignInWithOpenID_Call) Run(run func(ctx context.Context, provider string, token string, source string)) *MockService_SignInWithOpenID_Call {
+func (_c *MockService_UnlinkProvider_Call) Run(run func(ctx context.Context, principalID string, linkID string)) *MockService_UnlinkProvider_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -252,26 +1966,21 @@ func (_c *MockService_SignInWithOpenID_Call) Run(run func(ctx context.Context, p
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
-		var arg3 string
-		if args[3] != nil {
-			arg3 = args[3].(string)
-		}
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockService_SignInWithOpenID_Call) Return(signInResponse *SignInResponse, err error) *MockService_SignInWithOpenID_Call {
-	_c.Call.Return(signInResponse, err)
+func (_c *MockService_UnlinkProvider_Call) Return(err error) *MockService_UnlinkProvider_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockService_SignInWithOpenID_Call) RunAndReturn(run func(ctx context.Context, provider string, token string, source string) (*SignInResponse, error)) *MockService_SignInWithOpenID_Call {
+func (_c *MockService_UnlinkProvider_Call) RunAndReturn(run func(ctx context.Context, principalID string, linkID string) error) *MockService_UnlinkProvider_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1,6 +1,10 @@
 package signin
 
-import "time"
+import (
+	"time"
+
+	"github.com/zeusito/toci/pkg/security/webauthn"
+)
 
 type LoginWithEmailOTPRequest struct {
 	Email  string `json:"email" validate:"required,max=100,email"`
@@ -10,16 +14,91 @@ type LoginWithEmailOTPRequest struct {
 type VerifyEmailOTPRequest struct {
 	Code  string `json:"code" validate:"required,len=6"`
 	Email string `json:"email" validate:"email,required,max=100"`
+	// web to receive the session in cookies, when they are enabled
+	Source string `json:"source" validate:"omitempty,oneof=web mobile"`
+}
+
+type PasswordLoginRequest struct {
+	Email    string `json:"email" validate:"required,max=100,email"`
+	Password string `json:"password" validate:"required,max=1024"`
+	Source   string `json:"source" validate:"required,oneof=web mobile"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,max=100,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"required,max=100,email"`
+	Code     string `json:"code" validate:"required,len=6"`
+	Password string `json:"password" validate:"required,max=1024"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=1024"`
+	NewPassword     string `json:"newPassword" validate:"required,max=1024"`
+}
+
+type OIDCNonceRequest struct {
+	Provider string `json:"provider" validate:"required,max=50"`
+}
+
+// OIDCNonceResponse the nonce to put in the authentication request to the provider, the ID token is only
+// accepted with it
+type OIDCNonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 type OIDCLoginRequest struct {
-	Provider string `json:"provider" validate:"required,oneof=google"`
+	Provider string `json:"provider" validate:"required,max=50"`
 	Token    string `json:"token" validate:"required"`
+	Nonce    string `json:"nonce" validate:"required,max=255"`
 	Source   string `json:"source" validate:"required,oneof=web mobile"`
 }
 
+type OAuthStartResponse struct {
+	AuthorizeURL string `json:"authorizeUrl"`
+	State        string `json:"-"`
+}
+
+// OAuthCallbackRequest the provider sends the code in the query string, or in a form with response_mode=form_post
+type OAuthCallbackRequest struct {
+	Provider string `path:"provider"`
+	State    string `query:"state" form:"state" validate:"max=255"`
+	Code     string `query:"code" form:"code" validate:"max=2048"`
+}
+
+type LinkProviderRequest struct {
+	Provider string `json:"provider" validate:"required,max=50"`
+	Token    string `json:"token" validate:"required"`
+	Nonce    string `json:"nonce" validate:"required,max=255"`
+}
+
+type LinkedProviderResponse struct {
+	ID       string    `json:"id"`
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linkedAt"`
+}
+
+type PasskeyRegistrationRequest struct {
+	Credential webauthn.RegistrationResponse `json:"credential" validate:"required"`
+}
+
+type PasskeyLoginStartRequest struct {
+	Email string `json:"email" validate:"required,max=100,email"`
+}
+
+type PasskeyLoginFinishRequest struct {
+	Email      string                     `json:"email" validate:"required,max=100,email"`
+	Credential webauthn.AssertionResponse `json:"credential" validate:"required"`
+	// web to receive the session in cookies, when they are enabled
+	Source string `json:"source" validate:"omitempty,oneof=web mobile"`
+}
+
 type SignInResponse struct {
-	AccessToken string    `json:"accessToken"`
+	AccessToken string    `json:"accessToken,omitempty"`
 	TokenType   string    `json:"tokenType"`
 	ExpiresAt   time.Time `json:"expiresAt"`
 }
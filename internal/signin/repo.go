@@ -2,10 +2,27 @@ package signin
 
 import (
 	"context"
+	"time"
 
 	"github.com/zeusito/toci/internal/dbmodels"
 )
 
 type Repo interface {
 	FindOneByEmail(ctx context.Context, email string) (*dbmodels.IdentityRecord, error)
+	FindOneByID(ctx context.Context, id string) (*dbmodels.IdentityRecord, error)
+	// CreateWithProvider creates an identity together with the provider account it was provisioned from
+	CreateWithProvider(ctx context.Context, record *dbmodels.IdentityRecord, link *dbmodels.IdentityProviderRecord) error
+	FindProviderLink(ctx context.Context, provider, subject string) (*dbmodels.IdentityProviderRecord, error)
+	ListProviderLinks(ctx context.Context, identityID string) ([]dbmodels.IdentityProviderRecord, error)
+	CreateProviderLink(ctx context.Context, link *dbmodels.IdentityProviderRecord) error
+	// DeleteProviderLink returns sql.ErrNoRows when the link does not belong to the identity
+	DeleteProviderLink(ctx context.Context, identityID, linkID string) error
+	CountPasskeys(ctx context.Context, identityID string) (int, error)
+	// RecordFailedLogin counts a failed login and locks the identity once maxAttempts is reached,
+	// it returns whether the identity got locked
+	RecordFailedLogin(ctx context.Context, identityID string, maxAttempts int, lockFor time.Duration) (bool, error)
+	// ResetFailedLogins clears the failed logins, lifting an expired lock
+	ResetFailedLogins(ctx context.Context, identityID string) error
+	// MarkEmailVerified records the first proof of ownership of the email, a later one keeps the first date
+	MarkEmailVerified(ctx context.Context, identityID string, verifiedAt time.Time) error
 }
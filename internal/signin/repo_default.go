@@ -2,19 +2,204 @@ package signin
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/uptrace/bun"
 	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/events"
 )
 
 type defaultRepo struct {
-	db *bun.DB
+	db     *bun.DB
+	outbox events.Outbox
 }
 
-func NewDefaultRepo(db *bun.DB) Repo {
-	return &defaultRepo{db: db}
+func NewDefaultRepo(db *bun.DB, outbox events.Outbox) Repo {
+	return &defaultRepo{db: db, outbox: outbox}
 }
 
 func (r *defaultRepo) FindOneByEmail(ctx context.Context, email string) (*dbmodels.IdentityRecord, error) {
-	return nil, nil
+	var record dbmodels.IdentityRecord
+
+	err := r.db.NewSelect().
+		Model(&record).
+		Where("email = ?", email).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *defaultRepo) FindOneByID(ctx context.Context, id string) (*dbmodels.IdentityRecord, error) {
+	var record dbmodels.IdentityRecord
+
+	err := r.db.NewSelect().
+		Model(&record).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *defaultRepo) CreateWithProvider(ctx context.Context, record *dbmodels.IdentityRecord, link *dbmodels.IdentityProviderRecord) error {
+	event, err := events.NewIdentityCreated(record.ID, record.Email, link.Provider)
+	if err != nil {
+		return err
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(record).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(link).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return r.outbox.Append(ctx, tx, event)
+	})
+}
+
+func (r *defaultRepo) FindProviderLink(ctx context.Context, provider, subject string) (*dbmodels.IdentityProviderRecord, error) {
+	var link dbmodels.IdentityProviderRecord
+
+	err := r.db.NewSelect().
+		Model(&link).
+		Where("provider = ?", provider).
+		Where("subject = ?", subject).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *defaultRepo) ListProviderLinks(ctx context.Context, identityID string) ([]dbmodels.IdentityProviderRecord, error) {
+	var links []dbmodels.IdentityProviderRecord
+
+	err := r.db.NewSelect().
+		Model(&links).
+		Where("identity_id = ?", identityID).
+		Order("linked_at ASC").
+		Scan(ctx)
+
+	return links, err
+}
+
+func (r *defaultRepo) CreateProviderLink(ctx context.Context, link *dbmodels.IdentityProviderRecord) error {
+	_, err := r.db.NewInsert().Model(link).Exec(ctx)
+
+	return err
+}
+
+func (r *defaultRepo) DeleteProviderLink(ctx context.Context, identityID, linkID string) error {
+	result, err := r.db.NewDelete().
+		Model((*dbmodels.IdentityProviderRecord)(nil)).
+		Where("id = ?", linkID).
+		Where("identity_id = ?", identityID).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *defaultRepo) CountPasskeys(ctx context.Context, identityID string) (int, error) {
+	return r.db.NewSelect().
+		Table("webauthn_credentials").
+		Where("identity_id = ?", identityID).
+		Count(ctx)
+}
+
+func (r *defaultRepo) RecordFailedLogin(ctx context.Context, identityID string, maxAttempts int, lockFor time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	locked := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var attempts []int
+
+		// The count starts over once a previous lock expired
+		_, err := tx.NewUpdate().
+			Model((*dbmodels.IdentityRecord)(nil)).
+			Set("failed_login_attempts = CASE WHEN status = ? AND lock_expires_at <= ? THEN 1 ELSE failed_login_attempts + 1 END",
+				dbmodels.IdentityStatusLocked, now).
+			Set("updated_at = ?", now).
+			Where("id = ?", identityID).
+			Where("status IN (?)", bun.In([]dbmodels.IdentityStatus{dbmodels.IdentityStatusActive, dbmodels.IdentityStatusLocked})).
+			Returning("failed_login_attempts").
+			Exec(ctx, &attempts)
+		if err != nil || len(attempts) == 0 || attempts[0] < maxAttempts {
+			return err
+		}
+
+		lockedUntil := now.Add(lockFor)
+
+		_, err = tx.NewUpdate().
+			Model((*dbmodels.IdentityRecord)(nil)).
+			Set("status = ?", dbmodels.IdentityStatusLocked).
+			Set("lock_expires_at = ?", lockedUntil).
+			Where("id = ?", identityID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		event, err := events.NewIdentityLocked(identityID, attempts[0], lockedUntil)
+		if err != nil {
+			return err
+		}
+
+		locked = true
+
+		return r.outbox.Append(ctx, tx, event)
+	})
+
+	return locked, err
+}
+
+func (r *defaultRepo) ResetFailedLogins(ctx context.Context, identityID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*dbmodels.IdentityRecord)(nil)).
+		Set("failed_login_attempts = 0").
+		Set("status = CASE WHEN status = ? THEN ? ELSE status END", dbmodels.IdentityStatusLocked, dbmodels.IdentityStatusActive).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", identityID).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *defaultRepo) MarkEmailVerified(ctx context.Context, identityID string, verifiedAt time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*dbmodels.IdentityRecord)(nil)).
+		Set("email_verified_at = ?", verifiedAt).
+		Set("updated_at = ?", verifiedAt).
+		Where("id = ?", identityID).
+		Where("email_verified_at IS NULL").
+		Exec(ctx)
+
+	return err
 }
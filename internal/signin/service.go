@@ -1,9 +1,28 @@
 package signin
 
-import "context"
+import (
+	"context"
+
+	"github.com/zeusito/toci/pkg/security/webauthn"
+)
 
 type Service interface {
 	SignInWithEmailOTP(ctx context.Context, email string, source string) error
 	VerifyEmailOTP(ctx context.Context, code, email string) (*SignInResponse, error)
-	SignInWithOpenID(ctx context.Context, provider, token string, source string) (*SignInResponse, error)
+	SignInWithPassword(ctx context.Context, email, password string, source string) (*SignInResponse, error)
+	SignOut(ctx context.Context, principalID, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, email, code, password string) error
+	ChangePassword(ctx context.Context, principalID, currentPassword, newPassword string) error
+	IssueOpenIDNonce(ctx context.Context, provider string) (*OIDCNonceResponse, error)
+	SignInWithOpenID(ctx context.Context, provider, token, nonce string, source string) (*SignInResponse, error)
+	StartOAuth(ctx context.Context, provider string) (*OAuthStartResponse, error)
+	SignInWithOAuth(ctx context.Context, provider, state, code string) (*SignInResponse, error)
+	ListProviders(ctx context.Context, principalID string) ([]LinkedProviderResponse, error)
+	LinkProvider(ctx context.Context, principalID, provider, token, nonce string) (*LinkedProviderResponse, error)
+	UnlinkProvider(ctx context.Context, principalID, linkID string) error
+	BeginPasskeyRegistration(ctx context.Context, principalID string) (*webauthn.CredentialCreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, principalID string, credential webauthn.RegistrationResponse) error
+	BeginPasskeyLogin(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error)
+	FinishPasskeyLogin(ctx context.Context, email string, credential webauthn.AssertionResponse) (*SignInResponse, error)
 }
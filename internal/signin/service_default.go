@@ -2,63 +2,98 @@ package signin
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/google/uuid"
 	"github.com/zeusito/toci/internal/actions"
 	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/metrics"
+	"github.com/zeusito/toci/pkg/security/oauth"
+	"github.com/zeusito/toci/pkg/security/oidc"
 	"github.com/zeusito/toci/pkg/security/otp"
+	"github.com/zeusito/toci/pkg/security/passwords"
 	"github.com/zeusito/toci/pkg/security/sessions"
+	"github.com/zeusito/toci/pkg/security/webauthn"
 	"github.com/zeusito/toci/pkg/terrors"
-	"github.com/zeusito/toci/pkg/toolbox"
 )
 
 type DefaultService struct {
 	repo           Repo
 	otpManager     otp.Manager
 	sessionManager sessions.Manager
+	passkeyManager webauthn.Manager
+	oidcVerifier   oidc.Verifier
+	oauthManager   oauth.Manager
+	passwords      passwords.Manager
 	asyncActions   actions.Service
+	recorder       audit.Recorder
 }
 
-func NewDefaultService(repo Repo, otpManager otp.Manager, sessionManager sessions.Manager, asyncActions actions.Service) Service {
-	return &DefaultService{repo: repo, otpManager: otpManager, sessionManager: sessionManager, asyncActions: asyncActions}
+const (
+	// consecutive failed password logins that lock an identity
+	maxFailedLogins = 5
+	lockDuration    = 15 * time.Minute
+	// login actions end with the sign-in method, e.g. auth.login.password
+	loginActionPrefix = "auth.login."
+)
+
+var errUnverifiedEmail = errors.New("email is not verified")
+
+// externalIdentity the identity asserted by an OpenID or OAuth2 provider
+type externalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
 }
 
-func (s *DefaultService) SignInWithEmailOTP(ctx context.Context, email string, source string) error {
-	requestID := toolbox.GetRequestID(ctx)
+func NewDefaultService(repo Repo, otpManager otp.Manager, sessionManager sessions.Manager, passkeyManager webauthn.Manager,
+	oidcVerifier oidc.Verifier, oauthManager oauth.Manager, passwordManager passwords.Manager, asyncActions actions.Service, recorder audit.Recorder) Service {
+	return &DefaultService{repo: repo, otpManager: otpManager, sessionManager: sessionManager, passkeyManager: passkeyManager,
+		oidcVerifier: oidcVerifier, oauthManager: oauthManager, passwords: passwordManager, asyncActions: asyncActions,
+		recorder: recorder}
+}
 
+func (s *DefaultService) SignInWithEmailOTP(ctx context.Context, email string, source string) error {
 	// Normalize email to lowercase
 	email = strings.ToLower(email)
 
-	log.Info().Str("trace", requestID).Msgf("login with email and password: %s", email)
+	logger.Ctx(ctx).Info().Str("email", email).Msg("login with email and password")
 
 	record, err := s.repo.FindOneByEmail(ctx, email)
 	if err != nil {
-		log.Warn().Str("trace", requestID).Msgf("failed to find user by email: %s", email)
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to find user by email")
 		return terrors.UnAuthorized("credentials are invalid")
 	}
 
 	// Check if user is not active
-	if record.Status != dbmodels.IdentityStatusActive {
+	if !isActive(record) {
 		// Is it locked?
-		if record.Status == dbmodels.IdentityStatusLocked {
-			log.Warn().Str("trace", requestID).Msgf("user is locked: %s", email)
+		if isLocked(record) {
+			logger.Ctx(ctx).Warn().Str("email", email).Msg("user is locked")
 			return terrors.UnAuthorized("credentials are invalid")
 		}
 
-		log.Warn().Str("trace", requestID).Msgf("user is not active: %s", email)
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is not active")
 		return terrors.UnAuthorized("credentials are invalid")
 	}
 
 	// Generate a one time password
 	code, ok := s.otpManager.GenerateCode(ctx, 6, otp.CodeKindUserPassword, email)
 	if !ok {
-		log.Warn().Str("trace", requestID).Msgf("failed to generate one time password: %s", email)
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to generate one time password")
 		return terrors.UnAuthorized("credentials are invalid")
 	}
 
-	log.Info().Str("trace", requestID).Msg("one time password generated")
+	logger.Ctx(ctx).Info().Msg("one time password generated")
 
 	s.asyncActions.SendOTPByEmail(ctx, code, email)
 
@@ -66,40 +101,548 @@ func (s *DefaultService) SignInWithEmailOTP(ctx context.Context, email string, s
 }
 
 func (s *DefaultService) VerifyEmailOTP(ctx context.Context, code, email string) (*SignInResponse, error) {
-	requestID := toolbox.GetRequestID(ctx)
-	now := time.Now().UTC()
-
 	// Normalize email to lowercase
 	email = strings.ToLower(email)
 
-	log.Info().Str("trace", requestID).Msgf("verify email OTP: %s", email)
+	logger.Ctx(ctx).Info().Str("email", email).Msg("verify email OTP")
 
 	// Verify the code
 	ok := s.otpManager.VerifyCode(ctx, otp.CodeKindUserPassword, email, code)
 	if !ok {
-		log.Warn().Str("trace", requestID).Msgf("failed to verify code: %s", code)
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to verify code")
+		s.recordAudit(ctx, audit.ActionLoginOTP, "", audit.ResultFailure, nil)
 		return nil, terrors.UnAuthorized("credentials are invalid")
 	}
 
 	// Retrieve the identity data
 	record, err := s.repo.FindOneByEmail(ctx, email)
 	if err != nil {
-		log.Warn().Str("trace", requestID).Err(err).Msgf("failed to find identity: %s", email)
+		logger.Ctx(ctx).Warn().Err(err).Str("email", email).Msg("failed to find identity")
+		s.recordAudit(ctx, audit.ActionLoginOTP, "", audit.ResultFailure, nil)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	// The code was delivered to the email, which proves its ownership
+	if record.EmailVerifiedAt == nil {
+		now := time.Now().UTC()
+		if err := s.repo.MarkEmailVerified(ctx, record.ID, now); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msgf("failed to mark the email as verified: %s", record.ID)
+		} else {
+			record.EmailVerifiedAt = &now
+		}
+	}
+
+	return s.newAuditedSession(ctx, audit.ActionLoginOTP, record, nil)
+}
+
+func (s *DefaultService) SignInWithPassword(ctx context.Context, email, password string, source string) (*SignInResponse, error) {
+	// Normalize email to lowercase
+	email = strings.ToLower(email)
+
+	logger.Ctx(ctx).Info().Str("email", email).Msg("login with email and password")
+
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to find user by email")
+		s.recordAudit(ctx, audit.ActionLoginPassword, "", audit.ResultFailure, map[string]string{"reason": "unknown identity"})
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	// Always verified, so a locked account takes as long to answer as any other
+	valid := s.passwords.Verify(ctx, record.ID, password)
+
+	if isLocked(record) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is locked")
+		s.recordAudit(ctx, audit.ActionLoginPassword, record.ID, audit.ResultDenied, map[string]string{"reason": "locked"})
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	if !valid {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("invalid password")
+		s.recordAudit(ctx, audit.ActionLoginPassword, record.ID, audit.ResultFailure, map[string]string{"reason": "invalid password"})
+		s.recordFailedLogin(ctx, record)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	// Checked after the password so the status of an account is not disclosed
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is not active")
+		s.recordAudit(ctx, audit.ActionLoginPassword, record.ID, audit.ResultDenied, map[string]string{"reason": "inactive"})
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	if record.FailedLoginAttempts > 0 || record.Status == dbmodels.IdentityStatusLocked {
+		if err := s.repo.ResetFailedLogins(ctx, record.ID); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msg("failed to reset failed logins")
+		}
+	}
+
+	return s.newAuditedSession(ctx, audit.ActionLoginPassword, record, nil)
+}
+
+// SignOut revokes the session of the token
+func (s *DefaultService) SignOut(ctx context.Context, principalID, token string) error {
+	if !s.sessionManager.RemoveSession(ctx, token) {
+		logger.Ctx(ctx).Warn().Msg("failed to remove session")
+		s.recordAudit(ctx, audit.ActionLogout, principalID, audit.ResultFailure, nil)
+		return terrors.Unknown("failed to sign out")
+	}
+
+	s.recordAudit(ctx, audit.ActionLogout, principalID, audit.ResultSuccess, nil)
+
+	return nil
+}
+
+func (s *DefaultService) ForgotPassword(ctx context.Context, email string) error {
+	// Normalize email to lowercase
+	email = strings.ToLower(email)
+
+	logger.Ctx(ctx).Info().Str("email", email).Msg("forgot password")
+
+	// Unknown or inactive accounts get the same answer, so the endpoint does not reveal which emails exist
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to find user by email")
+		return nil
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is not active")
+		return nil
+	}
+
+	code, ok := s.otpManager.GenerateCode(ctx, 6, otp.CodeKindPasswordReset, email)
+	if !ok {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to generate password reset code")
+		return terrors.Unknown("failed to generate password reset code")
+	}
+
+	s.asyncActions.SendPasswordResetByEmail(ctx, code, email)
+
+	return nil
+}
+
+func (s *DefaultService) ResetPassword(ctx context.Context, email, code, password string) error {
+	// Normalize email to lowercase
+	email = strings.ToLower(email)
+
+	logger.Ctx(ctx).Info().Str("email", email).Msg("reset password")
+
+	if !s.otpManager.VerifyCode(ctx, otp.CodeKindPasswordReset, email, code) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to verify password reset code")
+		s.recordAudit(ctx, audit.ActionPasswordReset, "", audit.ResultFailure, map[string]string{"reason": "invalid code"})
+		return terrors.UnAuthorized("credentials are invalid")
+	}
+
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("email", email).Msg("failed to find identity")
+		return terrors.UnAuthorized("credentials are invalid")
+	}
+
+	err = s.passwords.Set(ctx, record.ID, password)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to set password: %s", record.ID)
+		return toPasswordError(err)
+	}
+
+	// Whoever knew the previous password is signed out everywhere
+	if !s.sessionManager.RemoveAllSessions(ctx, record.ID) {
+		logger.Ctx(ctx).Warn().Msgf("failed to revoke sessions: %s", record.ID)
+		return terrors.Unknown("failed to reset password")
+	}
+
+	// The code is only consumed once the password was accepted and the sessions revoked, so a policy violation
+	// or a failed revocation can be retried
+	s.otpManager.Remove(ctx, otp.CodeKindPasswordReset, email)
+
+	s.recordAudit(ctx, audit.ActionPasswordReset, record.ID, audit.ResultSuccess, nil)
+
+	return nil
+}
+
+func (s *DefaultService) ChangePassword(ctx context.Context, principalID, currentPassword, newPassword string) error {
+	logger.Ctx(ctx).Info().Msgf("change password: %s", principalID)
+
+	if !s.passwords.Verify(ctx, principalID, currentPassword) {
+		logger.Ctx(ctx).Warn().Msgf("invalid current password: %s", principalID)
+		s.recordAudit(ctx, audit.ActionPasswordChange, principalID, audit.ResultFailure, map[string]string{"reason": "invalid password"})
+		return terrors.Forbidden("current password is invalid")
+	}
+
+	err := s.passwords.Set(ctx, principalID, newPassword)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to set password: %s", principalID)
+		return toPasswordError(err)
+	}
+
+	s.recordAudit(ctx, audit.ActionPasswordChange, principalID, audit.ResultSuccess, nil)
+
+	return nil
+}
+
+func (s *DefaultService) IssueOpenIDNonce(ctx context.Context, provider string) (*OIDCNonceResponse, error) {
+	// Checked before anything is persisted, the endpoint is anonymous
+	if !s.oauthManager.IsOpenIDProvider(provider) {
+		logger.Ctx(ctx).Warn().Msgf("nonce requested for an unknown openid provider: %s", provider)
+		return nil, terrors.PreconditionFailed("unknown openid provider")
+	}
+
+	nonce, expiresAt, ok := s.oauthManager.IssueNonce(ctx, provider)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to issue nonce for openid provider: %s", provider)
+		return nil, terrors.Unknown("failed to issue nonce")
+	}
+
+	return &OIDCNonceResponse{Nonce: nonce, ExpiresAt: expiresAt}, nil
+}
+
+func (s *DefaultService) SignInWithOpenID(ctx context.Context, provider, token, nonce string, source string) (*SignInResponse, error) {
+	logger.Ctx(ctx).Info().Msgf("login with openid provider: %s", provider)
+
+	claims, ok := s.verifyIDToken(ctx, provider, token, nonce)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to verify id token from provider: %s", provider)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	return s.signInExternal(ctx, &externalIdentity{
+		Provider:      provider,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	})
+}
+
+// verifyIDToken accepts an ID token carrying a nonce we issued, so a token obtained by another client or
+// replayed cannot sign in
+func (s *DefaultService) verifyIDToken(ctx context.Context, provider, token, nonce string) (*oidc.Claims, bool) {
+	if !s.oauthManager.RedeemNonce(ctx, provider, nonce) {
+		logger.Ctx(ctx).Warn().Msgf("nonce was not issued for provider: %s", provider)
+		return nil, false
+	}
+
+	return s.oidcVerifier.Verify(ctx, provider, token, nonce)
+}
+
+func (s *DefaultService) StartOAuth(ctx context.Context, provider string) (*OAuthStartResponse, error) {
+	logger.Ctx(ctx).Info().Msgf("start oauth flow with provider: %s", provider)
+
+	authorizeURL, state, ok := s.oauthManager.Start(ctx, provider)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to start oauth flow with provider: %s", provider)
+		return nil, terrors.RecordNotFound("provider not found")
+	}
+
+	return &OAuthStartResponse{AuthorizeURL: authorizeURL, State: state}, nil
+}
+
+func (s *DefaultService) SignInWithOAuth(ctx context.Context, provider, state, code string) (*SignInResponse, error) {
+	logger.Ctx(ctx).Info().Msgf("complete oauth flow with provider: %s", provider)
+
+	identity, ok := s.oauthManager.Exchange(ctx, provider, state, code)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to complete oauth flow with provider: %s", provider)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	return s.signInExternal(ctx, &externalIdentity{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		GivenName:     identity.GivenName,
+		FamilyName:    identity.FamilyName,
+	})
+}
+
+func (s *DefaultService) ListProviders(ctx context.Context, principalID string) ([]LinkedProviderResponse, error) {
+	links, err := s.repo.ListProviderLinks(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to list linked providers: %s", principalID)
+		return nil, terrors.Unknown("failed to list linked providers")
+	}
+
+	resp := make([]LinkedProviderResponse, 0, len(links))
+	for i := range links {
+		resp = append(resp, toLinkedProvider(&links[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *DefaultService) LinkProvider(ctx context.Context, principalID, provider, token, nonce string) (*LinkedProviderResponse, error) {
+	logger.Ctx(ctx).Info().Msgf("link %s account to identity: %s", provider, principalID)
+
+	claims, ok := s.verifyIDToken(ctx, provider, token, nonce)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to verify id token from provider: %s", provider)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	existing, err := s.repo.FindProviderLink(ctx, provider, claims.Subject)
+	if err == nil {
+		if existing.IdentityID != principalID {
+			logger.Ctx(ctx).Warn().Msgf("%s account is linked to another identity", provider)
+			s.recordAudit(ctx, audit.ActionProviderLink, principalID, audit.ResultDenied, map[string]string{"provider": provider})
+			return nil, terrors.PreconditionFailed("provider account is linked to another identity")
+		}
+
+		resp := toLinkedProvider(existing)
+		return &resp, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to find provider link")
+		return nil, terrors.Unknown("failed to link provider")
+	}
+
+	// The session proves the ownership of the identity, the token the ownership of the provider account
+	link := newProviderLink(principalID, &externalIdentity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+
+	err = s.repo.CreateProviderLink(ctx, link)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to create provider link")
+		return nil, terrors.Unknown("failed to link provider")
+	}
+
+	s.recordAudit(ctx, audit.ActionProviderLink, principalID, audit.ResultSuccess, map[string]string{"provider": provider, "link": link.ID})
+
+	resp := toLinkedProvider(link)
+	return &resp, nil
+}
+
+func (s *DefaultService) UnlinkProvider(ctx context.Context, principalID, linkID string) error {
+	logger.Ctx(ctx).Info().Msgf("unlink provider %s from identity: %s", linkID, principalID)
+
+	record, err := s.repo.FindOneByID(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to find identity: %s", principalID)
+		return terrors.Forbidden("identity is not allowed to unlink providers")
+	}
+
+	links, err := s.repo.ListProviderLinks(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to list linked providers: %s", principalID)
+		return terrors.Unknown("failed to unlink provider")
+	}
+
+	methods, err := s.countLoginMethods(ctx, record, links, linkID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to count login methods: %s", principalID)
+		return terrors.Unknown("failed to unlink provider")
+	}
+
+	if methods == 0 {
+		logger.Ctx(ctx).Warn().Msgf("refusing to remove the last login method: %s", principalID)
+		return terrors.PreconditionFailed("the last login method cannot be removed")
+	}
+
+	err = s.repo.DeleteProviderLink(ctx, principalID, linkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return terrors.RecordNotFound("linked provider not found")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to delete provider link")
+		return terrors.Unknown("failed to unlink provider")
+	}
+
+	s.recordAudit(ctx, audit.ActionProviderUnlink, principalID, audit.ResultSuccess, map[string]string{"link": linkID})
+
+	return nil
+}
+
+func (s *DefaultService) BeginPasskeyRegistration(ctx context.Context, principalID string) (*webauthn.CredentialCreationOptions, error) {
+	logger.Ctx(ctx).Info().Msgf("begin passkey registration: %s", principalID)
+
+	record, err := s.repo.FindOneByID(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to find identity: %s", principalID)
+		return nil, terrors.Forbidden("identity is not allowed to register a passkey")
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Msgf("identity is not active: %s", principalID)
+		return nil, terrors.Forbidden("identity is not allowed to register a passkey")
+	}
+
+	options, ok := s.passkeyManager.BeginRegistration(ctx, toPasskeyUser(record))
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("failed to begin passkey registration: %s", principalID)
+		return nil, terrors.Unknown("failed to begin passkey registration")
+	}
+
+	return options, nil
+}
+
+func (s *DefaultService) FinishPasskeyRegistration(ctx context.Context, principalID string, credential webauthn.RegistrationResponse) error {
+	logger.Ctx(ctx).Info().Msgf("finish passkey registration: %s", principalID)
+
+	record, err := s.repo.FindOneByID(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to find identity: %s", principalID)
+		return terrors.Forbidden("identity is not allowed to register a passkey")
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Msgf("identity is not active: %s", principalID)
+		return terrors.Forbidden("identity is not allowed to register a passkey")
+	}
+
+	_, err = s.passkeyManager.FinishRegistration(ctx, toPasskeyUser(record), credential)
+	if errors.Is(err, webauthn.ErrInvalidRegistration) || errors.Is(err, webauthn.ErrCredentialRegistered) {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to verify passkey registration: %s", principalID)
+		s.recordAudit(ctx, audit.ActionPasskeyRegister, principalID, audit.ResultFailure, nil)
+		return terrors.PreconditionFailed("passkey registration could not be verified")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msgf("failed to register passkey: %s", principalID)
+		return terrors.Unknown("failed to register passkey")
+	}
+
+	s.recordAudit(ctx, audit.ActionPasskeyRegister, principalID, audit.ResultSuccess, nil)
+
+	return nil
+}
+
+func (s *DefaultService) BeginPasskeyLogin(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error) {
+	// Normalize email to lowercase
+	email = strings.ToLower(email)
+
+	logger.Ctx(ctx).Info().Str("email", email).Msg("begin passkey login")
+
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to find user by email")
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is not active")
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	options, ok := s.passkeyManager.BeginLogin(ctx, record.ID)
+	if !ok {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to begin passkey login")
 		return nil, terrors.UnAuthorized("credentials are invalid")
 	}
 
-	// Generate a session
+	return options, nil
+}
+
+func (s *DefaultService) FinishPasskeyLogin(ctx context.Context, email string, credential webauthn.AssertionResponse) (*SignInResponse, error) {
+	// Normalize email to lowercase
+	email = strings.ToLower(email)
+
+	logger.Ctx(ctx).Info().Str("email", email).Msg("finish passkey login")
+
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to find user by email")
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("user is not active")
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	_, ok := s.passkeyManager.FinishLogin(ctx, record.ID, credential)
+	if !ok {
+		logger.Ctx(ctx).Warn().Str("email", email).Msg("failed to verify passkey assertion")
+		s.recordAudit(ctx, audit.ActionLoginPasskey, record.ID, audit.ResultFailure, nil)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	return s.newAuditedSession(ctx, audit.ActionLoginPasskey, record, nil)
+}
+
+// recordFailedLogin counts a failed password login, the identity is locked after too many of them
+func (s *DefaultService) recordFailedLogin(ctx context.Context, record *dbmodels.IdentityRecord) {
+	locked, err := s.repo.RecordFailedLogin(ctx, record.ID, maxFailedLogins, lockDuration)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to record failed login")
+		return
+	}
+
+	if locked {
+		logger.Ctx(ctx).Warn().Msgf("identity locked after %d failed logins: %s", maxFailedLogins, record.ID)
+		metrics.Lockouts.Inc()
+		s.recordAudit(ctx, audit.ActionIdentityLock, record.ID, audit.ResultSuccess,
+			map[string]string{"failedLogins": strconv.Itoa(maxFailedLogins)})
+	}
+}
+
+// isLocked tells whether the identity is locked. A lock without expiration lasts until lifted by an administrator.
+func isLocked(record *dbmodels.IdentityRecord) bool {
+	if record.Status != dbmodels.IdentityStatusLocked {
+		return false
+	}
+
+	return record.LockExpiresAt.IsZero() || record.LockExpiresAt.After(time.Now().UTC())
+}
+
+// isActive tells whether the identity may sign in, an expired lock no longer applies
+func isActive(record *dbmodels.IdentityRecord) bool {
+	return record.Status == dbmodels.IdentityStatusActive ||
+		(record.Status == dbmodels.IdentityStatusLocked && !isLocked(record))
+}
+
+// newAuditedSession opens a session for an identity signing in with the given action and records the outcome
+func (s *DefaultService) newAuditedSession(ctx context.Context, action string, record *dbmodels.IdentityRecord, metadata map[string]string) (*SignInResponse, error) {
+	resp, err := s.newSession(ctx, record)
+
+	result := audit.ResultSuccess
+	if err != nil {
+		result = audit.ResultFailure
+	}
+	s.recordAudit(ctx, action, record.ID, result, metadata)
+
+	return resp, err
+}
+
+// recordAudit records an action of an identity on itself, the identity ID is empty when it is unknown
+func (s *DefaultService) recordAudit(ctx context.Context, action, identityID string, result audit.Result, metadata map[string]string) {
+	if method, ok := strings.CutPrefix(action, loginActionPrefix); ok && result != audit.ResultSuccess {
+		metrics.FailedLogins.WithLabelValues(method).Inc()
+	}
+
+	s.recorder.Record(ctx, audit.Event{
+		Action:     action,
+		ActorID:    identityID,
+		TargetType: audit.TargetIdentity,
+		TargetID:   identityID,
+		Result:     result,
+		Metadata:   metadata,
+	})
+}
+
+// newSession opens a session for an authenticated identity
+func (s *DefaultService) newSession(ctx context.Context, record *dbmodels.IdentityRecord) (*SignInResponse, error) {
+	now := time.Now().UTC()
+
+	roles := sessions.RoleUser
+	if len(record.Roles) > 0 {
+		roles = strings.Join(record.Roles, ",")
+	}
+
 	sessionData := sessions.Session{
 		PrincipalID: record.ID,
 		Metadata: sessions.SessionMetadata{
-			"roles": "user",
+			"roles": roles,
 		},
 		ExpiresAt: now.Add(time.Hour * 24),
 		CreatedAt: now,
 	}
 	sessionID, ok := s.sessionManager.CreateSession(ctx, sessionData, sessionData.ExpiresAt)
 	if !ok {
-		log.Warn().Str("trace", requestID).Msgf("failed to create session: %s", email)
+		logger.Ctx(ctx).Warn().Msgf("failed to create session: %s", record.ID)
 		return nil, terrors.UnAuthorized("credentials are invalid")
 	}
 
@@ -109,9 +652,156 @@ func (s *DefaultService) VerifyEmailOTP(ctx context.Context, code, email string)
 		TokenType:   "Bearer",
 		ExpiresAt:   sessionData.ExpiresAt,
 	}, nil
+}
+
+// signInExternal opens a session for an identity asserted by an external provider. The provider account is
+// resolved through its link, or auto-linked by verified email, provisioning the identity on first login.
+func (s *DefaultService) signInExternal(ctx context.Context, identity *externalIdentity) (*SignInResponse, error) {
+	var record *dbmodels.IdentityRecord
+
+	link, err := s.repo.FindProviderLink(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil:
+		record, err = s.repo.FindOneByID(ctx, link.IdentityID)
+	case errors.Is(err, sql.ErrNoRows):
+		record, err = s.linkByEmail(ctx, identity)
+	}
+	metadata := map[string]string{"provider": identity.Provider}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to resolve %s identity, subject: %s", identity.Provider, identity.Subject)
+		s.recordAudit(ctx, audit.ActionLoginExternal, "", audit.ResultFailure, metadata)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	if !isActive(record) {
+		logger.Ctx(ctx).Warn().Msgf("user is not active: %s", record.ID)
+		s.recordAudit(ctx, audit.ActionLoginExternal, record.ID, audit.ResultDenied, metadata)
+		return nil, terrors.UnAuthorized("credentials are invalid")
+	}
+
+	return s.newAuditedSession(ctx, audit.ActionLoginExternal, record, metadata)
+}
+
+// linkByEmail links the provider account to the identity owning the same email, both sides must have verified it
+func (s *DefaultService) linkByEmail(ctx context.Context, identity *externalIdentity) (*dbmodels.IdentityRecord, error) {
+	// Only a verified email can be trusted to identify the account
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errUnverifiedEmail
+	}
+
+	// Normalize email to lowercase
+	email := strings.ToLower(identity.Email)
+
+	record, err := s.repo.FindOneByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.provisionIdentity(ctx, email, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Otherwise anyone could pre-register the email and take over the provider login
+	if record.EmailVerifiedAt == nil {
+		return nil, errUnverifiedEmail
+	}
+
+	err = s.repo.CreateProviderLink(ctx, newProviderLink(record.ID, identity))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Ctx(ctx).Info().Msgf("%s account linked by email to identity: %s", identity.Provider, record.ID)
+
+	return record, nil
+}
 
+// provisionIdentity creates an identity on first login with an external provider
+func (s *DefaultService) provisionIdentity(ctx context.Context, email string, identity *externalIdentity) (*dbmodels.IdentityRecord, error) {
+	now := time.Now().UTC()
+
+	record := &dbmodels.IdentityRecord{
+		ID:              uuid.NewString(),
+		Email:           email,
+		FirstName:       identity.GivenName,
+		LastName:        identity.FamilyName,
+		Status:          dbmodels.IdentityStatusActive,
+		EmailVerifiedAt: &now,
+		LockExpiresAt:   now,
+		LastLoginAt:     now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err := s.repo.CreateWithProvider(ctx, record, newProviderLink(record.ID, identity))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Ctx(ctx).Info().Msgf("identity provisioned from %s: %s", identity.Provider, record.ID)
+
+	return record, nil
 }
 
-func (s *DefaultService) SignInWithOpenID(ctx context.Context, provider, token string, source string) (*SignInResponse, error) {
-	return nil, nil
+// countLoginMethods counts the ways an identity can still sign in, excluding the given provider link
+func (s *DefaultService) countLoginMethods(ctx context.Context, record *dbmodels.IdentityRecord, links []dbmodels.IdentityProviderRecord, excludedLinkID string) (int, error) {
+	passkeys, err := s.repo.CountPasskeys(ctx, record.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	methods := passkeys
+	if s.passwords.Exists(ctx, record.ID) {
+		methods++
+	}
+	for _, link := range links {
+		if link.ID != excludedLinkID {
+			methods++
+		}
+	}
+
+	// Email OTP signs in any identity with an email, a code delivered to it is a login method of its own
+	if record.Email != "" {
+		methods++
+	}
+
+	return methods, nil
+}
+
+func newProviderLink(identityID string, identity *externalIdentity) *dbmodels.IdentityProviderRecord {
+	return &dbmodels.IdentityProviderRecord{
+		ID:         uuid.NewString(),
+		IdentityID: identityID,
+		Provider:   identity.Provider,
+		Subject:    identity.Subject,
+		Email:      strings.ToLower(identity.Email),
+		LinkedAt:   time.Now().UTC(),
+	}
+}
+
+func toLinkedProvider(link *dbmodels.IdentityProviderRecord) LinkedProviderResponse {
+	return LinkedProviderResponse{
+		ID:       link.ID,
+		Provider: link.Provider,
+		Email:    link.Email,
+		LinkedAt: link.LinkedAt,
+	}
+}
+
+// toPasswordError exposes the policy violations, any other failure stays opaque
+func toPasswordError(err error) error {
+	switch {
+	case errors.Is(err, passwords.ErrTooShort), errors.Is(err, passwords.ErrTooLong),
+		errors.Is(err, passwords.ErrBreached), errors.Is(err, passwords.ErrReused):
+		return terrors.PreconditionFailed(err.Error())
+	default:
+		return terrors.Unknown("failed to set password")
+	}
+}
+
+func toPasskeyUser(record *dbmodels.IdentityRecord) webauthn.User {
+	return webauthn.User{
+		ID:          record.ID,
+		Name:        record.Email,
+		DisplayName: strings.TrimSpace(record.FirstName + " " + record.LastName),
+	}
 }
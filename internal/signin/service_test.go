@@ -2,14 +2,32 @@ package signin
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/zeusito/toci/internal/actions"
 	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/oauth"
+	"github.com/zeusito/toci/pkg/security/oidc"
 	"github.com/zeusito/toci/pkg/security/otp"
+	"github.com/zeusito/toci/pkg/security/passwords"
 	"github.com/zeusito/toci/pkg/security/sessions"
+	"github.com/zeusito/toci/pkg/security/webauthn"
+	"github.com/zeusito/toci/pkg/terrors"
 )
 
 func TestSignInWithEmailOTPInvalidEmail(t *testing.T) {
@@ -17,9 +35,15 @@ func TestSignInWithEmailOTPInvalidEmail(t *testing.T) {
 	repo := NewMockRepo(t)
 	ottManager := otp.NewMockManager(t)
 	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
 	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
 
-	svc := NewDefaultService(repo, ottManager, sessionManager, asyncActions)
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
 
 	// Expectations
 	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(nil, errors.New("record not found"))
@@ -33,9 +57,15 @@ func TestSignInWithEmailOTPUserLocked(t *testing.T) {
 	repo := NewMockRepo(t)
 	ottManager := otp.NewMockManager(t)
 	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
 	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
 
-	svc := NewDefaultService(repo, ottManager, sessionManager, asyncActions)
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
 
 	// Expectations
 	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
@@ -53,9 +83,15 @@ func TestSignInWithEmailOTPFailToGenerateOTP(t *testing.T) {
 	repo := NewMockRepo(t)
 	ottManager := otp.NewMockManager(t)
 	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
 	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
 
-	svc := NewDefaultService(repo, ottManager, sessionManager, asyncActions)
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
 
 	// Expectations
 	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
@@ -75,9 +111,15 @@ func TestSignInWithEmailOTPSuccess(t *testing.T) {
 	repo := NewMockRepo(t)
 	ottManager := otp.NewMockManager(t)
 	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
 	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
 
-	svc := NewDefaultService(repo, ottManager, sessionManager, asyncActions)
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
 
 	// Expectations
 	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
@@ -93,3 +135,941 @@ func TestSignInWithEmailOTPSuccess(t *testing.T) {
 	err := svc.SignInWithEmailOTP(ctx, "none@my.com", "web")
 	assert.NoError(t, err, "expected no error for successful OTP generation")
 }
+
+func TestFinishPasskeyLoginInvalidAssertion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+	assertion := webauthn.AssertionResponse{ID: "cred", RawID: []byte("cred"), Type: "public-key"}
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+
+	passkeyManager.EXPECT().FinishLogin(ctx, "1", assertion).Return(nil, false)
+
+	resp, err := svc.FinishPasskeyLogin(ctx, "None@My.com", assertion)
+	assert.Error(t, err, "expected error for invalid assertion")
+	assert.Nil(t, resp)
+}
+
+func TestFinishPasskeyLoginSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+	assertion := webauthn.AssertionResponse{ID: "cred", RawID: []byte("cred"), Type: "public-key"}
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+
+	passkeyManager.EXPECT().FinishLogin(ctx, "1", assertion).Return(&webauthn.Credential{PrincipalID: "1"}, true)
+
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.FinishPasskeyLogin(ctx, "none@my.com", assertion)
+	assert.NoError(t, err, "expected no error for a valid assertion")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+	assert.Equal(t, "Bearer", resp.TokenType)
+}
+
+func TestSignInWithOpenIDUnverifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{
+		Subject:       "sub-1",
+		Email:         "none@my.com",
+		EmailVerified: false,
+	}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(nil, sql.ErrNoRows)
+
+	resp, err := svc.SignInWithOpenID(ctx, "google", "id-token", "nonce", "web")
+	assert.Error(t, err, "expected error for unverified email")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithOpenIDProvisionsIdentity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{
+		Subject:       "sub-1",
+		Email:         "New@My.com",
+		EmailVerified: true,
+		GivenName:     "New",
+		FamilyName:    "User",
+	}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(nil, sql.ErrNoRows)
+
+	repo.EXPECT().FindOneByEmail(ctx, "new@my.com").Return(nil, sql.ErrNoRows)
+
+	repo.EXPECT().CreateWithProvider(ctx, mock.MatchedBy(func(record *dbmodels.IdentityRecord) bool {
+		return record.Email == "new@my.com" && record.FirstName == "New" &&
+			record.Status == dbmodels.IdentityStatusActive && record.EmailVerifiedAt != nil
+	}), mock.MatchedBy(func(link *dbmodels.IdentityProviderRecord) bool {
+		return link.Provider == "google" && link.Subject == "sub-1" && link.Email == "new@my.com"
+	})).Return(nil)
+
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.SignInWithOpenID(ctx, "google", "id-token", "nonce", "web")
+	assert.NoError(t, err, "expected no error when provisioning a new identity")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestSignInWithOAuthInvalidFlow(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().Exchange(ctx, "github", "state", "code").Return(nil, false)
+
+	resp, err := svc.SignInWithOAuth(ctx, "github", "state", "code")
+	assert.Error(t, err, "expected error for an invalid flow")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithOAuthExistingIdentity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().Exchange(ctx, "github", "state", "code").Return(&oauth.Identity{
+		Provider:      "github",
+		Subject:       "1234",
+		Email:         "None@My.com",
+		EmailVerified: true,
+	}, true)
+
+	verifiedAt := time.Now()
+	repo.EXPECT().FindProviderLink(ctx, "github", "1234").Return(nil, sql.ErrNoRows)
+
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:              "1",
+		Email:           "none@my.com",
+		Status:          dbmodels.IdentityStatusActive,
+		EmailVerifiedAt: &verifiedAt,
+	}, nil)
+
+	repo.EXPECT().CreateProviderLink(ctx, mock.MatchedBy(func(link *dbmodels.IdentityProviderRecord) bool {
+		return link.IdentityID == "1" && link.Provider == "github" && link.Subject == "1234"
+	})).Return(nil)
+
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.SignInWithOAuth(ctx, "github", "state", "code")
+	assert.NoError(t, err, "expected no error for an existing identity")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestSignInWithOAuthUnverifiedLocalEmail(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().Exchange(ctx, "github", "state", "code").Return(&oauth.Identity{
+		Provider:      "github",
+		Subject:       "1234",
+		Email:         "none@my.com",
+		EmailVerified: true,
+	}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "github", "1234").Return(nil, sql.ErrNoRows)
+
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+
+	resp, err := svc.SignInWithOAuth(ctx, "github", "state", "code")
+	assert.Error(t, err, "expected error when the local email is not verified")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithOpenIDLinkedProvider(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, the email at the provider no longer matches the identity
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{
+		Subject: "sub-1",
+		Email:   "changed@my.com",
+	}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(&dbmodels.IdentityProviderRecord{
+		ID:         "link-1",
+		IdentityID: "1",
+		Provider:   "google",
+		Subject:    "sub-1",
+	}, nil)
+
+	repo.EXPECT().FindOneByID(ctx, "1").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.SignInWithOpenID(ctx, "google", "id-token", "nonce", "web")
+	assert.NoError(t, err, "expected no error for a linked provider account")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestSignInWithOpenIDRequiresIssuedNonce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, the token is not even verified
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "chosen-by-the-client").Return(false)
+
+	resp, err := svc.SignInWithOpenID(ctx, "google", "id-token", "chosen-by-the-client", "web")
+	assert.Error(t, err, "expected error for a nonce that was not issued")
+	assert.Nil(t, resp)
+}
+
+func TestLinkProviderLinkedToAnotherIdentity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{Subject: "sub-1"}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(&dbmodels.IdentityProviderRecord{
+		ID:         "link-1",
+		IdentityID: "2",
+	}, nil)
+
+	resp, err := svc.LinkProvider(ctx, "1", "google", "id-token", "nonce")
+	assert.Error(t, err, "expected error for a provider account linked to another identity")
+	assert.Nil(t, resp)
+}
+
+func TestLinkProviderSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{
+		Subject: "sub-1",
+		Email:   "Other@My.com",
+	}, true)
+
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(nil, sql.ErrNoRows)
+
+	repo.EXPECT().CreateProviderLink(ctx, mock.MatchedBy(func(link *dbmodels.IdentityProviderRecord) bool {
+		return link.IdentityID == "1" && link.Subject == "sub-1" && link.Email == "other@my.com"
+	})).Return(nil)
+
+	resp, err := svc.LinkProvider(ctx, "1", "google", "id-token", "nonce")
+	assert.NoError(t, err, "expected no error when linking a new provider account")
+	assert.Equal(t, "google", resp.Provider)
+}
+
+func TestUnlinkProviderLastLoginMethod(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, no email and no passkeys
+	repo.EXPECT().FindOneByID(ctx, "1").Return(&dbmodels.IdentityRecord{ID: "1"}, nil)
+	repo.EXPECT().ListProviderLinks(ctx, "1").Return([]dbmodels.IdentityProviderRecord{{ID: "link-1"}}, nil)
+	repo.EXPECT().CountPasskeys(ctx, "1").Return(0, nil)
+	passwordManager.EXPECT().Exists(ctx, "1").Return(false)
+
+	err := svc.UnlinkProvider(ctx, "1", "link-1")
+	assert.EqualError(t, err, "the last login method cannot be removed")
+}
+
+func TestUnlinkProviderSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, a passkey is left
+	repo.EXPECT().FindOneByID(ctx, "1").Return(&dbmodels.IdentityRecord{ID: "1"}, nil)
+	repo.EXPECT().ListProviderLinks(ctx, "1").Return([]dbmodels.IdentityProviderRecord{{ID: "link-1"}}, nil)
+	repo.EXPECT().CountPasskeys(ctx, "1").Return(1, nil)
+	passwordManager.EXPECT().Exists(ctx, "1").Return(false)
+	repo.EXPECT().DeleteProviderLink(ctx, "1", "link-1").Return(nil)
+
+	err := svc.UnlinkProvider(ctx, "1", "link-1")
+	assert.NoError(t, err, "expected no error when another login method is left")
+}
+
+func TestUnlinkProviderEmailOTPLeft(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, no passkey nor password, the identity still signs in by email OTP
+	repo.EXPECT().FindOneByID(ctx, "1").Return(&dbmodels.IdentityRecord{ID: "1", Email: "none@my.com"}, nil)
+	repo.EXPECT().ListProviderLinks(ctx, "1").Return([]dbmodels.IdentityProviderRecord{{ID: "link-1"}}, nil)
+	repo.EXPECT().CountPasskeys(ctx, "1").Return(0, nil)
+	passwordManager.EXPECT().Exists(ctx, "1").Return(false)
+	repo.EXPECT().DeleteProviderLink(ctx, "1", "link-1").Return(nil)
+
+	err := svc.UnlinkProvider(ctx, "1", "link-1")
+	assert.NoError(t, err, "expected no error when the email OTP is left")
+}
+
+func TestSignInWithPasswordInvalidPassword(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+	passwordManager.EXPECT().Verify(ctx, "1", "wrong-password").Return(false)
+	repo.EXPECT().RecordFailedLogin(ctx, "1", maxFailedLogins, lockDuration).Return(false, nil)
+
+	resp, err := svc.SignInWithPassword(ctx, "None@My.com", "wrong-password", "web")
+	assert.Error(t, err, "expected error for an invalid password")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithPasswordLocksAfterTooManyFailures(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:                  "1",
+		Email:               "none@my.com",
+		Status:              dbmodels.IdentityStatusActive,
+		FailedLoginAttempts: maxFailedLogins - 1,
+	}, nil)
+	passwordManager.EXPECT().Verify(ctx, "1", "wrong-password").Return(false)
+	repo.EXPECT().RecordFailedLogin(ctx, "1", maxFailedLogins, lockDuration).Return(true, nil)
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionLoginPassword && event.ActorID == "1" && event.Result == audit.ResultFailure
+	})).Once()
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionIdentityLock && event.TargetID == "1"
+	})).Once()
+
+	resp, err := svc.SignInWithPassword(ctx, "none@my.com", "wrong-password", "web")
+	assert.Error(t, err, "expected error for an invalid password")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithPasswordLockedAccount(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:            "1",
+		Email:         "none@my.com",
+		Status:        dbmodels.IdentityStatusLocked,
+		LockExpiresAt: time.Now().UTC().Add(time.Minute),
+	}, nil)
+	passwordManager.EXPECT().Verify(ctx, "1", "correct-password").Return(true)
+
+	resp, err := svc.SignInWithPassword(ctx, "none@my.com", "correct-password", "web")
+	assert.Error(t, err, "expected error for a locked account")
+	assert.Nil(t, resp)
+}
+
+func TestSignInWithPasswordExpiredLockResetsFailedLogins(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:                  "1",
+		Email:               "none@my.com",
+		Status:              dbmodels.IdentityStatusLocked,
+		FailedLoginAttempts: maxFailedLogins,
+		LockExpiresAt:       time.Now().UTC().Add(-time.Minute),
+	}, nil)
+	passwordManager.EXPECT().Verify(ctx, "1", "correct-password").Return(true)
+	repo.EXPECT().ResetFailedLogins(ctx, "1").Return(nil)
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.SignInWithPassword(ctx, "none@my.com", "correct-password", "web")
+	assert.NoError(t, err, "expected no error once the lock expired")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestSignOutFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	sessionManager.EXPECT().RemoveSession(ctx, "opaque-token").Return(false)
+
+	err := svc.SignOut(ctx, "1", "opaque-token")
+	assert.Error(t, err, "expected error when the session cannot be removed")
+}
+
+func TestSignInWithPasswordSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{
+		ID:     "1",
+		Email:  "none@my.com",
+		Status: dbmodels.IdentityStatusActive,
+	}, nil)
+	passwordManager.EXPECT().Verify(ctx, "1", "correct-password").Return(true)
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	resp, err := svc.SignInWithPassword(ctx, "none@my.com", "correct-password", "web")
+	assert.NoError(t, err, "expected no error for a valid password")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestResetPasswordPolicyViolationKeepsCode(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, the code is not removed
+	ottManager.EXPECT().VerifyCode(ctx, otp.CodeKindPasswordReset, "none@my.com", "123456").Return(true)
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{ID: "1"}, nil)
+	passwordManager.EXPECT().Set(ctx, "1", "short").Return(passwords.ErrTooShort)
+
+	err := svc.ResetPassword(ctx, "none@my.com", "123456", "short")
+	assert.Error(t, err, "expected error for a password violating the policy")
+}
+
+func TestResetPasswordSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	ottManager.EXPECT().VerifyCode(ctx, otp.CodeKindPasswordReset, "none@my.com", "123456").Return(true)
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{ID: "1"}, nil)
+	passwordManager.EXPECT().Set(ctx, "1", "a-brand-new-password").Return(nil)
+	sessionManager.EXPECT().RemoveAllSessions(ctx, "1").Return(true)
+	ottManager.EXPECT().Remove(ctx, otp.CodeKindPasswordReset, "none@my.com").Return(true)
+
+	err := svc.ResetPassword(ctx, "none@my.com", "123456", "a-brand-new-password")
+	assert.NoError(t, err, "expected no error for a valid reset")
+}
+
+func TestResetPasswordFailedRevocationKeepsCode(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, the code is not removed so the reset can be retried
+	ottManager.EXPECT().VerifyCode(ctx, otp.CodeKindPasswordReset, "none@my.com", "123456").Return(true)
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{ID: "1"}, nil)
+	passwordManager.EXPECT().Set(ctx, "1", "a-brand-new-password").Return(nil)
+	sessionManager.EXPECT().RemoveAllSessions(ctx, "1").Return(false)
+
+	err := svc.ResetPassword(ctx, "none@my.com", "123456", "a-brand-new-password")
+	assert.Error(t, err, "expected error when the sessions could not be revoked")
+}
+
+func TestForgotPasswordSendsResetCode(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").Return(&dbmodels.IdentityRecord{ID: "1", Status: dbmodels.IdentityStatusActive}, nil)
+	ottManager.EXPECT().GenerateCode(ctx, 6, otp.CodeKindPasswordReset, "none@my.com").Return("123456", true)
+	asyncActions.EXPECT().SendPasswordResetByEmail(ctx, "123456", "none@my.com")
+
+	err := svc.ForgotPassword(ctx, "None@My.com")
+	assert.NoError(t, err)
+}
+
+func TestChangePasswordInvalidCurrentPassword(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations
+	passwordManager.EXPECT().Verify(ctx, "1", "wrong-password").Return(false)
+
+	err := svc.ChangePassword(ctx, "1", "wrong-password", "a-brand-new-password")
+	assert.Error(t, err, "expected error for an invalid current password")
+}
+
+func TestSessionCookiesOnlyForBrowserClients(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		cookie bool
+	}{
+		{name: "web", source: "web", cookie: true},
+		{name: "mobile", source: "mobile", cookie: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewMockService(t)
+			mux := chi.NewRouter()
+			cookies := security.NewSessionCookies(config.SessionCookieConfigurations{Enabled: true})
+			_ = NewController(mux, svc, sessions.NewMockManager(t), cookies)
+
+			// Expectations
+			svc.EXPECT().SignInWithPassword(mock.Anything, "none@my.com", "correct-password", tt.source).Return(&SignInResponse{
+				AccessToken: "opaque-token",
+				TokenType:   "Bearer",
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}, nil)
+
+			body := `{"email":"none@my.com","password":"correct-password","source":"` + tt.source + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/password/login", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp SignInResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+			if tt.cookie {
+				assert.Empty(t, resp.AccessToken, "expected the token to be left out of the body")
+				assert.Equal(t, sessionCookieTokenType, resp.TokenType)
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), security.SessionCookie+"=opaque-token")
+			} else {
+				assert.Equal(t, "opaque-token", resp.AccessToken)
+				assert.Empty(t, rec.Header().Values("Set-Cookie"))
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackFormPost(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		status int
+	}{
+		{name: "state bound to the user agent", cookie: "the-state", status: http.StatusOK},
+		{name: "state of another user agent", cookie: "another-state", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewMockService(t)
+			mux := chi.NewRouter()
+			_ = NewController(mux, svc, sessions.NewMockManager(t), security.NewSessionCookies(config.SessionCookieConfigurations{}))
+
+			// Expectations
+			if tt.status == http.StatusOK {
+				svc.EXPECT().SignInWithOAuth(mock.Anything, "apple", "the-state", "the-code").Return(&SignInResponse{
+					AccessToken: "opaque-token",
+					TokenType:   "Bearer",
+					ExpiresAt:   time.Now().Add(time.Hour),
+				}, nil)
+			}
+
+			form := url.Values{"state": {"the-state"}, "code": {"the-code"}}
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/oauth/apple/callback", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tt.cookie})
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestOAuthStateCookieIsSentOnFormPost(t *testing.T) {
+	svc := NewMockService(t)
+	mux := chi.NewRouter()
+	_ = NewController(mux, svc, sessions.NewMockManager(t), security.NewSessionCookies(config.SessionCookieConfigurations{}))
+
+	// Expectations
+	svc.EXPECT().StartOAuth(mock.Anything, "apple").Return(&OAuthStartResponse{
+		AuthorizeURL: "https://appleid.apple.com/auth/authorize?response_mode=form_post",
+		State:        "the-state",
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/auth/oauth/apple/start", nil))
+
+	require.Equal(t, http.StatusFound, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "the-state", cookies[0].Value)
+	assert.Equal(t, http.SameSiteNoneMode, cookies[0].SameSite)
+	assert.True(t, cookies[0].Secure)
+}
+
+func TestEmailOTPSignInThenGoogleLinksSameIdentity(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	ottManager := otp.NewMockManager(t)
+	sessionManager := sessions.NewMockManager(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	oidcVerifier := oidc.NewMockVerifier(t)
+	oauthManager := oauth.NewMockManager(t)
+	passwordManager := passwords.NewMockManager(t)
+	asyncActions := actions.NewMockService(t)
+	recorder := audit.NewMockRecorder(t)
+	recorder.EXPECT().Record(mock.Anything, mock.Anything).Maybe()
+
+	svc := NewDefaultService(repo, ottManager, sessionManager, passkeyManager, oidcVerifier, oauthManager, passwordManager, asyncActions, recorder)
+
+	// Expectations, the identity signed up by email OTP and has not verified its email yet
+	stored := dbmodels.IdentityRecord{ID: "1", Email: "none@my.com", Status: dbmodels.IdentityStatusActive}
+	repo.EXPECT().FindOneByEmail(ctx, "none@my.com").RunAndReturn(func(ctx context.Context, email string) (*dbmodels.IdentityRecord, error) {
+		record := stored
+		return &record, nil
+	})
+	repo.EXPECT().MarkEmailVerified(ctx, "1", mock.AnythingOfType("time.Time")).
+		RunAndReturn(func(ctx context.Context, identityID string, verifiedAt time.Time) error {
+			stored.EmailVerifiedAt = &verifiedAt
+			return nil
+		}).Once()
+	sessionManager.EXPECT().CreateSession(ctx, mock.AnythingOfType("sessions.Session"), mock.AnythingOfType("time.Time")).
+		Return("opaque-token", true)
+
+	ottManager.EXPECT().VerifyCode(ctx, otp.CodeKindUserPassword, "none@my.com", "123456").Return(true)
+
+	_, err := svc.VerifyEmailOTP(ctx, "123456", "None@My.com")
+	require.NoError(t, err)
+	require.NotNil(t, stored.EmailVerifiedAt, "expected the OTP to verify the email")
+
+	// Then Google, with the same verified email
+	oauthManager.EXPECT().RedeemNonce(ctx, "google", "nonce").Return(true)
+	oidcVerifier.EXPECT().Verify(ctx, "google", "id-token", "nonce").Return(&oidc.Claims{
+		Subject:       "sub-1",
+		Email:         "none@my.com",
+		EmailVerified: true,
+	}, true)
+	repo.EXPECT().FindProviderLink(ctx, "google", "sub-1").Return(nil, sql.ErrNoRows)
+	repo.EXPECT().CreateProviderLink(ctx, mock.MatchedBy(func(link *dbmodels.IdentityProviderRecord) bool {
+		return link.IdentityID == "1" && link.Provider == "google" && link.Subject == "sub-1"
+	})).Return(nil)
+
+	resp, err := svc.SignInWithOpenID(ctx, "google", "id-token", "nonce", "web")
+	assert.NoError(t, err, "expected the Google account to be linked to the OTP identity")
+	assert.Equal(t, "opaque-token", resp.AccessToken)
+}
+
+func TestIssueOpenIDNonceUnknownProvider(t *testing.T) {
+	ctx := context.Background()
+	oauthManager := oauth.NewMockManager(t)
+	recorder := audit.NewMockRecorder(t)
+
+	svc := NewDefaultService(NewMockRepo(t), otp.NewMockManager(t), sessions.NewMockManager(t), webauthn.NewMockManager(t),
+		oidc.NewMockVerifier(t), oauthManager, passwords.NewMockManager(t), actions.NewMockService(t), recorder)
+
+	// Expectations, no nonce is issued
+	oauthManager.EXPECT().IsOpenIDProvider("made-up").Return(false)
+
+	resp, err := svc.IssueOpenIDNonce(ctx, "made-up")
+	assert.EqualError(t, err, "unknown openid provider")
+	assert.Nil(t, resp)
+}
+
+func TestFinishPasskeyRegistrationStorageError(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	passkeyManager := webauthn.NewMockManager(t)
+	recorder := audit.NewMockRecorder(t)
+
+	svc := NewDefaultService(repo, otp.NewMockManager(t), sessions.NewMockManager(t), passkeyManager,
+		oidc.NewMockVerifier(t), oauth.NewMockManager(t), passwords.NewMockManager(t), actions.NewMockService(t), recorder)
+
+	// Expectations, the database is down, the client is not at fault
+	repo.EXPECT().FindOneByID(ctx, "1").Return(&dbmodels.IdentityRecord{ID: "1", Status: dbmodels.IdentityStatusActive}, nil)
+	passkeyManager.EXPECT().FinishRegistration(ctx, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	err := svc.FinishPasskeyRegistration(ctx, "1", webauthn.RegistrationResponse{})
+
+	var terr *terrors.Terror
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, http.StatusInternalServerError, terr.HttpStatusCode)
+}
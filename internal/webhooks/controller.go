@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/zeusito/toci/pkg/router"
+	"github.com/zeusito/toci/pkg/security"
+	"github.com/zeusito/toci/pkg/security/sessions"
+)
+
+type Controller struct {
+	svc Service
+}
+
+func NewController(mux *chi.Mux, svc Service, sessionManager sessions.Manager) *Controller {
+	c := &Controller{svc: svc}
+
+	mux.Group(func(r chi.Router) {
+		r.Use(security.AuthenticationFilter(sessionManager))
+		r.Post("/v1/organizations/{orgID}/webhooks", c.handleCreateEndpoint)
+		r.Get("/v1/organizations/{orgID}/webhooks", c.handleListEndpoints)
+		r.Delete("/v1/organizations/{orgID}/webhooks/{id}", c.handleDeleteEndpoint)
+		r.Post("/v1/organizations/{orgID}/webhooks/{id}/enable", c.handleEnableEndpoint)
+		r.Get("/v1/organizations/{orgID}/webhooks/{id}/deliveries", c.handleListDeliveries)
+		r.Post("/v1/organizations/{orgID}/webhooks/{id}/deliveries/{deliveryID}/replay", c.handleReplayDelivery)
+	})
+
+	return c
+}
+
+func (c *Controller) handleCreateEndpoint(w http.ResponseWriter, req *http.Request) {
+	var body CreateEndpointRequest
+	err := router.BindBody(req, &body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.CreateEndpoint(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"), body)
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusCreated, resp)
+}
+
+func (c *Controller) handleListEndpoints(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.ListEndpoints(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handleDeleteEndpoint(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err := c.svc.DeleteEndpoint(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"), chi.URLParam(req, "id"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleEnableEndpoint(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err := c.svc.EnableEndpoint(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"), chi.URLParam(req, "id"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
+
+func (c *Controller) handleListDeliveries(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	resp, err := c.svc.ListDeliveries(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"), chi.URLParam(req, "id"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, resp)
+}
+
+func (c *Controller) handleReplayDelivery(w http.ResponseWriter, req *http.Request) {
+	claims := sessions.ExtractClaimsFromContext(req.Context())
+
+	err := c.svc.ReplayDelivery(req.Context(), claims.PrincipalID, chi.URLParam(req, "orgID"), chi.URLParam(req, "id"),
+		chi.URLParam(req, "deliveryID"))
+	if err != nil {
+		router.RenderErrorFor(w, req, err)
+		return
+	}
+
+	router.Render(w, req, http.StatusOK, router.SimpleSuccessResponseBody())
+}
@@ -0,0 +1,214 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/jobs"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+const JobKindDelivery = "webhook.delivery"
+
+const (
+	defaultTimeout      = 10 * time.Second
+	defaultMaxAttempts  = 8
+	defaultDisableAfter = 20
+)
+
+// Headers of a delivery. The signature is the HMAC-SHA256 of "<timestamp>.<body>" with the secret of the
+// endpoint, receivers should reject old timestamps to prevent replays.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+	// signatureVersion prefixes the signature, so the scheme can evolve
+	signatureVersion = "v1="
+)
+
+type DeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Sign returns the signature header of a body sent at the given time
+func Sign(secret string, timestamp time.Time, body []byte) (string, error) {
+	signer, err := hasher.NewHmacSHA256(base64.StdEncoding.EncodeToString([]byte(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := signer.Hash(strconv.FormatInt(timestamp.Unix(), 10) + "." + string(body))
+	if err != nil {
+		return "", err
+	}
+
+	return signatureVersion + hash, nil
+}
+
+// Deliverer sends the deliveries scheduled in the job queue, failed attempts are retried with the backoff of the jobs
+type Deliverer struct {
+	repo         Repo
+	httpClient   *http.Client
+	disableAfter int
+}
+
+func NewDeliverer(repo Repo, cfg config.WebhooksConfigurations) *Deliverer {
+	dialer := &net.Dialer{
+		Timeout: withDefault(cfg.Timeout, defaultTimeout),
+		Control: newDestinationGuard(cfg).control,
+	}
+
+	return &Deliverer{
+		repo: repo,
+		httpClient: &http.Client{
+			Timeout: withDefault(cfg.Timeout, defaultTimeout),
+			// No proxy, the address dialed is the one of the endpoint and it is checked by the dialer
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			// A redirect is a misconfigured endpoint, the signed body is not sent anywhere else
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		disableAfter: withDefault(cfg.DisableAfter, defaultDisableAfter),
+	}
+}
+
+// Handle attempts a delivery, it is the handler of the delivery jobs
+func (d *Deliverer) Handle(ctx context.Context, job *jobs.Job) error {
+	var payload DeliveryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	delivery, endpoint, err := d.repo.FindDeliveryWithEndpoint(ctx, payload.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the endpoint was deleted in the meantime
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status == dbmodels.WebhookDeliveryStatusSucceeded {
+		return nil
+	}
+
+	if endpoint.Status != dbmodels.WebhookEndpointStatusActive {
+		// nothing was sent, the failures of the endpoint are left as they are
+		if err := d.repo.CancelDelivery(ctx, delivery.ID, "endpoint is disabled"); err != nil {
+			return err
+		}
+		return jobs.Permanent(errors.New("webhook endpoint is disabled"))
+	}
+
+	responseStatus, sendErr := d.send(ctx, endpoint, delivery)
+	if sendErr == nil {
+		return d.repo.RecordSuccess(ctx, delivery.ID, endpoint.ID, responseStatus)
+	}
+
+	final := job.Attempts >= job.MaxAttempts
+	disabled, err := d.repo.RecordFailure(ctx, delivery.ID, endpoint.ID, responseStatus, sendErr.Error(), final, d.disableAfter)
+	if err != nil {
+		return errors.Join(sendErr, err)
+	}
+
+	if disabled {
+		log.Warn().Str("endpoint", endpoint.ID).Str("organization", endpoint.OrganizationID).
+			Msgf("webhook endpoint disabled after %d consecutive failures", d.disableAfter)
+		return jobs.Permanent(sendErr)
+	}
+
+	return sendErr
+}
+
+// send posts the event to the endpoint, it returns the response status, 0 when there was no response
+func (d *Deliverer) send(ctx context.Context, endpoint *dbmodels.WebhookEndpointRecord, delivery *dbmodels.WebhookDeliveryRecord) (int, error) {
+	now := time.Now()
+
+	signature, err := Sign(endpoint.Secret.Plaintext(), now, delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The event ID stays the same across attempts and replays, receivers deduplicate with it
+	req.Header.Set(HeaderID, delivery.EventID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, signature)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The body is drained so the connection is reused, it is never stored: the error is shown to the
+	// organization and the response could be the one of an internal service
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sink fans the events of the outbox out to the endpoints subscribed to them
+type Sink struct {
+	repo Repo
+}
+
+func NewSink(repo Repo) *Sink {
+	return &Sink{repo: repo}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Publish(ctx context.Context, event *events.Event) error {
+	scheduled, err := s.repo.FanOut(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if scheduled > 0 {
+		log.Debug().Str("event", event.ID).Msgf("event scheduled for %d webhook endpoints", scheduled)
+	}
+
+	return nil
+}
+
+func withDefault[T int | time.Duration](value, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,131 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"github.com/zeusito/toci/pkg/config"
+)
+
+var errPrivateDestination = errors.New("webhook endpoints must resolve to public addresses")
+
+// nonPublicPrefixes the loopback, private, link-local (cloud metadata), shared, documentation, multicast and
+// reserved ranges, plus the IPv6 transition ranges able to embed any IPv4 address
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// destinationGuard keeps the deliveries away from the internal network. The URL of an endpoint is checked
+// when it is registered and the address dialed is checked on every delivery, so a DNS record changed in
+// between cannot point an endpoint at an internal service.
+type destinationGuard struct {
+	allowPrivate bool
+	lookup       func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+func newDestinationGuard(cfg config.WebhooksConfigurations) *destinationGuard {
+	return &destinationGuard{
+		allowPrivate: cfg.AllowPrivateDestinations,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+}
+
+// validate checks the URL of an endpoint being registered, it must be https and resolve to public addresses only
+func (g *destinationGuard) validate(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("webhook endpoints must be https URLs")
+	}
+
+	if g.allowPrivate {
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		if !isPublicAddr(addr) {
+			return errPrivateDestination
+		}
+		return nil
+	}
+
+	addrs, err := g.lookup(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve the webhook endpoint: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return errPrivateDestination
+		}
+	}
+
+	return nil
+}
+
+// control is the Control of the dialer of the deliveries, it runs after the resolution with the address dialed
+func (g *destinationGuard) control(_, address string, _ syscall.RawConn) error {
+	if g.allowPrivate {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddr(addrPort.Addr()) {
+		return errPrivateDestination
+	}
+
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.Zone() != "" {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
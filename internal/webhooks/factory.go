@@ -0,0 +1,23 @@
+package webhooks
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/jobs"
+	"github.com/zeusito/toci/pkg/security/sessions"
+)
+
+// InitModule registers the routes, the delivery jobs and the sink fanning the events out, the pool and the
+// relay must not be started yet
+func InitModule(mux *chi.Mux, db *bun.DB, sessionManager sessions.Manager, jobQueue jobs.Queue, jobPool *jobs.Pool,
+	eventRelay *events.Relay, recorder audit.Recorder, cfg config.WebhooksConfigurations) {
+	repo := NewDefaultRepo(db, jobQueue, withDefault(cfg.MaxAttempts, defaultMaxAttempts))
+	svc := NewDefaultService(repo, recorder, cfg)
+	_ = NewController(mux, svc, sessionManager)
+
+	jobPool.Handle(JobKindDelivery, NewDeliverer(repo, cfg).Handle)
+	eventRelay.AddSink(NewSink(repo))
+}
@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"time"
+)
+
+type CreateEndpointRequest struct {
+	URL string `json:"url" validate:"required,max=2048,https_url"`
+	// empty for all the event types
+	EventTypes []string `json:"eventTypes" validate:"max=20,dive,required,max=100"`
+}
+
+type EndpointResponse struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"eventTypes"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+// CreatedEndpointResponse the signing secret is only returned when the endpoint is created
+type CreatedEndpointResponse struct {
+	EndpointResponse
+	Secret string `json:"secret"`
+}
+
+type DeliveryResponse struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"responseStatus"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
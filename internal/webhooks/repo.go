@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/events"
+)
+
+type Repo interface {
+	// FindMemberRole returns sql.ErrNoRows when the identity is not a member of the organization
+	FindMemberRole(ctx context.Context, organizationID, identityID string) (dbmodels.OrganizationRole, error)
+	CreateEndpoint(ctx context.Context, record *dbmodels.WebhookEndpointRecord) error
+	ListEndpoints(ctx context.Context, organizationID string) ([]dbmodels.WebhookEndpointRecord, error)
+	// FindEndpoint returns sql.ErrNoRows when the endpoint does not belong to the organization
+	FindEndpoint(ctx context.Context, organizationID, endpointID string) (*dbmodels.WebhookEndpointRecord, error)
+	// DeleteEndpoint returns sql.ErrNoRows when the endpoint does not belong to the organization
+	DeleteEndpoint(ctx context.Context, organizationID, endpointID string) error
+	// EnableEndpoint activates a disabled endpoint and clears its failures
+	EnableEndpoint(ctx context.Context, endpointID string) error
+	ListDeliveries(ctx context.Context, endpointID string, limit int) ([]dbmodels.WebhookDeliveryRecord, error)
+	// FindDelivery returns sql.ErrNoRows when the delivery does not belong to the endpoint
+	FindDelivery(ctx context.Context, endpointID, deliveryID string) (*dbmodels.WebhookDeliveryRecord, error)
+	// ReplayDelivery sets the delivery back to pending and schedules it again
+	ReplayDelivery(ctx context.Context, deliveryID string) error
+	// FanOut creates a delivery of the event for every subscribed endpoint of the organizations of its
+	// identity and schedules them, an event fanned out twice is only delivered once
+	FanOut(ctx context.Context, event *events.Event) (int, error)
+	// FindDeliveryWithEndpoint returns the delivery to attempt and its endpoint
+	FindDeliveryWithEndpoint(ctx context.Context, deliveryID string) (*dbmodels.WebhookDeliveryRecord, *dbmodels.WebhookEndpointRecord, error)
+	// RecordSuccess marks the delivery as succeeded and clears the failures of the endpoint
+	RecordSuccess(ctx context.Context, deliveryID, endpointID string, responseStatus int) error
+	// RecordFailure records a failed attempt, the delivery fails for good when final. The endpoint is
+	// disabled once it reaches disableAfter consecutive failures, it returns whether it got disabled.
+	RecordFailure(ctx context.Context, deliveryID, endpointID string, responseStatus int, lastError string, final bool,
+		disableAfter int) (bool, error)
+	// CancelDelivery fails the delivery for good without attempting it, the failures of the endpoint are unchanged
+	CancelDelivery(ctx context.Context, deliveryID, lastError string) error
+}
@@ -0,0 +1,361 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/jobs"
+)
+
+type defaultRepo struct {
+	db    *bun.DB
+	queue jobs.Queue
+	// attempts of the delivery jobs
+	maxAttempts int
+}
+
+func NewDefaultRepo(db *bun.DB, queue jobs.Queue, maxAttempts int) Repo {
+	return &defaultRepo{db: db, queue: queue, maxAttempts: maxAttempts}
+}
+
+func (r *defaultRepo) FindMemberRole(ctx context.Context, organizationID, identityID string) (dbmodels.OrganizationRole, error) {
+	var member dbmodels.OrganizationMemberRecord
+
+	err := r.db.NewSelect().
+		Model(&member).
+		Where("organization_id = ?", organizationID).
+		Where("identity_id = ?", identityID).
+		Scan(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+func (r *defaultRepo) CreateEndpoint(ctx context.Context, record *dbmodels.WebhookEndpointRecord) error {
+	_, err := r.db.NewInsert().Model(record).Exec(ctx)
+
+	return err
+}
+
+func (r *defaultRepo) ListEndpoints(ctx context.Context, organizationID string) ([]dbmodels.WebhookEndpointRecord, error) {
+	var records []dbmodels.WebhookEndpointRecord
+
+	// The secrets are only needed to sign, they are not decrypted here
+	err := r.db.NewSelect().
+		Model(&records).
+		ExcludeColumn("secret").
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Scan(ctx)
+
+	return records, err
+}
+
+func (r *defaultRepo) FindEndpoint(ctx context.Context, organizationID, endpointID string) (*dbmodels.WebhookEndpointRecord, error) {
+	var record dbmodels.WebhookEndpointRecord
+
+	err := r.db.NewSelect().
+		Model(&record).
+		ExcludeColumn("secret").
+		Where("id = ?", endpointID).
+		Where("organization_id = ?", organizationID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *defaultRepo) DeleteEndpoint(ctx context.Context, organizationID, endpointID string) error {
+	res, err := r.db.NewDelete().
+		Model((*dbmodels.WebhookEndpointRecord)(nil)).
+		Where("id = ?", endpointID).
+		Where("organization_id = ?", organizationID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *defaultRepo) EnableEndpoint(ctx context.Context, endpointID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*dbmodels.WebhookEndpointRecord)(nil)).
+		Set("status = ?", dbmodels.WebhookEndpointStatusActive).
+		Set("consecutive_failures = 0").
+		Set("disabled_at = NULL").
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", endpointID).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *defaultRepo) ListDeliveries(ctx context.Context, endpointID string, limit int) ([]dbmodels.WebhookDeliveryRecord, error) {
+	var records []dbmodels.WebhookDeliveryRecord
+
+	err := r.db.NewSelect().
+		Model(&records).
+		ExcludeColumn("payload").
+		Where("endpoint_id = ?", endpointID).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	return records, err
+}
+
+func (r *defaultRepo) FindDelivery(ctx context.Context, endpointID, deliveryID string) (*dbmodels.WebhookDeliveryRecord, error) {
+	var record dbmodels.WebhookDeliveryRecord
+
+	err := r.db.NewSelect().
+		Model(&record).
+		Where("id = ?", deliveryID).
+		Where("endpoint_id = ?", endpointID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *defaultRepo) ReplayDelivery(ctx context.Context, deliveryID string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*dbmodels.WebhookDeliveryRecord)(nil)).
+			Set("status = ?", dbmodels.WebhookDeliveryStatusPending).
+			Set("updated_at = ?", time.Now().UTC()).
+			Where("id = ?", deliveryID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return r.enqueue(ctx, tx, deliveryID)
+	})
+}
+
+func (r *defaultRepo) FanOut(ctx context.Context, event *events.Event) (int, error) {
+	// Only the events of an identity can be attributed to organizations
+	if event.AggregateType != events.AggregateIdentity {
+		return 0, nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	scheduled := 0
+
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var endpointIDs []string
+
+		err := tx.NewSelect().
+			Model((*dbmodels.WebhookEndpointRecord)(nil)).
+			Column("we.id").
+			Join("JOIN organization_members AS om ON om.organization_id = we.organization_id").
+			Where("om.identity_id = ?", event.AggregateID).
+			Where("we.status = ?", dbmodels.WebhookEndpointStatusActive).
+			Where("(cardinality(we.event_types) = 0 OR ? = ANY(we.event_types))", string(event.Type)).
+			Scan(ctx, &endpointIDs)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+
+		for _, endpointID := range endpointIDs {
+			delivery := &dbmodels.WebhookDeliveryRecord{
+				ID:         uuid.NewString(),
+				EndpointID: endpointID,
+				EventID:    event.ID,
+				EventType:  string(event.Type),
+				Payload:    payload,
+				Status:     dbmodels.WebhookDeliveryStatusPending,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+
+			// The relay delivers at least once, an event it already fanned out is skipped
+			res, err := tx.NewInsert().
+				Model(delivery).
+				On("CONFLICT (endpoint_id, event_id) DO NOTHING").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				continue
+			}
+
+			if err := r.enqueue(ctx, tx, delivery.ID); err != nil {
+				return err
+			}
+			scheduled++
+		}
+
+		return nil
+	})
+
+	return scheduled, err
+}
+
+func (r *defaultRepo) FindDeliveryWithEndpoint(ctx context.Context, deliveryID string) (*dbmodels.WebhookDeliveryRecord, *dbmodels.WebhookEndpointRecord, error) {
+	var delivery dbmodels.WebhookDeliveryRecord
+
+	err := r.db.NewSelect().
+		Model(&delivery).
+		Where("id = ?", deliveryID).
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var endpoint dbmodels.WebhookEndpointRecord
+
+	err = r.db.NewSelect().
+		Model(&endpoint).
+		Where("id = ?", delivery.EndpointID).
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &delivery, &endpoint, nil
+}
+
+func (r *defaultRepo) RecordSuccess(ctx context.Context, deliveryID, endpointID string, responseStatus int) error {
+	now := time.Now().UTC()
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*dbmodels.WebhookDeliveryRecord)(nil)).
+			Set("status = ?", dbmodels.WebhookDeliveryStatusSucceeded).
+			Set("attempts = attempts + 1").
+			Set("response_status = ?", responseStatus).
+			Set("last_error = ''").
+			Set("delivered_at = ?", now).
+			Set("updated_at = ?", now).
+			Where("id = ?", deliveryID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*dbmodels.WebhookEndpointRecord)(nil)).
+			Set("consecutive_failures = 0").
+			Where("id = ?", endpointID).
+			Where("consecutive_failures > 0").
+			Exec(ctx)
+
+		return err
+	})
+}
+
+func (r *defaultRepo) RecordFailure(ctx context.Context, deliveryID, endpointID string, responseStatus int, lastError string,
+	final bool, disableAfter int) (bool, error) {
+	now := time.Now().UTC()
+	disabled := false
+
+	status := dbmodels.WebhookDeliveryStatusPending
+	if final {
+		status = dbmodels.WebhookDeliveryStatusFailed
+	}
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*dbmodels.WebhookDeliveryRecord)(nil)).
+			Set("status = ?", status).
+			Set("attempts = attempts + 1").
+			Set("response_status = ?", responseStatus).
+			Set("last_error = ?", lastError).
+			Set("updated_at = ?", now).
+			Where("id = ?", deliveryID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		var failures int
+
+		err = tx.NewUpdate().
+			Model((*dbmodels.WebhookEndpointRecord)(nil)).
+			Set("consecutive_failures = consecutive_failures + 1").
+			Where("id = ?", endpointID).
+			Returning("consecutive_failures").
+			Scan(ctx, &failures)
+		if err != nil {
+			return err
+		}
+
+		if failures < disableAfter {
+			return nil
+		}
+
+		res, err := tx.NewUpdate().
+			Model((*dbmodels.WebhookEndpointRecord)(nil)).
+			Set("status = ?", dbmodels.WebhookEndpointStatusDisabled).
+			Set("disabled_at = ?", now).
+			Set("updated_at = ?", now).
+			Where("id = ?", endpointID).
+			Where("status = ?", dbmodels.WebhookEndpointStatusActive).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		disabled = affected > 0
+
+		return err
+	})
+
+	return disabled, err
+}
+
+func (r *defaultRepo) CancelDelivery(ctx context.Context, deliveryID, lastError string) error {
+	_, err := r.db.NewUpdate().
+		Model((*dbmodels.WebhookDeliveryRecord)(nil)).
+		Set("status = ?", dbmodels.WebhookDeliveryStatusFailed).
+		Set("last_error = ?", lastError).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", deliveryID).
+		Exec(ctx)
+
+	return err
+}
+
+// enqueue schedules an attempt of the delivery within the transaction
+func (r *defaultRepo) enqueue(ctx context.Context, tx bun.IDB, deliveryID string) error {
+	return r.queue.EnqueueTx(ctx, tx, JobKindDelivery, DeliveryPayload{DeliveryID: deliveryID}, jobs.WithMaxAttempts(r.maxAttempts))
+}
@@ -0,0 +1,15 @@
+package webhooks
+
+import (
+	"context"
+)
+
+// Service manages the webhook endpoints of an organization, only its owners and admins are allowed to
+type Service interface {
+	CreateEndpoint(ctx context.Context, principalID, organizationID string, req CreateEndpointRequest) (*CreatedEndpointResponse, error)
+	ListEndpoints(ctx context.Context, principalID, organizationID string) ([]EndpointResponse, error)
+	DeleteEndpoint(ctx context.Context, principalID, organizationID, endpointID string) error
+	EnableEndpoint(ctx context.Context, principalID, organizationID, endpointID string) error
+	ListDeliveries(ctx context.Context, principalID, organizationID, endpointID string) ([]DeliveryResponse, error)
+	ReplayDelivery(ctx context.Context, principalID, organizationID, endpointID, deliveryID string) error
+}
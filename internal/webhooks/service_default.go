@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/terrors"
+	"github.com/zeusito/toci/pkg/toolbox"
+	"github.com/zeusito/toci/pkg/toolbox/crypto"
+)
+
+const (
+	// secrets are prefixed so they are easy to recognize, e.g. by secret scanners
+	secretPrefix = "whsec_"
+	secretLength = 40
+	// most recent deliveries listed per endpoint
+	deliveriesPageSize = 100
+)
+
+type DefaultService struct {
+	repo         Repo
+	recorder     audit.Recorder
+	destinations *destinationGuard
+}
+
+func NewDefaultService(repo Repo, recorder audit.Recorder, cfg config.WebhooksConfigurations) Service {
+	return &DefaultService{repo: repo, recorder: recorder, destinations: newDestinationGuard(cfg)}
+}
+
+func (s *DefaultService) CreateEndpoint(ctx context.Context, principalID, organizationID string, req CreateEndpointRequest) (*CreatedEndpointResponse, error) {
+	logger.Ctx(ctx).Info().Msgf("create webhook endpoint for organization: %s", organizationID)
+
+	if err := s.authorize(ctx, principalID, organizationID); err != nil {
+		return nil, err
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(events.KnownTypes, events.Type(eventType)) {
+			return nil, terrors.PreconditionFailed("unknown event type: " + eventType)
+		}
+	}
+
+	if err := s.destinations.validate(ctx, req.URL); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("rejected webhook endpoint: %s", req.URL)
+		return nil, terrors.PreconditionFailed("webhook endpoint must be a public https URL")
+	}
+
+	secret := toolbox.SecureRandomString(secretLength)
+	if secret == "" {
+		logger.Ctx(ctx).Warn().Msg("failed to generate webhook secret")
+		return nil, terrors.Unknown("failed to create webhook endpoint")
+	}
+	secret = secretPrefix + secret
+
+	now := time.Now().UTC()
+	id := uuid.NewString()
+	record := &dbmodels.WebhookEndpointRecord{
+		ID:             id,
+		OrganizationID: organizationID,
+		URL:            req.URL,
+		Secret:         crypto.NewEncryptedString(id, secret),
+		EventTypes:     req.EventTypes,
+		Status:         dbmodels.WebhookEndpointStatusActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if record.EventTypes == nil {
+		record.EventTypes = []string{}
+	}
+
+	if err := s.repo.CreateEndpoint(ctx, record); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to create webhook endpoint")
+		return nil, terrors.Unknown("failed to create webhook endpoint")
+	}
+
+	s.recordAudit(ctx, audit.ActionWebhookCreate, principalID, organizationID, audit.TargetWebhookEndpoint, id, audit.ResultSuccess)
+
+	return &CreatedEndpointResponse{EndpointResponse: toEndpoint(record), Secret: secret}, nil
+}
+
+func (s *DefaultService) ListEndpoints(ctx context.Context, principalID, organizationID string) ([]EndpointResponse, error) {
+	if err := s.authorize(ctx, principalID, organizationID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.ListEndpoints(ctx, organizationID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to list webhook endpoints: %s", organizationID)
+		return nil, terrors.Unknown("failed to list webhook endpoints")
+	}
+
+	resp := make([]EndpointResponse, 0, len(records))
+	for i := range records {
+		resp = append(resp, toEndpoint(&records[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *DefaultService) DeleteEndpoint(ctx context.Context, principalID, organizationID, endpointID string) error {
+	logger.Ctx(ctx).Info().Msgf("delete webhook endpoint %s of organization: %s", endpointID, organizationID)
+
+	if err := s.authorize(ctx, principalID, organizationID); err != nil {
+		return err
+	}
+
+	err := s.repo.DeleteEndpoint(ctx, organizationID, endpointID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return terrors.RecordNotFound("webhook endpoint not found")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to delete webhook endpoint")
+		return terrors.Unknown("failed to delete webhook endpoint")
+	}
+
+	s.recordAudit(ctx, audit.ActionWebhookDelete, principalID, organizationID, audit.TargetWebhookEndpoint, endpointID, audit.ResultSuccess)
+
+	return nil
+}
+
+func (s *DefaultService) EnableEndpoint(ctx context.Context, principalID, organizationID, endpointID string) error {
+	logger.Ctx(ctx).Info().Msgf("enable webhook endpoint %s of organization: %s", endpointID, organizationID)
+
+	if _, err := s.findEndpoint(ctx, principalID, organizationID, endpointID); err != nil {
+		return err
+	}
+
+	if err := s.repo.EnableEndpoint(ctx, endpointID); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to enable webhook endpoint")
+		return terrors.Unknown("failed to enable webhook endpoint")
+	}
+
+	s.recordAudit(ctx, audit.ActionWebhookEnable, principalID, organizationID, audit.TargetWebhookEndpoint, endpointID, audit.ResultSuccess)
+
+	return nil
+}
+
+func (s *DefaultService) ListDeliveries(ctx context.Context, principalID, organizationID, endpointID string) ([]DeliveryResponse, error) {
+	if _, err := s.findEndpoint(ctx, principalID, organizationID, endpointID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.ListDeliveries(ctx, endpointID, deliveriesPageSize)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to list webhook deliveries: %s", endpointID)
+		return nil, terrors.Unknown("failed to list webhook deliveries")
+	}
+
+	resp := make([]DeliveryResponse, 0, len(records))
+	for i := range records {
+		resp = append(resp, toDelivery(&records[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *DefaultService) ReplayDelivery(ctx context.Context, principalID, organizationID, endpointID, deliveryID string) error {
+	logger.Ctx(ctx).Info().Msgf("replay webhook delivery %s of endpoint: %s", deliveryID, endpointID)
+
+	endpoint, err := s.findEndpoint(ctx, principalID, organizationID, endpointID)
+	if err != nil {
+		return err
+	}
+
+	if endpoint.Status != dbmodels.WebhookEndpointStatusActive {
+		return terrors.PreconditionFailed("webhook endpoint is disabled")
+	}
+
+	delivery, err := s.repo.FindDelivery(ctx, endpointID, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return terrors.RecordNotFound("webhook delivery not found")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to find webhook delivery")
+		return terrors.Unknown("failed to replay webhook delivery")
+	}
+
+	if delivery.Status == dbmodels.WebhookDeliveryStatusPending {
+		return terrors.PreconditionFailed("webhook delivery is already scheduled")
+	}
+
+	if err := s.repo.ReplayDelivery(ctx, deliveryID); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to replay webhook delivery")
+		return terrors.Unknown("failed to replay webhook delivery")
+	}
+
+	s.recordAudit(ctx, audit.ActionWebhookReplay, principalID, organizationID, audit.TargetWebhookDelivery, deliveryID, audit.ResultSuccess)
+
+	return nil
+}
+
+// authorize allows the owners and admins of the organization
+func (s *DefaultService) authorize(ctx context.Context, principalID, organizationID string) error {
+	role, err := s.repo.FindMemberRole(ctx, organizationID, principalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Ctx(ctx).Warn().Err(err).Msgf("failed to find membership of organization: %s", organizationID)
+		return terrors.Unknown("failed to authorize")
+	}
+
+	if role != dbmodels.OrganizationRoleOwner && role != dbmodels.OrganizationRoleAdmin {
+		logger.Ctx(ctx).Warn().Msgf("identity %s may not manage the webhooks of organization: %s", principalID, organizationID)
+		s.recordAudit(ctx, audit.ActionWebhookManage, principalID, organizationID, "", "", audit.ResultDenied)
+		return terrors.Forbidden("identity is not allowed to manage webhooks")
+	}
+
+	return nil
+}
+
+// findEndpoint authorizes the principal and returns the endpoint of the organization
+func (s *DefaultService) findEndpoint(ctx context.Context, principalID, organizationID, endpointID string) (*dbmodels.WebhookEndpointRecord, error) {
+	if err := s.authorize(ctx, principalID, organizationID); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.repo.FindEndpoint(ctx, organizationID, endpointID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, terrors.RecordNotFound("webhook endpoint not found")
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to find webhook endpoint")
+		return nil, terrors.Unknown("failed to find webhook endpoint")
+	}
+
+	return endpoint, nil
+}
+
+// recordAudit records an action of a principal on a webhook resource of the organization
+func (s *DefaultService) recordAudit(ctx context.Context, action, principalID, organizationID, targetType, targetID string, result audit.Result) {
+	s.recorder.Record(ctx, audit.Event{
+		Action:         action,
+		ActorID:        principalID,
+		OrganizationID: organizationID,
+		TargetType:     targetType,
+		TargetID:       targetID,
+		Result:         result,
+	})
+}
+
+func toEndpoint(record *dbmodels.WebhookEndpointRecord) EndpointResponse {
+	return EndpointResponse{
+		ID:                  record.ID,
+		URL:                 record.URL,
+		EventTypes:          record.EventTypes,
+		Status:              string(record.Status),
+		ConsecutiveFailures: record.ConsecutiveFailures,
+		DisabledAt:          record.DisabledAt,
+		CreatedAt:           record.CreatedAt,
+	}
+}
+
+func toDelivery(record *dbmodels.WebhookDeliveryRecord) DeliveryResponse {
+	return DeliveryResponse{
+		ID:             record.ID,
+		EventID:        record.EventID,
+		EventType:      record.EventType,
+		Status:         string(record.Status),
+		Attempts:       record.Attempts,
+		ResponseStatus: record.ResponseStatus,
+		LastError:      record.LastError,
+		CreatedAt:      record.CreatedAt,
+		DeliveredAt:    record.DeliveredAt,
+	}
+}
@@ -0,0 +1,402 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/internal/dbmodels"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/events"
+	"github.com/zeusito/toci/pkg/jobs"
+	"github.com/zeusito/toci/pkg/toolbox/crypto"
+)
+
+const testSecret = "whsec_test-secret"
+
+// localDestinations lets the deliveries reach the test servers listening on the loopback
+var localDestinations = config.WebhooksConfigurations{AllowPrivateDestinations: true}
+
+// newTestService resolves every host to a public address
+func newTestService(repo Repo, recorder audit.Recorder) Service {
+	svc := NewDefaultService(repo, recorder, config.WebhooksConfigurations{}).(*DefaultService)
+	svc.destinations.lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+	}
+
+	return svc
+}
+
+func testEndpoint(url string) *dbmodels.WebhookEndpointRecord {
+	return &dbmodels.WebhookEndpointRecord{
+		ID:             "endpoint-1",
+		OrganizationID: "org-1",
+		URL:            url,
+		Secret:         crypto.NewEncryptedString("endpoint-1", testSecret),
+		Status:         dbmodels.WebhookEndpointStatusActive,
+	}
+}
+
+func testDelivery() *dbmodels.WebhookDeliveryRecord {
+	return &dbmodels.WebhookDeliveryRecord{
+		ID:         "delivery-1",
+		EndpointID: "endpoint-1",
+		EventID:    "event-1",
+		EventType:  string(events.TypeSessionCreated),
+		Payload:    []byte(`{"id":"event-1","type":"session.created"}`),
+		Status:     dbmodels.WebhookDeliveryStatusPending,
+	}
+}
+
+func testJob(attempts int) *jobs.Job {
+	return &jobs.Job{
+		Kind:        JobKindDelivery,
+		Payload:     []byte(`{"delivery_id":"delivery-1"}`),
+		Attempts:    attempts,
+		MaxAttempts: 3,
+	}
+}
+
+func TestCreateEndpointRequiresAdmin(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleMember, nil)
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionWebhookManage && event.Result == audit.ResultDenied
+	})).Return()
+
+	resp, err := svc.CreateEndpoint(ctx, "1", "org-1", CreateEndpointRequest{URL: "https://hooks.example.com"})
+	assert.Error(t, err, "expected error for a member who is not an admin")
+	assert.Nil(t, resp)
+}
+
+func TestCreateEndpointNotAMember(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return("", sql.ErrNoRows)
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionWebhookManage && event.Result == audit.ResultDenied
+	})).Return()
+
+	resp, err := svc.CreateEndpoint(ctx, "1", "org-1", CreateEndpointRequest{URL: "https://hooks.example.com"})
+	assert.Error(t, err, "expected error for an identity outside of the organization")
+	assert.Nil(t, resp)
+}
+
+func TestCreateEndpointUnknownEventType(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleAdmin, nil)
+
+	resp, err := svc.CreateEndpoint(ctx, "1", "org-1", CreateEndpointRequest{
+		URL:        "https://hooks.example.com",
+		EventTypes: []string{"identity.deleted"},
+	})
+	assert.Error(t, err, "expected error for an unknown event type")
+	assert.Nil(t, resp)
+}
+
+func TestCreateEndpointSuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleOwner, nil)
+	repo.EXPECT().CreateEndpoint(ctx, mock.Anything).
+		RunAndReturn(func(ctx context.Context, record *dbmodels.WebhookEndpointRecord) error {
+			assert.Equal(t, "org-1", record.OrganizationID)
+			assert.Equal(t, []string{"session.created"}, record.EventTypes)
+			assert.Equal(t, dbmodels.WebhookEndpointStatusActive, record.Status)
+			return nil
+		})
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionWebhookCreate && event.ActorID == "1" && event.OrganizationID == "org-1"
+	})).Return()
+
+	resp, err := svc.CreateEndpoint(ctx, "1", "org-1", CreateEndpointRequest{
+		URL:        "https://hooks.example.com",
+		EventTypes: []string{"session.created"},
+	})
+	require.NoError(t, err, "expected no error for an admin")
+	assert.True(t, strings.HasPrefix(resp.Secret, secretPrefix))
+	assert.Len(t, resp.Secret, len(secretPrefix)+secretLength)
+	assert.Equal(t, "https://hooks.example.com", resp.URL)
+}
+
+func TestCreateEndpointRejectsInternalDestinations(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+	svc.(*DefaultService).destinations.lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.7")}, nil
+	}
+
+	for _, url := range []string{
+		"http://hooks.example.com",
+		"https://127.0.0.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://[::ffff:192.168.1.1]/hook",
+		"https://[fd00:ec2::254]/hook",
+		"https://hooks.example.com",
+	} {
+		t.Run(url, func(t *testing.T) {
+			// Expectations
+			repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleOwner, nil).Once()
+
+			resp, err := svc.CreateEndpoint(ctx, "1", "org-1", CreateEndpointRequest{URL: url})
+			assert.Error(t, err, "expected error for an internal destination")
+			assert.Nil(t, resp)
+		})
+	}
+}
+
+func TestReplayDeliveryDisabledEndpoint(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	endpoint := testEndpoint("https://hooks.example.com")
+	endpoint.Status = dbmodels.WebhookEndpointStatusDisabled
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleAdmin, nil)
+	repo.EXPECT().FindEndpoint(ctx, "org-1", "endpoint-1").Return(endpoint, nil)
+
+	err := svc.ReplayDelivery(ctx, "1", "org-1", "endpoint-1", "delivery-1")
+	assert.Error(t, err, "expected error for a disabled endpoint")
+}
+
+func TestReplayDeliveryAnotherOrganization(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleAdmin, nil)
+	repo.EXPECT().FindEndpoint(ctx, "org-1", "endpoint-2").Return(nil, sql.ErrNoRows)
+
+	err := svc.ReplayDelivery(ctx, "1", "org-1", "endpoint-2", "delivery-1")
+	assert.Error(t, err, "expected error for an endpoint of another organization")
+}
+
+func TestReplayDeliverySuccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	recorder := audit.NewMockRecorder(t)
+	svc := newTestService(repo, recorder)
+
+	delivery := testDelivery()
+	delivery.Status = dbmodels.WebhookDeliveryStatusFailed
+
+	// Expectations
+	repo.EXPECT().FindMemberRole(ctx, "org-1", "1").Return(dbmodels.OrganizationRoleAdmin, nil)
+	repo.EXPECT().FindEndpoint(ctx, "org-1", "endpoint-1").Return(testEndpoint("https://hooks.example.com"), nil)
+	repo.EXPECT().FindDelivery(ctx, "endpoint-1", "delivery-1").Return(delivery, nil)
+	repo.EXPECT().ReplayDelivery(ctx, "delivery-1").Return(nil)
+	recorder.EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionWebhookReplay && event.TargetID == "delivery-1"
+	})).Return()
+
+	err := svc.ReplayDelivery(ctx, "1", "org-1", "endpoint-1", "delivery-1")
+	assert.NoError(t, err, "expected no error for a failed delivery")
+}
+
+func TestDeliverySignedWithTimestampAndBody(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	delivery := testDelivery()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), 5*time.Second)
+
+		expected, err := Sign(testSecret, time.Unix(timestamp, 0), body)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, r.Header.Get(HeaderSignature))
+		assert.Equal(t, "event-1", r.Header.Get(HeaderID))
+		assert.Equal(t, "session.created", r.Header.Get(HeaderEvent))
+		assert.JSONEq(t, string(delivery.Payload), string(body))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(delivery, testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordSuccess(ctx, "delivery-1", "endpoint-1", http.StatusNoContent).Return(nil)
+
+	err := NewDeliverer(repo, localDestinations).Handle(ctx, testJob(1))
+	assert.NoError(t, err)
+}
+
+func TestSignatureDependsOnTimestamp(t *testing.T) {
+	body := []byte(`{"id":"event-1"}`)
+	now := time.Now()
+
+	first, err := Sign(testSecret, now, body)
+	require.NoError(t, err)
+	second, err := Sign(testSecret, now.Add(time.Minute), body)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, signatureVersion))
+	assert.NotEqual(t, first, second)
+}
+
+func TestDeliveryFailureIsRetried(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordFailure(ctx, "delivery-1", "endpoint-1", http.StatusInternalServerError,
+		"endpoint responded with status 500", false, defaultDisableAfter).Return(false, nil)
+
+	err := NewDeliverer(repo, localDestinations).Handle(ctx, testJob(1))
+	assert.Error(t, err, "expected error so the delivery is retried")
+	assert.False(t, jobs.IsPermanent(err))
+}
+
+func TestDeliveryLastAttemptFails(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordFailure(ctx, "delivery-1", "endpoint-1", http.StatusBadGateway, mock.Anything, true, defaultDisableAfter).
+		Return(false, nil)
+
+	err := NewDeliverer(repo, localDestinations).Handle(ctx, testJob(3))
+	assert.Error(t, err, "expected error for the last attempt")
+}
+
+func TestDeliveryRedirectIsAFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://elsewhere.example.com", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordFailure(ctx, "delivery-1", "endpoint-1", http.StatusFound, mock.Anything, false, defaultDisableAfter).
+		Return(false, nil)
+
+	err := NewDeliverer(repo, localDestinations).Handle(ctx, testJob(1))
+	assert.Error(t, err, "expected error for a redirect")
+}
+
+func TestDeliveryDisablesFailingEndpoint(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordFailure(ctx, "delivery-1", "endpoint-1", http.StatusServiceUnavailable, mock.Anything, false, 2).
+		Return(true, nil)
+
+	err := NewDeliverer(repo, config.WebhooksConfigurations{DisableAfter: 2, AllowPrivateDestinations: true}).Handle(ctx, testJob(1))
+	assert.True(t, jobs.IsPermanent(err), "expected no retry once the endpoint is disabled")
+}
+
+func TestDeliveryToDisabledEndpointIsNotSent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a disabled endpoint must not receive deliveries")
+	}))
+	defer receiver.Close()
+
+	endpoint := testEndpoint(receiver.URL)
+	endpoint.Status = dbmodels.WebhookEndpointStatusDisabled
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), endpoint, nil)
+	repo.EXPECT().CancelDelivery(ctx, "delivery-1", "endpoint is disabled").Return(nil)
+
+	err := NewDeliverer(repo, localDestinations).Handle(ctx, testJob(1))
+	assert.True(t, jobs.IsPermanent(err))
+}
+
+func TestDeliveryDoesNotDialInternalAddresses(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback address must not receive deliveries")
+	}))
+	defer receiver.Close()
+
+	// Expectations
+	repo.EXPECT().FindDeliveryWithEndpoint(ctx, "delivery-1").Return(testDelivery(), testEndpoint(receiver.URL), nil)
+	repo.EXPECT().RecordFailure(ctx, "delivery-1", "endpoint-1", 0, mock.Anything, false, defaultDisableAfter).
+		RunAndReturn(func(ctx context.Context, deliveryID, endpointID string, responseStatus int, lastError string,
+			final bool, disableAfter int) (bool, error) {
+			assert.Contains(t, lastError, errPrivateDestination.Error())
+			return false, nil
+		})
+
+	err := NewDeliverer(repo, config.WebhooksConfigurations{}).Handle(ctx, testJob(1))
+	assert.Error(t, err, "expected error for a loopback address")
+}
+
+func TestSinkFansOutEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockRepo(t)
+	event, err := events.NewSessionRevoked("1")
+	require.NoError(t, err)
+
+	// Expectations
+	repo.EXPECT().FanOut(ctx, event).Return(2, nil)
+
+	assert.NoError(t, NewSink(repo).Publish(ctx, event))
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultStartTimeout = 15 * time.Second
+	DefaultStopTimeout  = 10 * time.Second
+)
+
+// Component a part of the application, started after the components it depends on and stopped before them
+type Component struct {
+	Name string
+	// Start prepares the component, the next one starts once it returned
+	Start func(ctx context.Context) error
+	// Run serves in the background until Stop is called, e.g. an HTTP server. The application shuts down when it
+	// returns an error.
+	Run  func() error
+	Stop func(ctx context.Context) error
+	// bound Start and Stop, the defaults apply when they are zero
+	StartTimeout time.Duration
+	StopTimeout  time.Duration
+}
+
+// Worker runs in the background until it is shut down, e.g. a pool of job workers or a scheduler
+type Worker interface {
+	Start()
+	Shutdown(ctx context.Context) error
+}
+
+// NewWorker the component of a worker
+func NewWorker(name string, worker Worker) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			worker.Start()
+			return nil
+		},
+		Stop: worker.Shutdown,
+	}
+}
+
+// App starts its components in the order they were added and stops them in reverse order
+type App struct {
+	components []Component
+	failures   chan error
+}
+
+func New() *App {
+	return &App{failures: make(chan error, 1)}
+}
+
+// Add registers a component, after the ones it depends on
+func (a *App) Add(components ...Component) {
+	a.components = append(a.components, components...)
+}
+
+// Run starts the components and blocks until ctx is done, SIGINT or SIGTERM is received or a component fails,
+// then stops the started components. It returns the errors of the failed component and of the stops. A second
+// signal kills the process.
+func (a *App) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	started, err := a.start(ctx)
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			log.Warn().Msg("Shutting down gracefully...")
+		case err = <-a.failures:
+			log.Error().Err(err).Msg("Shutting down after a failure...")
+		}
+	}
+
+	// the default behavior of the signals is restored, so a second one kills the process
+	stopSignals()
+
+	return errors.Join(err, a.stop(started))
+}
+
+// start returns the started components, the ones to stop
+func (a *App) start(ctx context.Context) ([]Component, error) {
+	var started []Component
+
+	for _, c := range a.components {
+		if ctx.Err() != nil {
+			return started, nil
+		}
+
+		if c.Start != nil {
+			if err := within(ctx, withDefault(c.StartTimeout, DefaultStartTimeout), c.Start); err != nil {
+				if ctx.Err() != nil {
+					// interrupted by a signal, it is not a failure
+					return started, nil
+				}
+				return started, fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+		}
+		started = append(started, c)
+
+		if c.Run != nil {
+			go a.run(c)
+		}
+
+		log.Info().Msgf("started %s", c.Name)
+	}
+
+	return started, nil
+}
+
+func (a *App) run(c Component) {
+	err := c.Run()
+	if err == nil {
+		return
+	}
+
+	// only the first failure is reported, it already triggered the shutdown
+	select {
+	case a.failures <- fmt.Errorf("%s failed: %w", c.Name, err):
+	default:
+	}
+}
+
+// stop stops the components in reverse order, each within its timeout, even when one of them fails
+func (a *App) stop(started []Component) error {
+	var errs []error
+
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		if err := within(context.Background(), withDefault(c.StopTimeout, DefaultStopTimeout), c.Stop); err != nil {
+			log.Warn().Err(err).Msgf("failed to stop %s", c.Name)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name, err))
+			continue
+		}
+
+		log.Info().Msgf("stopped %s", c.Name)
+	}
+
+	return errors.Join(errs...)
+}
+
+// within returns once the timeout expires even when fn ignores its context, fn is then left running
+func within(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		return ctx.Err()
+	}
+}
+
+func withDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
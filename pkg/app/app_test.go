@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order the components are started and stopped in
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.record("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestRunStartsInOrderAndStopsInReverse(t *testing.T) {
+	rec := &recorder{}
+	app := New()
+	app.Add(rec.component("database"), rec.component("workers"), rec.component("http"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- app.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		return len(rec.events) == 3
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start database", "start workers", "start http", "stop http", "stop workers", "stop database"},
+		rec.events)
+}
+
+func TestRunStopsStartedComponentsWhenOneFailsToStart(t *testing.T) {
+	rec := &recorder{}
+	app := New()
+	failing := rec.component("workers")
+	failing.Start = func(context.Context) error { return errors.New("no handler") }
+	app.Add(rec.component("database"), failing, rec.component("http"))
+
+	err := app.Run(context.Background())
+
+	assert.EqualError(t, err, "failed to start workers: no handler")
+	assert.Equal(t, []string{"start database", "stop database"}, rec.events)
+}
+
+func TestRunShutsDownWhenAComponentFails(t *testing.T) {
+	rec := &recorder{}
+	app := New()
+	server := rec.component("http")
+	server.Run = func() error { return errors.New("address already in use") }
+	app.Add(rec.component("database"), server)
+
+	err := app.Run(context.Background())
+
+	assert.EqualError(t, err, "http failed: address already in use")
+	assert.Equal(t, []string{"start database", "start http", "stop http", "stop database"}, rec.events)
+}
+
+func TestRunBoundsEachStop(t *testing.T) {
+	rec := &recorder{}
+	app := New()
+	stuck := rec.component("workers")
+	stuck.StopTimeout = 10 * time.Millisecond
+	stuck.Stop = func(context.Context) error {
+		select {}
+	}
+	app.Add(rec.component("database"), stuck, Component{Name: "http", Run: func() error { return errors.New("boom") }})
+
+	err := app.Run(context.Background())
+
+	assert.EqualError(t, err, "http failed: boom\nfailed to stop workers: timed out after 10ms")
+	assert.Contains(t, rec.events, "stop database", "expected the next components to stop anyway")
+}
+
+type testWorker struct {
+	started bool
+	stopped bool
+}
+
+func (w *testWorker) Start() { w.started = true }
+
+func (w *testWorker) Shutdown(context.Context) error {
+	w.stopped = true
+	return nil
+}
+
+func TestNewWorker(t *testing.T) {
+	worker := &testWorker{}
+	app := New()
+	app.Add(NewWorker("scheduler", worker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, app.Run(ctx))
+
+	assert.False(t, worker.started, "expected nothing to start once ctx is done")
+
+	app = New()
+	app.Add(NewWorker("scheduler", worker), Component{Name: "failing", Run: func() error { return errors.New("boom") }})
+	require.Error(t, app.Run(context.Background()))
+
+	assert.True(t, worker.started)
+	assert.True(t, worker.stopped)
+}
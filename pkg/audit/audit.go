@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+	// ResultDenied the actor was not allowed to perform the action
+	ResultDenied Result = "denied"
+)
+
+// Actions recorded by the modules, searches filter on them
+const (
+	ActionLoginPassword   = "auth.login.password"
+	ActionLoginOTP        = "auth.login.otp"
+	ActionLoginPasskey    = "auth.login.passkey"
+	ActionLoginExternal   = "auth.login.external"
+	ActionLogout          = "auth.logout"
+	ActionPasswordChange  = "auth.password.change"
+	ActionPasswordReset   = "auth.password.reset"
+	ActionPasskeyRegister = "auth.passkey.register"
+	ActionProviderLink    = "auth.provider.link"
+	ActionProviderUnlink  = "auth.provider.unlink"
+	ActionIdentityLock    = "identity.lock"
+	ActionSessionCreate   = "session.create"
+	ActionSessionRevoke   = "session.revoke"
+	ActionWebhookCreate   = "webhook.create"
+	ActionWebhookDelete   = "webhook.delete"
+	ActionWebhookEnable   = "webhook.enable"
+	ActionWebhookReplay   = "webhook.delivery.replay"
+	// ActionWebhookManage a principal tried to manage the webhooks of an organization it is not an admin of
+	ActionWebhookManage = "webhook.manage"
+	ActionAuditExport   = "audit.export"
+)
+
+// Types of the targets of the actions
+const (
+	TargetIdentity        = "identity"
+	TargetProviderLink    = "provider_link"
+	TargetWebhookEndpoint = "webhook_endpoint"
+	TargetWebhookDelivery = "webhook_delivery"
+	TargetAuditEvents     = "audit_events"
+)
+
+// Event who did what to which target, and how it turned out. The request details are filled in from the
+// context when recorded.
+type Event struct {
+	ID             string            `json:"id"`
+	OccurredAt     time.Time         `json:"occurredAt"`
+	ActorID        string            `json:"actorId,omitempty"`
+	OrganizationID string            `json:"organizationId,omitempty"`
+	Action         string            `json:"action"`
+	TargetType     string            `json:"targetType,omitempty"`
+	TargetID       string            `json:"targetId,omitempty"`
+	IP             string            `json:"ip,omitempty"`
+	UserAgent      string            `json:"userAgent,omitempty"`
+	RequestID      string            `json:"requestId,omitempty"`
+	Result         Result            `json:"result"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+type ctxKeyRequestInfo int
+
+const requestInfoKey ctxKeyRequestInfo = 1
+
+// requestInfo the details of the HTTP request an event is recorded in
+type requestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// CaptureRequest is a middleware keeping the client IP and user agent for the events recorded while
+// handling the request, it must run after middleware.RealIP
+func CaptureRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			// RealIP sets the address without a port
+			ip = r.RemoteAddr
+		}
+
+		ctx := context.WithValue(r.Context(), requestInfoKey, requestInfo{IP: ip, UserAgent: r.UserAgent()})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestInfoFromContext(ctx context.Context) requestInfo {
+	info, _ := ctx.Value(requestInfoKey).(requestInfo)
+
+	return info
+}
@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Reasons of a broken link
+const (
+	reasonPrevHashMismatch   = "previous hash does not match the previous event"
+	reasonHashMismatch       = "hash does not match the event"
+	reasonUnchained          = "event has no hash after the start of the chain"
+	reasonCheckpointInvalid  = "checkpoint signature is invalid"
+	reasonCheckpointMismatch = "checkpoint does not match the event"
+	reasonCheckpointMissing  = "checkpointed event is missing"
+)
+
+// linkContent the content hashed for an event, chained to the hash of the previous one. The JSON of an event
+// is deterministic, the keys of the metadata are sorted.
+func linkContent(prevHash string, event *Event) (string, error) {
+	normalized := *event
+	normalized.OccurredAt = event.OccurredAt.UTC()
+
+	body, err := json.Marshal(&normalized)
+	if err != nil {
+		return "", err
+	}
+
+	return prevHash + "\n" + string(body), nil
+}
+
+func checkpointContent(checkpoint *Checkpoint) string {
+	return "checkpoint\n" + strconv.FormatInt(checkpoint.Sequence, 10) + "\n" + checkpoint.EventHash + "\n" +
+		checkpoint.CreatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+func (m *DefaultManager) hashLink(prevHash string, event *Event) (string, error) {
+	content, err := linkContent(prevHash, event)
+	if err != nil {
+		return "", err
+	}
+
+	return m.hasher.Hash(content)
+}
+
+func (m *DefaultManager) verifyLink(event *StoredEvent) bool {
+	content, err := linkContent(event.PrevHash, &event.Event)
+	if err != nil {
+		return false
+	}
+
+	return m.hasher.Verify(content, event.Hash)
+}
+
+func (m *DefaultManager) verifyCheckpoint(checkpoint *Checkpoint) bool {
+	return m.hasher.Verify(checkpointContent(checkpoint), checkpoint.Signature)
+}
+
+func (m *DefaultManager) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	last, err := m.storage.FindLastEvent(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if last.Hash == "" {
+		// nothing was chained yet
+		return nil, nil
+	}
+
+	latest, err := m.storage.FindLatestCheckpoint(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if latest != nil && latest.Sequence >= last.Sequence {
+		return nil, nil
+	}
+
+	checkpoint := &Checkpoint{
+		Sequence:  last.Sequence,
+		EventHash: last.Hash,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	checkpoint.Signature, err = m.hasher.Hash(checkpointContent(checkpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.storage.InsertCheckpoint(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+func (m *DefaultManager) Verify(ctx context.Context, sinceCheckpoint bool) (*Verification, error) {
+	verification := &Verification{}
+	prevHash := ""
+	chained := false
+
+	if sinceCheckpoint {
+		checkpoint, err := m.storage.FindLatestCheckpoint(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		if checkpoint != nil {
+			if !m.verifyCheckpoint(checkpoint) {
+				verification.Broken = &BrokenLink{Sequence: checkpoint.Sequence, Reason: reasonCheckpointInvalid}
+				return verification, nil
+			}
+
+			// The signed hash stands for every event up to the checkpoint
+			verification.From = checkpoint.Sequence
+			verification.To = checkpoint.Sequence
+			prevHash = checkpoint.EventHash
+			chained = true
+		}
+	}
+
+	after := verification.From
+	for {
+		events, err := m.storage.ListChain(ctx, after, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		checkpoints, err := m.storage.ListCheckpoints(ctx, after, events[len(events)-1].Sequence)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			for len(checkpoints) > 0 && checkpoints[0].Sequence < event.Sequence {
+				// the event it signed is gone
+				verification.Broken = &BrokenLink{Sequence: checkpoints[0].Sequence, Reason: reasonCheckpointMissing}
+				return verification, nil
+			}
+
+			if event.Hash == "" && !chained {
+				verification.Unchained++
+				after = event.Sequence
+				continue
+			}
+			chained = true
+
+			if broken := m.checkLink(event, prevHash); broken != "" {
+				verification.Broken = &BrokenLink{Sequence: event.Sequence, EventID: event.ID, Reason: broken}
+				return verification, nil
+			}
+
+			if len(checkpoints) > 0 && checkpoints[0].Sequence == event.Sequence {
+				if broken := m.checkCheckpoint(checkpoints[0], event); broken != "" {
+					verification.Broken = &BrokenLink{Sequence: event.Sequence, EventID: event.ID, Reason: broken}
+					return verification, nil
+				}
+				checkpoints = checkpoints[1:]
+			}
+
+			prevHash = event.Hash
+			after = event.Sequence
+			verification.To = event.Sequence
+			verification.Verified++
+		}
+
+		if len(events) < verifyBatchSize {
+			break
+		}
+	}
+
+	// A checkpoint after the last event means the tail of the trail was removed
+	checkpoints, err := m.storage.ListCheckpoints(ctx, after, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) > 0 {
+		verification.Broken = &BrokenLink{Sequence: checkpoints[0].Sequence, Reason: reasonCheckpointMissing}
+	}
+
+	return verification, nil
+}
+
+// checkLink returns why the event does not extend the chain, or an empty string
+func (m *DefaultManager) checkLink(event *StoredEvent, prevHash string) string {
+	switch {
+	case event.Hash == "":
+		return reasonUnchained
+	case event.PrevHash != prevHash:
+		return reasonPrevHashMismatch
+	case !m.verifyLink(event):
+		return reasonHashMismatch
+	default:
+		return ""
+	}
+}
+
+// checkCheckpoint returns why the checkpoint does not sign the event, or an empty string
+func (m *DefaultManager) checkCheckpoint(checkpoint *Checkpoint, event *StoredEvent) string {
+	switch {
+	case !m.verifyCheckpoint(checkpoint):
+		return reasonCheckpointInvalid
+	case checkpoint.EventHash != event.Hash:
+		return reasonCheckpointMismatch
+	default:
+		return ""
+	}
+}
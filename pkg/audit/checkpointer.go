@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const (
+	defaultCheckpointInterval = time.Hour
+	checkpointTimeout         = 30 * time.Second
+)
+
+// Checkpointer signs the head of the chain periodically, every instance may run one
+type Checkpointer struct {
+	manager  Manager
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewCheckpointer(manager Manager, cfg config.AuditConfigurations) *Checkpointer {
+	interval := cfg.CheckpointInterval
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+
+	return &Checkpointer{
+		manager:  manager,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start launches the checkpoint loop
+func (c *Checkpointer) Start() {
+	log.Info().Msgf("starting audit checkpoints every %s", c.interval)
+
+	go c.loop()
+}
+
+// Shutdown stops the loop after the checkpoint in flight
+func (c *Checkpointer) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Checkpointer) loop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+		checkpoint, err := c.manager.Checkpoint(ctx)
+		cancel()
+
+		if err != nil {
+			log.Error().Err(err).Msg("failed to checkpoint the audit trail")
+			continue
+		}
+		if checkpoint != nil {
+			log.Info().Int64("sequence", checkpoint.Sequence).Msg("audit trail checkpointed")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/toolbox"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+type DefaultManager struct {
+	storage Storage
+	hasher  hasher.Hasher
+}
+
+func (m *DefaultManager) Record(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	// The time is hashed as stored, the column keeps microseconds
+	event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+	if event.RequestID == "" {
+		event.RequestID = toolbox.GetRequestID(ctx)
+	}
+
+	info := requestInfoFromContext(ctx)
+	if event.IP == "" {
+		event.IP = info.IP
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = info.UserAgent
+	}
+	if len(event.UserAgent) > maxUserAgentLength {
+		event.UserAgent = strings.ToValidUTF8(event.UserAgent[:maxUserAgentLength], "")
+	}
+
+	// The audited action is done, the trail is written even when the request was canceled in the meantime
+	err := m.storage.Insert(context.WithoutCancel(ctx), &event, func(prevHash string) (string, error) {
+		return m.hashLink(prevHash, &event)
+	})
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msgf("failed to record audit event: %s", event.Action)
+	}
+}
+
+func (m *DefaultManager) Search(ctx context.Context, filter Filter, cursor string, limit int) (*Page, error) {
+	before, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	// One more event tells whether there is a next page
+	stored, err := m.storage.Search(ctx, filter, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Events: make([]*Event, 0, min(len(stored), limit))}
+	for i, event := range stored {
+		if i == limit {
+			page.NextCursor = encodeCursor(stored[i-1].Sequence)
+			break
+		}
+		page.Events = append(page.Events, &event.Event)
+	}
+
+	return page, nil
+}
+
+func (m *DefaultManager) Export(ctx context.Context, filter Filter, fn func(event *Event) error) error {
+	var before int64
+
+	for {
+		stored, err := m.storage.Search(ctx, filter, before, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range stored {
+			if err := fn(&event.Event); err != nil {
+				return err
+			}
+		}
+
+		if len(stored) < exportBatchSize {
+			return nil
+		}
+		before = stored[len(stored)-1].Sequence
+	}
+}
+
+// encodeCursor the cursor is opaque to clients, it hides the sequence it holds
+func encodeCursor(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(sequence, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	sequence, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil || sequence <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return sequence, nil
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	// events read at once by an export
+	exportBatchSize = 500
+	// the size of the user agent column
+	maxUserAgentLength = 512
+	// events read at once by a verification of the chain
+	verifyBatchSize = 1000
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Recorder writes the audit trail
+type Recorder interface {
+	// Record stores the event, the request details missing from it are taken from ctx. A failure is logged
+	// and never fails the audited action.
+	Record(ctx context.Context, event Event)
+}
+
+type Manager interface {
+	Recorder
+	// Search returns the events matching the filter, newest first. The cursor of the returned page fetches the
+	// next one, it is empty on the last page.
+	Search(ctx context.Context, filter Filter, cursor string, limit int) (*Page, error)
+	// Export hands every event matching the filter to fn, newest first
+	Export(ctx context.Context, filter Filter, fn func(event *Event) error) error
+	// Checkpoint signs the head of the chain, it returns nil when there is no new event since the last one
+	Checkpoint(ctx context.Context) (*Checkpoint, error)
+	// Verify walks the chain, from the latest checkpoint when sinceCheckpoint is set, up to the first broken link
+	Verify(ctx context.Context, sinceCheckpoint bool) (*Verification, error)
+}
+
+// Filter the criteria of a search, empty fields match everything
+type Filter struct {
+	ActorID        string
+	OrganizationID string
+	Action         string
+	TargetType     string
+	TargetID       string
+	Result         Result
+	From           time.Time
+	To             time.Time
+}
+
+type Page struct {
+	Events     []*Event `json:"events"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// StoredEvent an event with its position in the audit trail and its link in the chain
+type StoredEvent struct {
+	Event
+	Sequence int64
+	PrevHash string
+	Hash     string
+}
+
+// Checkpoint a signed head of the chain, the events up to it do not have to be verified again
+type Checkpoint struct {
+	Sequence  int64     `json:"sequence"`
+	EventHash string    `json:"eventHash"`
+	CreatedAt time.Time `json:"createdAt"`
+	Signature string    `json:"signature"`
+}
+
+// Verification the outcome of a walk of the chain
+type Verification struct {
+	// the sequence the walk started after, the one of the trusted checkpoint or 0
+	From int64 `json:"from"`
+	// the sequence of the last event verified
+	To       int64 `json:"to"`
+	Verified int   `json:"verified"`
+	// events recorded before the chain was introduced
+	Unchained int         `json:"unchained"`
+	Broken    *BrokenLink `json:"broken,omitempty"`
+}
+
+func (v *Verification) OK() bool {
+	return v.Broken == nil
+}
+
+// BrokenLink the first event, or checkpoint, that does not match the chain
+type BrokenLink struct {
+	Sequence int64  `json:"sequence"`
+	EventID  string `json:"eventId,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// LinkFunc returns the hash of an event chained to the hash of the previous one
+type LinkFunc func(prevHash string) (string, error)
+
+type Storage interface {
+	// Insert appends the event to the chain, link is called with the hash of the last event while no other
+	// event can be appended
+	Insert(ctx context.Context, event *Event, link LinkFunc) error
+	// Search returns up to limit events matching the filter, by descending sequence, before the given sequence
+	// when it is positive
+	Search(ctx context.Context, filter Filter, before int64, limit int) ([]*StoredEvent, error)
+	// ListChain returns up to limit events after the given sequence, by ascending sequence
+	ListChain(ctx context.Context, after int64, limit int) ([]*StoredEvent, error)
+	// FindLastEvent returns sql.ErrNoRows when the trail is empty
+	FindLastEvent(ctx context.Context) (*StoredEvent, error)
+	// InsertCheckpoint ignores a checkpoint of a sequence that already has one
+	InsertCheckpoint(ctx context.Context, checkpoint *Checkpoint) error
+	// FindLatestCheckpoint returns sql.ErrNoRows when there is no checkpoint
+	FindLatestCheckpoint(ctx context.Context) (*Checkpoint, error)
+	// ListCheckpoints returns the checkpoints of the sequences after from, up to to when it is positive, by
+	// ascending sequence
+	ListCheckpoints(ctx context.Context, from, to int64) ([]*Checkpoint, error)
+}
+
+// NewManagerWithPgSQLStorage the chain is hashed with hashKeyring, its retired keys must be kept to verify the
+// events hashed with them
+func NewManagerWithPgSQLStorage(db *bun.DB, hashKeyring hasher.Hasher) (Manager, bool) {
+	return &DefaultManager{storage: NewPgSQLStorage(db), hasher: hashKeyring}, true
+}
@@ -0,0 +1,403 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+func storedEvents(sequences ...int64) []*StoredEvent {
+	stored := make([]*StoredEvent, 0, len(sequences))
+	for _, sequence := range sequences {
+		stored = append(stored, &StoredEvent{Event: Event{Action: ActionLogout}, Sequence: sequence})
+	}
+	return stored
+}
+
+func TestRecordFillsRequestDetails(t *testing.T) {
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	var recorded *Event
+	handler := CaptureRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		manager.Record(r.Context(), Event{Action: ActionLogout, ActorID: "1", Result: ResultSuccess})
+	}))
+
+	// Expectations
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, event *Event, link LinkFunc) error {
+			recorded = event
+			return nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/logout", nil)
+	req.RemoteAddr = "203.0.113.7:4711"
+	req.Header.Set("User-Agent", strings.Repeat("a", maxUserAgentLength+10))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "request-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, recorded)
+	assert.NotEmpty(t, recorded.ID)
+	assert.False(t, recorded.OccurredAt.IsZero())
+	assert.Equal(t, "203.0.113.7", recorded.IP)
+	assert.Len(t, recorded.UserAgent, maxUserAgentLength)
+	assert.Equal(t, "request-1", recorded.RequestID)
+	assert.Equal(t, recorded.OccurredAt.Truncate(time.Microsecond), recorded.OccurredAt)
+}
+
+func TestRecordFailureIsNotPropagated(t *testing.T) {
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	// Expectations
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+	assert.NotPanics(t, func() {
+		manager.Record(context.Background(), Event{Action: ActionLogout, Result: ResultSuccess})
+	})
+}
+
+func TestSearchPaginatesWithCursor(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	// Expectations
+	storage.EXPECT().Search(ctx, Filter{}, int64(0), 3).Return(storedEvents(10, 9, 8), nil)
+	storage.EXPECT().Search(ctx, Filter{}, int64(9), 3).Return(storedEvents(8), nil)
+
+	page, err := manager.Search(ctx, Filter{}, "", 2)
+	require.NoError(t, err, "expected no error for the first page")
+	assert.Len(t, page.Events, 2)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = manager.Search(ctx, Filter{}, page.NextCursor, 2)
+	require.NoError(t, err, "expected no error for the last page")
+	assert.Len(t, page.Events, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestSearchCapsLimit(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	// Expectations
+	storage.EXPECT().Search(ctx, Filter{}, int64(0), maxPageSize+1).Return(nil, nil)
+
+	page, err := manager.Search(ctx, Filter{}, "", maxPageSize*10)
+	require.NoError(t, err)
+	assert.Empty(t, page.Events)
+}
+
+func TestSearchInvalidCursor(t *testing.T) {
+	manager := &DefaultManager{storage: NewMockStorage(t)}
+
+	for _, cursor := range []string{"not base64!", encodeCursor(0) + "x", "LTE"} {
+		_, err := manager.Search(context.Background(), Filter{}, cursor, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "expected error for cursor %q", cursor)
+	}
+}
+
+func TestExportReadsInBatches(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	firstBatch := make([]int64, exportBatchSize)
+	for i := range firstBatch {
+		firstBatch[i] = int64(exportBatchSize + 2 - i)
+	}
+
+	// Expectations
+	storage.EXPECT().Search(ctx, Filter{}, int64(0), exportBatchSize).Return(storedEvents(firstBatch...), nil)
+	storage.EXPECT().Search(ctx, Filter{}, int64(3), exportBatchSize).Return(storedEvents(2, 1), nil)
+
+	exported := 0
+	err := manager.Export(ctx, Filter{}, func(event *Event) error {
+		exported++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, exportBatchSize+2, exported)
+}
+
+func TestExportStopsOnError(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMockStorage(t)
+	manager := &DefaultManager{storage: storage}
+
+	// Expectations
+	storage.EXPECT().Search(ctx, Filter{}, int64(0), exportBatchSize).Return(storedEvents(2, 1), nil)
+
+	err := manager.Export(ctx, Filter{}, func(event *Event) error {
+		return errors.New("client went away")
+	})
+	assert.Error(t, err)
+}
+
+func newChainManager(t *testing.T) (*DefaultManager, *MockStorage) {
+	signer, err := hasher.NewHmacSHA256(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	require.NoError(t, err)
+
+	storage := NewMockStorage(t)
+	return &DefaultManager{storage: storage, hasher: signer}, storage
+}
+
+// chainOf links the events the way Record does, with sequences starting at 1
+func chainOf(t *testing.T, manager *DefaultManager, actions ...string) []*StoredEvent {
+	chain := make([]*StoredEvent, 0, len(actions))
+	prevHash := ""
+	for i, action := range actions {
+		event := &StoredEvent{
+			Event: Event{
+				ID:         "event-" + strconv.Itoa(i+1),
+				OccurredAt: time.Date(2026, 10, 19, 12, 0, i, 0, time.UTC),
+				Action:     action,
+				Result:     ResultSuccess,
+				Metadata:   map[string]string{"b": "2", "a": "1"},
+			},
+			Sequence: int64(i + 1),
+			PrevHash: prevHash,
+		}
+
+		hash, err := manager.hashLink(prevHash, &event.Event)
+		require.NoError(t, err)
+		event.Hash = hash
+		prevHash = hash
+
+		chain = append(chain, event)
+	}
+	return chain
+}
+
+func signedCheckpoint(t *testing.T, manager *DefaultManager, event *StoredEvent) *Checkpoint {
+	checkpoint := &Checkpoint{Sequence: event.Sequence, EventHash: event.Hash, CreatedAt: time.Now().UTC()}
+	signature, err := manager.hasher.Hash(checkpointContent(checkpoint))
+	require.NoError(t, err)
+	checkpoint.Signature = signature
+	return checkpoint
+}
+
+func TestRecordChainsToPreviousHash(t *testing.T) {
+	manager, storage := newChainManager(t)
+
+	var recorded *StoredEvent
+
+	// Expectations
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, event *Event, link LinkFunc) error {
+			hash, err := link("previous-hash")
+			recorded = &StoredEvent{Event: *event, PrevHash: "previous-hash", Hash: hash}
+			return err
+		})
+
+	manager.Record(context.Background(), Event{Action: ActionLogout, ActorID: "1", Result: ResultSuccess})
+
+	require.NotNil(t, recorded)
+	assert.True(t, manager.verifyLink(recorded), "expected the hash to cover the event and the previous hash")
+
+	recorded.PrevHash = "another-hash"
+	assert.False(t, manager.verifyLink(recorded), "expected the hash to depend on the previous hash")
+}
+
+func TestVerifyIntactChain(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate, ActionLogout)
+	checkpoint := signedCheckpoint(t, manager, chain[1])
+
+	// Expectations
+	storage.EXPECT().ListChain(ctx, int64(0), verifyBatchSize).Return(chain, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(0), int64(3)).Return([]*Checkpoint{checkpoint}, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(3), int64(0)).Return(nil, nil)
+
+	verification, err := manager.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, verification.OK(), "expected an intact chain, got %+v", verification.Broken)
+	assert.Equal(t, 3, verification.Verified)
+	assert.Equal(t, int64(3), verification.To)
+}
+
+func TestVerifySkipsEventsBeforeTheChain(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP)
+	chain[0].Sequence = 2
+	legacy := &StoredEvent{Event: Event{ID: "legacy", Action: ActionLogout}, Sequence: 1}
+
+	// Expectations
+	storage.EXPECT().ListChain(ctx, int64(0), verifyBatchSize).Return([]*StoredEvent{legacy, chain[0]}, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(0), int64(2)).Return(nil, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(2), int64(0)).Return(nil, nil)
+
+	verification, err := manager.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, verification.OK())
+	assert.Equal(t, 1, verification.Unchained)
+	assert.Equal(t, 1, verification.Verified)
+}
+
+func TestVerifyReportsFirstBrokenLink(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		tamper   func(chain []*StoredEvent) []*StoredEvent
+		sequence int64
+		reason   string
+	}{
+		{
+			name: "edited event",
+			tamper: func(chain []*StoredEvent) []*StoredEvent {
+				chain[1].Result = ResultFailure
+				return chain
+			},
+			sequence: 2,
+			reason:   reasonHashMismatch,
+		},
+		{
+			name: "deleted event",
+			tamper: func(chain []*StoredEvent) []*StoredEvent {
+				return append(chain[:1], chain[2:]...)
+			},
+			sequence: 3,
+			reason:   reasonPrevHashMismatch,
+		},
+		{
+			name: "removed hash",
+			tamper: func(chain []*StoredEvent) []*StoredEvent {
+				chain[2].Hash = ""
+				return chain
+			},
+			sequence: 3,
+			reason:   reasonUnchained,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, storage := newChainManager(t)
+			chain := tt.tamper(chainOf(t, manager, ActionLoginOTP, ActionSessionCreate, ActionLogout, ActionSessionRevoke))
+
+			// Expectations
+			storage.EXPECT().ListChain(ctx, int64(0), verifyBatchSize).Return(chain, nil)
+			storage.EXPECT().ListCheckpoints(ctx, int64(0), chain[len(chain)-1].Sequence).Return(nil, nil)
+
+			verification, err := manager.Verify(ctx, false)
+			require.NoError(t, err)
+			require.False(t, verification.OK(), "expected a broken chain")
+			assert.Equal(t, tt.sequence, verification.Broken.Sequence)
+			assert.Equal(t, tt.reason, verification.Broken.Reason)
+		})
+	}
+}
+
+func TestVerifyDetectsTruncatedTail(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate, ActionLogout)
+	checkpoint := signedCheckpoint(t, manager, chain[2])
+
+	// Expectations
+	storage.EXPECT().ListChain(ctx, int64(0), verifyBatchSize).Return(chain[:2], nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(0), int64(2)).Return(nil, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(2), int64(0)).Return([]*Checkpoint{checkpoint}, nil)
+
+	verification, err := manager.Verify(ctx, false)
+	require.NoError(t, err)
+	require.False(t, verification.OK(), "expected a broken chain")
+	assert.Equal(t, int64(3), verification.Broken.Sequence)
+	assert.Equal(t, reasonCheckpointMissing, verification.Broken.Reason)
+}
+
+func TestVerifySinceCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate, ActionLogout)
+	checkpoint := signedCheckpoint(t, manager, chain[1])
+
+	// Expectations
+	storage.EXPECT().FindLatestCheckpoint(ctx).Return(checkpoint, nil)
+	storage.EXPECT().ListChain(ctx, int64(2), verifyBatchSize).Return(chain[2:], nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(2), int64(3)).Return(nil, nil)
+	storage.EXPECT().ListCheckpoints(ctx, int64(3), int64(0)).Return(nil, nil)
+
+	verification, err := manager.Verify(ctx, true)
+	require.NoError(t, err)
+	assert.True(t, verification.OK(), "expected an intact chain, got %+v", verification.Broken)
+	assert.Equal(t, int64(2), verification.From)
+	assert.Equal(t, 1, verification.Verified)
+}
+
+func TestVerifyForgedCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate)
+	checkpoint := signedCheckpoint(t, manager, chain[1])
+	checkpoint.EventHash = chain[0].Hash
+
+	// Expectations
+	storage.EXPECT().FindLatestCheckpoint(ctx).Return(checkpoint, nil)
+
+	verification, err := manager.Verify(ctx, true)
+	require.NoError(t, err)
+	require.False(t, verification.OK(), "expected a broken chain")
+	assert.Equal(t, reasonCheckpointInvalid, verification.Broken.Reason)
+}
+
+func TestCheckpointSignsNewHead(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate)
+
+	// Expectations
+	storage.EXPECT().FindLastEvent(ctx).Return(chain[1], nil)
+	storage.EXPECT().FindLatestCheckpoint(ctx).Return(nil, sql.ErrNoRows)
+	storage.EXPECT().InsertCheckpoint(ctx, mock.Anything).Return(nil)
+
+	checkpoint, err := manager.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, int64(2), checkpoint.Sequence)
+	assert.Equal(t, chain[1].Hash, checkpoint.EventHash)
+	assert.True(t, manager.verifyCheckpoint(checkpoint))
+}
+
+func TestCheckpointWithoutNewEvents(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := newChainManager(t)
+	chain := chainOf(t, manager, ActionLoginOTP, ActionSessionCreate)
+
+	// Expectations
+	storage.EXPECT().FindLastEvent(ctx).Return(chain[1], nil)
+	storage.EXPECT().FindLatestCheckpoint(ctx).Return(signedCheckpoint(t, manager, chain[1]), nil)
+
+	checkpoint, err := manager.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+}
+
+func TestCheckpointerShutdownTwice(t *testing.T) {
+	manager, _ := newChainManager(t)
+	checkpointer := NewCheckpointer(manager, config.AuditConfigurations{CheckpointInterval: time.Hour})
+
+	checkpointer.Start()
+
+	assert.NoError(t, checkpointer.Shutdown(context.Background()))
+	assert.NoError(t, checkpointer.Shutdown(context.Background()), "expected a second shutdown to be a no-op")
+}
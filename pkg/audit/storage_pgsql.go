@@ -0,0 +1,269 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AuditEventRecord the database model of an audit event, the table is append only
+type AuditEventRecord struct {
+	bun.BaseModel  `bun:"table:audit_events,alias:ae"`
+	Sequence       int64             `bun:"sequence,pk,autoincrement"`
+	ID             string            `bun:"id"`
+	OccurredAt     time.Time         `bun:"occurred_at"`
+	ActorID        string            `bun:"actor_id"`
+	OrganizationID string            `bun:"organization_id"`
+	Action         string            `bun:"action"`
+	TargetType     string            `bun:"target_type"`
+	TargetID       string            `bun:"target_id"`
+	IPAddress      string            `bun:"ip_address"`
+	UserAgent      string            `bun:"user_agent"`
+	RequestID      string            `bun:"request_id"`
+	Result         string            `bun:"result"`
+	Metadata       map[string]string `bun:"metadata,type:jsonb"`
+	PrevHash       string            `bun:"prev_hash"`
+	Hash           string            `bun:"hash"`
+}
+
+type AuditCheckpointRecord struct {
+	bun.BaseModel `bun:"table:audit_checkpoints,alias:ac"`
+	Sequence      int64     `bun:"sequence,pk"`
+	EventHash     string    `bun:"event_hash"`
+	CreatedAt     time.Time `bun:"created_at"`
+	Signature     string    `bun:"signature"`
+}
+
+// chainLockID the advisory lock serializing the appends to the chain
+const chainLockID = 7_310_528_401
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Insert appends an event to the trail, the appends are serialized so every event is chained to the one before
+func (s *PgSQLStorage) Insert(ctx context.Context, event *Event, link LinkFunc) error {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", chainLockID); err != nil {
+			return err
+		}
+
+		var prevHash string
+		err := tx.NewSelect().
+			Model((*AuditEventRecord)(nil)).
+			Column("hash").
+			Order("sequence DESC").
+			Limit(1).
+			Scan(ctx, &prevHash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		hash, err := link(prevHash)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().
+			Model(&AuditEventRecord{
+				ID:             event.ID,
+				OccurredAt:     event.OccurredAt,
+				ActorID:        event.ActorID,
+				OrganizationID: event.OrganizationID,
+				Action:         event.Action,
+				TargetType:     event.TargetType,
+				TargetID:       event.TargetID,
+				IPAddress:      event.IP,
+				UserAgent:      event.UserAgent,
+				RequestID:      event.RequestID,
+				Result:         string(event.Result),
+				Metadata:       metadata,
+				PrevHash:       prevHash,
+				Hash:           hash,
+			}).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+// Search returns the events matching the filter by descending sequence
+func (s *PgSQLStorage) Search(ctx context.Context, filter Filter, before int64, limit int) ([]*StoredEvent, error) {
+	var records []AuditEventRecord
+
+	query := s.db.NewSelect().
+		Model(&records).
+		Order("sequence DESC").
+		Limit(limit)
+
+	if before > 0 {
+		query.Where("sequence < ?", before)
+	}
+	if filter.ActorID != "" {
+		query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.OrganizationID != "" {
+		query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.Action != "" {
+		query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Result != "" {
+		query.Where("result = ?", filter.Result)
+	}
+	if !filter.From.IsZero() {
+		query.Where("occurred_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query.Where("occurred_at < ?", filter.To.UTC())
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return toStoredEvents(records), nil
+}
+
+// ListChain returns the events after the given sequence by ascending sequence
+func (s *PgSQLStorage) ListChain(ctx context.Context, after int64, limit int) ([]*StoredEvent, error) {
+	var records []AuditEventRecord
+
+	err := s.db.NewSelect().
+		Model(&records).
+		Where("sequence > ?", after).
+		Order("sequence ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStoredEvents(records), nil
+}
+
+func (s *PgSQLStorage) FindLastEvent(ctx context.Context) (*StoredEvent, error) {
+	var record AuditEventRecord
+
+	err := s.db.NewSelect().
+		Model(&record).
+		Order("sequence DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStoredEvent(&record), nil
+}
+
+func (s *PgSQLStorage) InsertCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	_, err := s.db.NewInsert().
+		Model(&AuditCheckpointRecord{
+			Sequence:  checkpoint.Sequence,
+			EventHash: checkpoint.EventHash,
+			CreatedAt: checkpoint.CreatedAt,
+			Signature: checkpoint.Signature,
+		}).
+		On("CONFLICT (sequence) DO NOTHING").
+		Exec(ctx)
+
+	return err
+}
+
+func (s *PgSQLStorage) FindLatestCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	var record AuditCheckpointRecord
+
+	err := s.db.NewSelect().
+		Model(&record).
+		Order("sequence DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCheckpoint(&record), nil
+}
+
+func (s *PgSQLStorage) ListCheckpoints(ctx context.Context, from, to int64) ([]*Checkpoint, error) {
+	var records []AuditCheckpointRecord
+
+	query := s.db.NewSelect().
+		Model(&records).
+		Where("sequence > ?", from).
+		Order("sequence ASC")
+
+	if to > 0 {
+		query.Where("sequence <= ?", to)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]*Checkpoint, 0, len(records))
+	for i := range records {
+		checkpoints = append(checkpoints, toCheckpoint(&records[i]))
+	}
+
+	return checkpoints, nil
+}
+
+func toStoredEvents(records []AuditEventRecord) []*StoredEvent {
+	events := make([]*StoredEvent, 0, len(records))
+	for i := range records {
+		events = append(events, toStoredEvent(&records[i]))
+	}
+
+	return events
+}
+
+func toStoredEvent(record *AuditEventRecord) *StoredEvent {
+	return &StoredEvent{
+		Sequence: record.Sequence,
+		PrevHash: record.PrevHash,
+		Hash:     record.Hash,
+		Event: Event{
+			ID:             record.ID,
+			OccurredAt:     record.OccurredAt,
+			ActorID:        record.ActorID,
+			OrganizationID: record.OrganizationID,
+			Action:         record.Action,
+			TargetType:     record.TargetType,
+			TargetID:       record.TargetID,
+			IP:             record.IPAddress,
+			UserAgent:      record.UserAgent,
+			RequestID:      record.RequestID,
+			Result:         Result(record.Result),
+			Metadata:       record.Metadata,
+		},
+	}
+}
+
+func toCheckpoint(record *AuditCheckpointRecord) *Checkpoint {
+	return &Checkpoint{
+		Sequence:  record.Sequence,
+		EventHash: record.EventHash,
+		CreatedAt: record.CreatedAt,
+		Signature: record.Signature,
+	}
+}
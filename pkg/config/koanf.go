@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/toml"
 	"github.com/knadh/koanf/providers/env"
@@ -10,15 +11,139 @@ import (
 )
 
 type Configurations struct {
-	Server   ServerConfigurations   `koanf:"server"`
-	Database DatabaseConfigurations `koanf:"database"`
-	Hasher   HasherConfigurations   `koanf:"hasher"`
-	Auth     AuthConfigurations     `koanf:"auth"`
-	Email    EmailConfigurations    `koanf:"email"`
+	Logger     LoggerConfigurations     `koanf:"logger"`
+	Server     ServerConfigurations     `koanf:"server"`
+	Database   DatabaseConfigurations   `koanf:"database"`
+	Hasher     HasherConfigurations     `koanf:"hasher"`
+	Auth       AuthConfigurations       `koanf:"auth"`
+	Email      EmailConfigurations      `koanf:"email"`
+	WebAuthn   WebAuthnConfigurations   `koanf:"webauthn"`
+	OIDC       OIDCConfigurations       `koanf:"oidc"`
+	OAuth      OAuthConfigurations      `koanf:"oauth"`
+	Password   PasswordConfigurations   `koanf:"password"`
+	Encryption EncryptionConfigurations `koanf:"encryption"`
+	Jobs       JobsConfigurations       `koanf:"jobs"`
+	Events     EventsConfigurations     `koanf:"events"`
+	Webhooks   WebhooksConfigurations   `koanf:"webhooks"`
+	Audit      AuditConfigurations      `koanf:"audit"`
+	Metrics    MetricsConfigurations    `koanf:"metrics"`
+	Tracing    TracingConfigurations    `koanf:"tracing"`
+	Health     HealthConfigurations     `koanf:"health"`
 }
 
+type LoggerConfigurations struct {
+	// trace, debug, info, warn or error
+	Level string `koanf:"level"`
+	// json or console
+	Format string `koanf:"format"`
+	// stdout, stderr or the path of a file the lines are appended to
+	Output   string                       `koanf:"output"`
+	Sampling LoggerSamplingConfigurations `koanf:"sampling"`
+	// fields whose values are redacted on top of the default ones, e.g. password, token or code
+	RedactFields []string `koanf:"redact-fields"`
+}
+
+// LoggerSamplingConfigurations the info, debug and trace lines are sampled, warnings and errors never are. Every
+// period, the first burst lines are written, then one out of every thereafter, none when it is 0.
+type LoggerSamplingConfigurations struct {
+	Enabled    bool          `koanf:"enabled"`
+	Burst      uint32        `koanf:"burst"`
+	Period     time.Duration `koanf:"period"`
+	Thereafter uint32        `koanf:"thereafter"`
+}
+
+// ServerConfigurations a zero timeout or limit disables it
 type ServerConfigurations struct {
-	Port string `koanf:"port"`
+	Port              string        `koanf:"port"`
+	ReadTimeout       time.Duration `koanf:"read-timeout"`
+	ReadHeaderTimeout time.Duration `koanf:"read-header-timeout"`
+	WriteTimeout      time.Duration `koanf:"write-timeout"`
+	IdleTimeout       time.Duration `koanf:"idle-timeout"`
+	MaxHeaderBytes    int           `koanf:"max-header-bytes"`
+	// the context of the requests is canceled after this, the client gets a 504
+	RequestTimeout time.Duration `koanf:"request-timeout"`
+	// larger request bodies are rejected with a 413
+	MaxBodyBytes    int64                         `koanf:"max-body-bytes"`
+	Routes          []RouteConfigurations         `koanf:"routes"`
+	Multipart       MultipartConfigurations       `koanf:"multipart"`
+	AccessLog       AccessLogConfigurations       `koanf:"access-log"`
+	TLS             TLSConfigurations             `koanf:"tls"`
+	CORS            CORSConfigurations            `koanf:"cors"`
+	SecurityHeaders SecurityHeadersConfigurations `koanf:"security-headers"`
+}
+
+// MultipartConfigurations limits of the multipart forms on top of the body limit, a zero value takes the default
+type MultipartConfigurations struct {
+	// the larger parts are written to temporary files, 32 MiB by default
+	MaxMemory int64 `koanf:"max-memory"`
+	// unlimited by default
+	MaxFileBytes int64 `koanf:"max-file-bytes"`
+	// 10 by default
+	MaxFiles int `koanf:"max-files"`
+}
+
+// CORSConfigurations lets browser clients of other origins, e.g. a SPA, call the API
+type CORSConfigurations struct {
+	Enabled bool `koanf:"enabled"`
+	// an origin may have one wildcard for its subdomains, e.g. https://*.example.com
+	AllowedOrigins []string `koanf:"allowed-origins"`
+	AllowedMethods []string `koanf:"allowed-methods"`
+	AllowedHeaders []string `koanf:"allowed-headers"`
+	// response headers readable by the clients
+	ExposedHeaders []string `koanf:"exposed-headers"`
+	// sends the cookies, the origins cannot be the * wildcard then
+	AllowCredentials bool `koanf:"allow-credentials"`
+	// how long the browsers cache the preflight responses
+	MaxAge time.Duration `koanf:"max-age"`
+}
+
+// SecurityHeadersConfigurations an empty value leaves its header out
+type SecurityHeadersConfigurations struct {
+	Enabled bool `koanf:"enabled"`
+	// Strict-Transport-Security, 0 leaves it out
+	HSTSMaxAge            time.Duration `koanf:"hsts-max-age"`
+	HSTSIncludeSubdomains bool          `koanf:"hsts-include-subdomains"`
+	ContentSecurityPolicy string        `koanf:"content-security-policy"`
+	// DENY or SAMEORIGIN
+	FrameOptions   string `koanf:"frame-options"`
+	ReferrerPolicy string `koanf:"referrer-policy"`
+}
+
+// RouteConfigurations overrides the request timeout and body limit of a route, e.g. an upload. The read and write
+// timeouts of its connection are extended to the request timeout.
+type RouteConfigurations struct {
+	// empty for every method
+	Method string `koanf:"method"`
+	// route pattern, as registered in the router, e.g. /v1/identities/{id}/avatar
+	Pattern        string        `koanf:"pattern"`
+	RequestTimeout time.Duration `koanf:"request-timeout"`
+	MaxBodyBytes   int64         `koanf:"max-body-bytes"`
+}
+
+// TLSConfigurations the certificate files are reloaded when they change, e.g. when renewed. HTTP/2 is negotiated
+// over TLS.
+type TLSConfigurations struct {
+	Enabled  bool   `koanf:"enabled"`
+	CertFile string `koanf:"cert-file"`
+	KeyFile  string `koanf:"key-file"`
+	// 1.2 or 1.3
+	MinVersion string `koanf:"min-version"`
+	// bundle of the CAs the client certificates are verified against, mutual TLS is disabled without it
+	ClientCAFile string `koanf:"client-ca-file"`
+	// require or optional, a client without a certificate is rejected or served without a client identity
+	ClientAuth string `koanf:"client-auth"`
+}
+
+// AccessLogConfigurations one line per completed request. Failed (4xx and 5xx) and slow requests are always
+// logged, the other ones are sampled.
+type AccessLogConfigurations struct {
+	Enabled bool `koanf:"enabled"`
+	// share of the other requests logged, from 0 to 1
+	SampleRate float64 `koanf:"sample-rate"`
+	// paths that are never logged, e.g. the health probes
+	SkipPaths []string `koanf:"skip-paths"`
+	// requests slower than this are logged as warnings, 0 disables the warnings
+	SlowThreshold time.Duration `koanf:"slow-threshold"`
 }
 
 type DatabaseConfigurations struct {
@@ -33,11 +158,66 @@ type DatabaseConfigurations struct {
 }
 
 type HasherConfigurations struct {
-	SHASecret string `koanf:"sha-secret"`
+	// Legacy single secret, its hashes carry no key ID. Keep it configured until its rows are re-keyed.
+	SHASecret  string                           `koanf:"sha-secret"`
+	PrimaryKey string                           `koanf:"primary-key"`
+	Keys       map[string]HmacKeyConfigurations `koanf:"keys"`
+	Argon2     Argon2IdConfigurations           `koanf:"argon2"`
+}
+
+// HmacKeyConfigurations a keyring entry, the base64 secret is read from one of the sources
+type HmacKeyConfigurations struct {
+	SecretSource `koanf:",squash"`
+	// Retired keys only verify existing hashes
+	Retired bool `koanf:"retired"`
+}
+
+// EncryptionConfigurations the keys used to encrypt sensitive columns
+type EncryptionConfigurations struct {
+	PrimaryKey string                                 `koanf:"primary-key"`
+	Keys       map[string]EncryptionKeyConfigurations `koanf:"keys"`
+}
+
+// EncryptionKeyConfigurations a key encryption key, the base64 secret of 32 bytes is read from one of the sources
+type EncryptionKeyConfigurations struct {
+	SecretSource `koanf:",squash"`
+	// aes-256-gcm (default) or xchacha20-poly1305
+	Algorithm string `koanf:"algorithm"`
+	// Retired keys only decrypt existing values
+	Retired bool `koanf:"retired"`
+}
+
+// Argon2IdConfigurations the cost of new password hashes, existing hashes are upgraded on login
+type Argon2IdConfigurations struct {
+	Time      uint32 `koanf:"time"`
+	MemoryKiB uint32 `koanf:"memory-kib"`
+	Threads   uint8  `koanf:"threads"`
+	KeyLength uint32 `koanf:"key-length"`
+}
+
+// PasswordConfigurations the policy applied when a password is set
+type PasswordConfigurations struct {
+	MinLength int `koanf:"min-length"`
+	MaxLength int `koanf:"max-length"`
+	// Optional, a file with one breached password per line
+	BreachedListFile string `koanf:"breached-list-file"`
+	// How many previous passwords cannot be reused
+	HistorySize int `koanf:"history-size"`
 }
 
 type AuthConfigurations struct {
-	DevMode bool `koanf:"dev-mode"`
+	DevMode       bool                        `koanf:"dev-mode"`
+	SessionCookie SessionCookieConfigurations `koanf:"session-cookie"`
+}
+
+// SessionCookieConfigurations browser clients get the session token in an HTTP only cookie instead of the response
+// body. The requests authenticated by the cookie are protected against CSRF by a double-submit token.
+type SessionCookieConfigurations struct {
+	Enabled bool `koanf:"enabled"`
+	// empty for the host of the API only
+	Domain string `koanf:"domain"`
+	// lax (default), strict or none
+	SameSite string `koanf:"same-site"`
 }
 
 type EmailConfigurations struct {
@@ -46,6 +226,138 @@ type EmailConfigurations struct {
 	ApiKey    string `koanf:"api-key"`
 	TestEmail string `koanf:"test-email"`
 	FromEmail string `koanf:"from-email"`
+	// api (default) or smtp
+	Provider string `koanf:"provider"`
+	// endpoint of the HTTP API provider, Resend compatible
+	ApiURL string `koanf:"api-url"`
+	// name of the application, shown in the templates
+	AppName string             `koanf:"app-name"`
+	SMTP    SMTPConfigurations `koanf:"smtp"`
+}
+
+type SMTPConfigurations struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+	// starttls (default), implicit or none
+	TLS string `koanf:"tls"`
+}
+
+type JobsConfigurations struct {
+	Workers int `koanf:"workers"`
+	// how often idle workers look for jobs
+	PollInterval time.Duration `koanf:"poll-interval"`
+	// how long a claimed job stays locked, it is claimed again when its worker dies
+	Lease       time.Duration `koanf:"lease"`
+	MaxAttempts int           `koanf:"max-attempts"`
+	BackoffBase time.Duration `koanf:"backoff-base"`
+	BackoffMax  time.Duration `koanf:"backoff-max"`
+}
+
+type EventsConfigurations struct {
+	PollInterval time.Duration `koanf:"poll-interval"`
+	BatchSize    int           `koanf:"batch-size"`
+	// how long a claimed event stays locked, it is delivered again when its relay dies
+	Lease       time.Duration `koanf:"lease"`
+	BackoffBase time.Duration `koanf:"backoff-base"`
+	BackoffMax  time.Duration `koanf:"backoff-max"`
+	// attempts after which an event still failing is moved to the dead letter, unblocking its aggregate
+	MaxAttempts int `koanf:"max-attempts"`
+	// logs every published event
+	LogSink  bool                         `koanf:"log-sink"`
+	Webhooks []EventWebhookConfigurations `koanf:"webhooks"`
+}
+
+type EventWebhookConfigurations struct {
+	URL string `koanf:"url"`
+	// signs the body with HMAC-SHA256 when set
+	Secret string `koanf:"secret"`
+}
+
+// WebhooksConfigurations the delivery of the events to the endpoints registered by the organizations,
+// retries are scheduled with the backoff of the jobs
+type WebhooksConfigurations struct {
+	// how long an endpoint has to answer
+	Timeout     time.Duration `koanf:"timeout"`
+	MaxAttempts int           `koanf:"max-attempts"`
+	// consecutive failed attempts after which an endpoint is disabled
+	DisableAfter int `koanf:"disable-after"`
+	// lets the endpoints target loopback and private addresses, for local development only
+	AllowPrivateDestinations bool `koanf:"allow-private-destinations"`
+}
+
+type AuditConfigurations struct {
+	// how often the head of the hash chain is signed, a verification can start from the latest checkpoint
+	CheckpointInterval time.Duration `koanf:"checkpoint-interval"`
+}
+
+// MetricsConfigurations the metrics are served on their own port, which must not be exposed publicly
+type MetricsConfigurations struct {
+	Enabled bool   `koanf:"enabled"`
+	Port    string `koanf:"port"`
+	Path    string `koanf:"path"`
+}
+
+// TracingConfigurations the spans are exported over OTLP/HTTP. When disabled, spans are not recorded but the W3C
+// trace context of the requests is still propagated.
+type TracingConfigurations struct {
+	Enabled     bool   `koanf:"enabled"`
+	ServiceName string `koanf:"service-name"`
+	// host and port of the OTLP/HTTP collector, e.g. localhost:4318
+	Endpoint string `koanf:"endpoint"`
+	// plain HTTP instead of HTTPS to the collector
+	Insecure bool `koanf:"insecure"`
+	// headers of the export requests, e.g. the API key of the collector
+	Headers map[string]string `koanf:"headers"`
+	// share of the traces started here that are sampled, from 0 to 1. Traces started upstream keep their decision.
+	SampleRatio float64 `koanf:"sample-ratio"`
+}
+
+type HealthConfigurations struct {
+	// how long the readiness fails before the server stops, so the load balancers drain the traffic. It should
+	// exceed the period of their readiness probes.
+	ShutdownDelay time.Duration `koanf:"shutdown-delay"`
+}
+
+type WebAuthnConfigurations struct {
+	RPID    string   `koanf:"rp-id"`
+	RPName  string   `koanf:"rp-name"`
+	Origins []string `koanf:"origins"`
+}
+
+type OIDCConfigurations struct {
+	Providers map[string]OIDCProviderConfigurations `koanf:"providers"`
+}
+
+type OIDCProviderConfigurations struct {
+	Issuer   string `koanf:"issuer"`
+	ClientID string `koanf:"client-id"`
+	// Optional, enables the server-side authorization code flow
+	ClientSecret string   `koanf:"client-secret"`
+	RedirectURL  string   `koanf:"redirect-url"`
+	Scopes       []string `koanf:"scopes"`
+	// Optional, "form_post" has the provider submit the callback as a form instead of a redirect
+	ResponseMode string `koanf:"response-mode"`
+}
+
+// OAuthConfigurations plain OAuth2 providers (without OpenID Connect), e.g. GitHub
+type OAuthConfigurations struct {
+	Providers map[string]OAuthProviderConfigurations `koanf:"providers"`
+}
+
+type OAuthProviderConfigurations struct {
+	ClientID     string   `koanf:"client-id"`
+	ClientSecret string   `koanf:"client-secret"`
+	RedirectURL  string   `koanf:"redirect-url"`
+	Scopes       []string `koanf:"scopes"`
+	AuthorizeURL string   `koanf:"authorize-url"`
+	TokenURL     string   `koanf:"token-url"`
+	UserInfoURL  string   `koanf:"userinfo-url"`
+	// Optional, used when the user info endpoint does not return a verified email
+	EmailsURL string `koanf:"emails-url"`
+	// Optional, "form_post" has the provider submit the callback as a form instead of a redirect
+	ResponseMode string `koanf:"response-mode"`
 }
 
 // LoadConfigurations Loads configurations depending upon the environment
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretSource a secret given inline, by the name of an env variable or by the path of a file
+type SecretSource struct {
+	Secret     string `koanf:"secret"`
+	SecretEnv  string `koanf:"secret-env"`
+	SecretFile string `koanf:"secret-file"`
+}
+
+// Load resolves the secret, the first configured source wins
+func (s SecretSource) Load() (string, error) {
+	switch {
+	case s.Secret != "":
+		return s.Secret, nil
+	case s.SecretEnv != "":
+		secret, ok := os.LookupEnv(s.SecretEnv)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("env variable %s is not set", s.SecretEnv)
+		}
+		return secret, nil
+	case s.SecretFile != "":
+		content, err := os.ReadFile(s.SecretFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	default:
+		return "", errors.New("no secret, secret-env or secret-file")
+	}
+}
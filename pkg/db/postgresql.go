@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -66,11 +68,21 @@ func MustCreatePooledConnection(dbConfig config.DatabaseConfigurations) *Databas
 	)
 
 	db := bun.NewDB(dbPool, pgdialect.New(), bun.WithDiscardUnknownColumns()).
-		WithQueryHook(hook)
+		WithQueryHook(hook).
+		WithQueryHook(NewTracingQueryHook())
 
 	return &DatabaseConnection{Conn: db, pool: pool}
 }
 
+// Ping checks that the database is reachable
+func (c *DatabaseConnection) Ping(ctx context.Context) error {
+	if c.Conn == nil {
+		return errors.New("database is disabled")
+	}
+
+	return c.Conn.PingContext(ctx)
+}
+
 func (c *DatabaseConnection) Close() {
 	if c.pool == nil {
 		return
@@ -78,3 +90,38 @@ func (c *DatabaseConnection) Close() {
 
 	c.pool.Close()
 }
+
+// RegisterMetrics exposes the stats of the connection pool, they are read on every scrape
+func (c *DatabaseConnection) RegisterMetrics(registerer prometheus.Registerer) {
+	if c.pool == nil {
+		return
+	}
+
+	gauge := func(name, help string, fn func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 { return fn(c.pool.Stat()) })
+	}
+	counter := func(name, help string, fn func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 { return fn(c.pool.Stat()) })
+	}
+
+	registerer.MustRegister(
+		gauge("db_pool_total_connections", "Connections of the pool, idle, acquired or being constructed.",
+			func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+		gauge("db_pool_idle_connections", "Idle connections of the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+		gauge("db_pool_acquired_connections", "Connections currently acquired from the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+		gauge("db_pool_constructing_connections", "Connections being established.",
+			func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }),
+		gauge("db_pool_max_connections", "Maximum size of the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+		counter("db_pool_acquires_total", "Connections acquired from the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+		counter("db_pool_empty_acquires_total", "Acquires that waited for a connection because the pool was empty.",
+			func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+		counter("db_pool_canceled_acquires_total", "Acquires canceled by their context.",
+			func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+		counter("db_pool_acquire_duration_seconds_total", "Time spent acquiring connections.",
+			func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+	)
+}
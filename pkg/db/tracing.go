@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/zeusito/toci/pkg/db")
+
+// TracingQueryHook starts a client span for each query. The text of the queries is not recorded since Bun inlines
+// the arguments, which may be personal data.
+type TracingQueryHook struct{}
+
+var _ bun.QueryHook = (*TracingQueryHook)(nil)
+
+func NewTracingQueryHook() *TracingQueryHook {
+	return &TracingQueryHook{}
+}
+
+func (h *TracingQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	// queries outside of a trace, e.g. of the background workers polling, would each start a trace
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	operation := event.Operation()
+	attrs := []attribute.KeyValue{semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(operation)}
+
+	name := operation
+	if event.IQuery != nil {
+		if table := event.IQuery.GetTableName(); table != "" {
+			name += " " + table
+			attrs = append(attrs, semconv.DBCollectionName(table))
+		}
+	}
+
+	ctx, _ = tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithTimestamp(event.StartTime),
+		trace.WithAttributes(attrs...))
+
+	return ctx
+}
+
+func (h *TracingQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	// no rows is an expected outcome, e.g. of a lookup
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
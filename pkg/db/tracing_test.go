@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/tracing/tracingtest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+func runQuery(ctx context.Context, query string, err error) {
+	hook := NewTracingQueryHook()
+	event := &bun.QueryEvent{Query: query, StartTime: time.Now(), Err: err}
+
+	ctx = hook.BeforeQuery(ctx, event)
+	hook.AfterQuery(ctx, event)
+}
+
+func TestTracingQueryHookStartsChildSpans(t *testing.T) {
+	exporter := tracingtest.Exporter(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+
+	runQuery(ctx, "SELECT * FROM identities WHERE email = 'john@example.com'", nil)
+	runQuery(ctx, "UPDATE identities SET status = 'locked'", errors.New("connection reset"))
+	runQuery(ctx, "SELECT * FROM sessions", sql.ErrNoRows)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	selected := spans[0]
+	assert.Equal(t, "SELECT", selected.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), selected.Parent.SpanID())
+	assert.Contains(t, selected.Attributes, semconv.DBSystemNamePostgreSQL)
+	for _, attr := range selected.Attributes {
+		assert.NotContains(t, attr.Value.Emit(), "john@example.com", "expected the query arguments not to be recorded")
+	}
+	assert.Equal(t, codes.Unset, selected.Status.Code)
+
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, codes.Unset, spans[2].Status.Code, "expected no rows not to be an error")
+}
+
+func TestTracingQueryHookIgnoresQueriesOutsideOfTraces(t *testing.T) {
+	exporter := tracingtest.Exporter(t)
+
+	runQuery(context.Background(), "SELECT 1", nil)
+
+	assert.Empty(t, exporter.GetSpans())
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	TypeIdentityCreated Type = "identity.created"
+	TypeIdentityLocked  Type = "identity.locked"
+	TypeSessionCreated  Type = "session.created"
+	TypeSessionRevoked  Type = "session.revoked"
+)
+
+// KnownTypes every type of event emitted, consumers may subscribe to a subset of them
+var KnownTypes = []Type{TypeIdentityCreated, TypeIdentityLocked, TypeSessionCreated, TypeSessionRevoked}
+
+// AggregateIdentity all the events of an identity, its sessions included, are delivered in order
+const AggregateIdentity = "identity"
+
+// Event a state change, delivered at least once to every sink. Consumers deduplicate with the ID.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          Type            `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+type IdentityCreated struct {
+	IdentityID string `json:"identity_id"`
+	Email      string `json:"email"`
+	// how the identity was created, e.g. the external provider
+	Source string `json:"source"`
+}
+
+type IdentityLocked struct {
+	IdentityID     string    `json:"identity_id"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
+type SessionCreated struct {
+	PrincipalID string    `json:"principal_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type SessionRevoked struct {
+	PrincipalID string `json:"principal_id"`
+}
+
+// New builds an event of an aggregate, the payload is JSON encoded
+func New(eventType Type, aggregateType, aggregateID string, payload any) (*Event, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       encoded,
+		OccurredAt:    time.Now().UTC(),
+	}, nil
+}
+
+func NewIdentityCreated(identityID, email, source string) (*Event, error) {
+	return New(TypeIdentityCreated, AggregateIdentity, identityID, &IdentityCreated{
+		IdentityID: identityID,
+		Email:      email,
+		Source:     source,
+	})
+}
+
+func NewIdentityLocked(identityID string, failedAttempts int, lockedUntil time.Time) (*Event, error) {
+	return New(TypeIdentityLocked, AggregateIdentity, identityID, &IdentityLocked{
+		IdentityID:     identityID,
+		FailedAttempts: failedAttempts,
+		LockedUntil:    lockedUntil,
+	})
+}
+
+func NewSessionCreated(principalID string, expiresAt time.Time) (*Event, error) {
+	return New(TypeSessionCreated, AggregateIdentity, principalID, &SessionCreated{
+		PrincipalID: principalID,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+func NewSessionRevoked(principalID string) (*Event, error) {
+	return New(TypeSessionRevoked, AggregateIdentity, principalID, &SessionRevoked{PrincipalID: principalID})
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// statusTimeout bounds the update recording the outcome of a delivery, it must succeed even during a shutdown
+const statusTimeout = 5 * time.Second
+
+// Relay delivers the events of the outbox to every sink. An event is removed once all the sinks
+// received it, only the failing sinks receive it again on the next attempt. An event still failing after
+// the maximum attempts is moved to the dead letter, so it does not block its aggregate forever.
+type Relay struct {
+	storage      Storage
+	sinks        []Sink
+	pollInterval time.Duration
+	batchSize    int
+	lease        time.Duration
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	maxAttempts  int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	// canceled when the shutdown deadline expires, deliveries in flight must give up
+	deliveryCtx    context.Context
+	cancelDelivery context.CancelFunc
+}
+
+func NewRelay(storage Storage, cfg config.EventsConfigurations, sinks ...Sink) *Relay {
+	deliveryCtx, cancelDelivery := context.WithCancel(context.Background())
+
+	return &Relay{
+		storage:        storage,
+		sinks:          sinks,
+		pollInterval:   withDefault(cfg.PollInterval, defaultPollInterval),
+		batchSize:      withDefault(cfg.BatchSize, defaultBatchSize),
+		lease:          withDefault(cfg.Lease, defaultLease),
+		backoffBase:    withDefault(cfg.BackoffBase, defaultBackoffBase),
+		backoffMax:     withDefault(cfg.BackoffMax, defaultBackoffMax),
+		maxAttempts:    withDefault(cfg.MaxAttempts, defaultMaxAttempts),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		deliveryCtx:    deliveryCtx,
+		cancelDelivery: cancelDelivery,
+	}
+}
+
+// AddSink registers a sink, it must be called before Start
+func (r *Relay) AddSink(sink Sink) {
+	r.sinks = append(r.sinks, sink)
+}
+
+// Start launches the relay loop
+func (r *Relay) Start() {
+	names := make([]string, 0, len(r.sinks))
+	for _, sink := range r.sinks {
+		names = append(names, sink.Name())
+	}
+
+	log.Info().Msgf("starting event relay to %v", names)
+
+	go r.loop()
+}
+
+// Shutdown stops the relay after the batch in flight. When ctx expires first, the deliveries are
+// canceled and the events are delivered again once their lease expires.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		r.cancelDelivery()
+		return nil
+	case <-ctx.Done():
+		r.cancelDelivery()
+		<-r.done
+		return ctx.Err()
+	}
+}
+
+func (r *Relay) loop() {
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		claimed, err := r.storage.Claim(r.deliveryCtx, r.batchSize, r.lease)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to claim events")
+		}
+
+		for _, event := range claimed {
+			r.deliver(event)
+		}
+
+		// A full batch means more events are probably waiting
+		if len(claimed) == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+func (r *Relay) deliver(event *ClaimedEvent) {
+	ctx, cancel := context.WithTimeout(r.deliveryCtx, r.lease)
+	delivered, err := r.publish(ctx, event)
+	cancel()
+
+	statusCtx, cancelStatus := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancelStatus()
+
+	switch {
+	case err == nil:
+		err = r.storage.MarkPublished(statusCtx, event.Sequence)
+	case event.Attempts >= r.maxAttempts:
+		log.Error().Err(err).Str("event", event.ID).Str("type", string(event.Type)).Int("attempts", event.Attempts).
+			Msg("failed to deliver event, moved to dead letter")
+		err = r.storage.Bury(statusCtx, event.Sequence, delivered, err.Error())
+	default:
+		delay := r.backoff(event.Attempts)
+		log.Warn().Err(err).Str("event", event.ID).Str("type", string(event.Type)).Int("attempts", event.Attempts).
+			Msgf("failed to deliver event, retrying in %s", delay)
+		err = r.storage.Reschedule(statusCtx, event.Sequence, delivered, time.Now().UTC().Add(delay), err.Error())
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("event", event.ID).Msg("failed to record the event delivery")
+	}
+}
+
+// publish hands the event to every sink that did not receive it yet, a failing sink does not keep the
+// others from receiving it. It returns the sinks that received the event so far.
+func (r *Relay) publish(ctx context.Context, event *ClaimedEvent) ([]string, error) {
+	delivered := append(make([]string, 0, len(r.sinks)), event.DeliveredSinks...)
+	var errs []error
+
+	for _, sink := range r.sinks {
+		if slices.Contains(delivered, sink.Name()) {
+			continue
+		}
+
+		if err := sink.Publish(ctx, &event.Event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+
+		delivered = append(delivered, sink.Name())
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// backoff doubles the delay on every attempt up to the maximum, with up to 25% of jitter
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.backoffMax
+	if attempts < 32 {
+		delay = min(r.backoffBase<<(max(attempts, 1)-1), r.backoffMax)
+	}
+
+	return delay + rand.N(delay/4+1)
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultLease        = time.Minute
+	defaultBackoffBase  = time.Second
+	defaultBackoffMax   = 10 * time.Minute
+	defaultMaxAttempts  = 20
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	// StatusDead events exhausted their attempts, they are kept for inspection and no longer block their aggregate
+	StatusDead Status = "dead"
+)
+
+type Outbox interface {
+	// Append stores the events with the given connection, pass the transaction of the state change so
+	// both are committed or rolled back together
+	Append(ctx context.Context, db bun.IDB, events ...*Event) error
+}
+
+// Sink receives the relayed events, an error makes the relay deliver the event again later
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *Event) error
+}
+
+type Storage interface {
+	Insert(ctx context.Context, db bun.IDB, events []*Event) error
+	// Claim leases up to limit undelivered events, only the oldest one of each aggregate, so an
+	// aggregate's events are never delivered out of order
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*ClaimedEvent, error)
+	// MarkPublished removes an event delivered to every sink
+	MarkPublished(ctx context.Context, sequence int64) error
+	// Reschedule releases an event that failed to be delivered, the sinks that received it are skipped next time
+	Reschedule(ctx context.Context, sequence int64, deliveredSinks []string, nextAttemptAt time.Time, lastError string) error
+	// Bury moves an event that exhausted its attempts to the dead status
+	Bury(ctx context.Context, sequence int64, deliveredSinks []string, lastError string) error
+}
+
+// ClaimedEvent an event leased by the relay, the sequence orders the events of the outbox
+type ClaimedEvent struct {
+	Event
+	Sequence int64
+	Attempts int
+	// the names of the sinks that already received the event in a previous attempt
+	DeliveredSinks []string
+}
+
+func NewOutboxWithPgSQLStorage(db *bun.DB) Outbox {
+	return &DefaultOutbox{storage: NewPgSQLStorage(db)}
+}
+
+func NewRelayWithPgSQLStorage(db *bun.DB, cfg config.EventsConfigurations, sinks ...Sink) *Relay {
+	return NewRelay(NewPgSQLStorage(db), cfg, sinks...)
+}
+
+// NewSinksFromConfig Creates the log and webhook sinks enabled in the configuration
+func NewSinksFromConfig(cfg config.EventsConfigurations) []Sink {
+	var sinks []Sink
+
+	if cfg.LogSink {
+		sinks = append(sinks, &LogSink{})
+	}
+
+	for _, webhook := range cfg.Webhooks {
+		sinks = append(sinks, NewWebhookSink(webhook.URL, webhook.Secret))
+	}
+
+	return sinks
+}
+
+type DefaultOutbox struct {
+	storage Storage
+}
+
+// Append stores the events, nothing is delivered before the transaction commits
+func (o *DefaultOutbox) Append(ctx context.Context, db bun.IDB, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return o.storage.Insert(ctx, db, events)
+}
+
+func withDefault[T int | time.Duration](value, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,255 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+var testConfig = config.EventsConfigurations{
+	PollInterval: 5 * time.Millisecond,
+	BatchSize:    10,
+	BackoffBase:  time.Second,
+	BackoffMax:   time.Minute,
+}
+
+func testEvent(t *testing.T) *ClaimedEvent {
+	event, err := NewSessionCreated("1", time.Now().UTC().Add(time.Hour))
+	require.NoError(t, err)
+
+	return &ClaimedEvent{Event: *event, Sequence: 42, Attempts: 1}
+}
+
+// claimOnce hands out the event on the first claim, then nothing
+func claimOnce(storage *MockStorage, event *ClaimedEvent) {
+	storage.EXPECT().Claim(mock.Anything, testConfig.BatchSize, defaultLease).Return([]*ClaimedEvent{event}, nil).Once()
+	storage.EXPECT().Claim(mock.Anything, testConfig.BatchSize, defaultLease).Return(nil, nil).Maybe()
+}
+
+// runRelay starts the relay and shuts it down once the outcome of the delivery is recorded
+func runRelay(t *testing.T, relay *Relay, recorded chan struct{}) {
+	relay.Start()
+
+	select {
+	case <-recorded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivery outcome was not recorded")
+	}
+
+	require.NoError(t, relay.Shutdown(context.Background()))
+}
+
+func TestAppendWithoutEvents(t *testing.T) {
+	storage := NewMockStorage(t)
+	outbox := &DefaultOutbox{storage: storage}
+
+	assert.NoError(t, outbox.Append(context.Background(), nil))
+}
+
+func TestAppend(t *testing.T) {
+	storage := NewMockStorage(t)
+	outbox := &DefaultOutbox{storage: storage}
+	event, err := NewIdentityCreated("1", "john@example.com", "web")
+	require.NoError(t, err)
+
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, []*Event{event}).
+		RunAndReturn(func(ctx context.Context, db bun.IDB, events []*Event) error {
+			assert.Equal(t, TypeIdentityCreated, events[0].Type)
+			assert.Equal(t, AggregateIdentity, events[0].AggregateType)
+			assert.Equal(t, "1", events[0].AggregateID)
+			assert.JSONEq(t, `{"identity_id":"1","email":"john@example.com","source":"web"}`, string(events[0].Payload))
+			return nil
+		})
+
+	assert.NoError(t, outbox.Append(context.Background(), nil, event))
+}
+
+func TestRelayDeliversToEverySink(t *testing.T) {
+	storage := NewMockStorage(t)
+	event := testEvent(t)
+	recorded := make(chan struct{})
+
+	first := NewMockSink(t)
+	first.EXPECT().Name().Return("first").Maybe()
+	first.EXPECT().Publish(mock.Anything, &event.Event).Return(nil).Once()
+	second := NewMockSink(t)
+	second.EXPECT().Name().Return("second").Maybe()
+	second.EXPECT().Publish(mock.Anything, &event.Event).Return(nil).Once()
+
+	claimOnce(storage, event)
+	storage.EXPECT().MarkPublished(mock.Anything, int64(42)).
+		RunAndReturn(func(ctx context.Context, sequence int64) error {
+			close(recorded)
+			return nil
+		})
+
+	runRelay(t, NewRelay(storage, testConfig, first, second), recorded)
+}
+
+func TestRelayReschedulesFailedDelivery(t *testing.T) {
+	storage := NewMockStorage(t)
+	event := testEvent(t)
+	event.Attempts = 3
+	recorded := make(chan struct{})
+
+	sink := NewMockSink(t)
+	sink.EXPECT().Name().Return("failing")
+	sink.EXPECT().Publish(mock.Anything, &event.Event).Return(errors.New("unavailable")).Once()
+
+	claimOnce(storage, event)
+	storage.EXPECT().Reschedule(mock.Anything, int64(42), []string{}, mock.Anything, "failing: unavailable").
+		RunAndReturn(func(ctx context.Context, sequence int64, deliveredSinks []string, nextAttemptAt time.Time, lastError string) error {
+			// Third attempt, 4s of backoff plus up to 25% of jitter
+			delay := time.Until(nextAttemptAt)
+			assert.Greater(t, delay, 3*time.Second)
+			assert.LessOrEqual(t, delay, 5*time.Second)
+			close(recorded)
+			return nil
+		})
+
+	runRelay(t, NewRelay(storage, testConfig, sink), recorded)
+}
+
+func TestRelayOnlyRetriesFailedSinks(t *testing.T) {
+	storage := NewMockStorage(t)
+	event := testEvent(t)
+	event.DeliveredSinks = []string{"delivered"}
+	recorded := make(chan struct{})
+
+	// received the event in a previous attempt, it is not published again
+	delivered := NewMockSink(t)
+	delivered.EXPECT().Name().Return("delivered")
+	failing := NewMockSink(t)
+	failing.EXPECT().Name().Return("failing")
+	failing.EXPECT().Publish(mock.Anything, &event.Event).Return(errors.New("unavailable")).Once()
+	// still receives the event although the sink before it failed
+	healthy := NewMockSink(t)
+	healthy.EXPECT().Name().Return("healthy")
+	healthy.EXPECT().Publish(mock.Anything, &event.Event).Return(nil).Once()
+
+	claimOnce(storage, event)
+	storage.EXPECT().Reschedule(mock.Anything, int64(42), []string{"delivered", "healthy"}, mock.Anything, "failing: unavailable").
+		RunAndReturn(func(ctx context.Context, sequence int64, deliveredSinks []string, nextAttemptAt time.Time, lastError string) error {
+			close(recorded)
+			return nil
+		})
+
+	runRelay(t, NewRelay(storage, testConfig, delivered, failing, healthy), recorded)
+}
+
+func TestRelayMovesEventsToDeadLetter(t *testing.T) {
+	storage := NewMockStorage(t)
+	event := testEvent(t)
+	event.Attempts = 3
+	recorded := make(chan struct{})
+	cfg := testConfig
+	cfg.MaxAttempts = 3
+
+	sink := NewMockSink(t)
+	sink.EXPECT().Name().Return("failing")
+	sink.EXPECT().Publish(mock.Anything, &event.Event).Return(errors.New("invalid payload")).Once()
+
+	claimOnce(storage, event)
+	storage.EXPECT().Bury(mock.Anything, int64(42), []string{}, "failing: invalid payload").
+		RunAndReturn(func(ctx context.Context, sequence int64, deliveredSinks []string, lastError string) error {
+			close(recorded)
+			return nil
+		})
+
+	runRelay(t, NewRelay(storage, cfg, sink), recorded)
+}
+
+func TestRelayBackoffIsCapped(t *testing.T) {
+	relay := NewRelay(NewMockStorage(t), testConfig)
+
+	delay := relay.backoff(100)
+
+	assert.GreaterOrEqual(t, delay, time.Minute)
+	assert.LessOrEqual(t, delay, time.Minute+15*time.Second)
+}
+
+func TestRelayShutdownWithoutEvents(t *testing.T) {
+	storage := NewMockStorage(t)
+	storage.EXPECT().Claim(mock.Anything, testConfig.BatchSize, defaultLease).Return(nil, nil).Maybe()
+	relay := NewRelay(storage, testConfig)
+
+	relay.Start()
+
+	assert.NoError(t, relay.Shutdown(context.Background()))
+	assert.NoError(t, relay.Shutdown(context.Background()), "expected a second shutdown to be a no-op")
+}
+
+func TestBusDeliversToSubscribersOfTheType(t *testing.T) {
+	bus := NewBus()
+	event := testEvent(t)
+	var received []string
+
+	bus.Subscribe(TypeSessionCreated, func(ctx context.Context, event *Event) error {
+		received = append(received, "created")
+		return nil
+	})
+	bus.Subscribe(TypeSessionCreated, func(ctx context.Context, event *Event) error {
+		received = append(received, "failing")
+		return errors.New("boom")
+	})
+	bus.Subscribe(TypeSessionRevoked, func(ctx context.Context, event *Event) error {
+		received = append(received, "revoked")
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), &event.Event)
+
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, []string{"created", "failing"}, received)
+}
+
+func TestWebhookSinkSignsTheBody(t *testing.T) {
+	event := testEvent(t)
+	secret := "webhook-secret"
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+		assert.Equal(t, event.ID, r.Header.Get("X-Event-ID"))
+		assert.Equal(t, string(TypeSessionCreated), r.Header.Get("X-Event-Type"))
+
+		var received Event
+		assert.NoError(t, json.Unmarshal(body, &received))
+		assert.Equal(t, event.ID, received.ID)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sink := NewWebhookSink(receiver.URL, secret)
+
+	assert.NoError(t, sink.Publish(context.Background(), &event.Event))
+}
+
+func TestWebhookSinkFailsOnErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	sink := NewWebhookSink(receiver.URL, "")
+
+	assert.ErrorContains(t, sink.Publish(context.Background(), &testEvent(t).Event), "503")
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/tracing"
+)
+
+// LogSink logs the events, useful in development and as an audit trail of the relay
+type LogSink struct{}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, event *Event) error {
+	log.Info().
+		Str("event", event.ID).
+		Str("type", string(event.Type)).
+		Str("aggregate", event.AggregateType+"/"+event.AggregateID).
+		Msg("event published")
+
+	return nil
+}
+
+// WebhookSink posts the events as JSON. With a secret, the body is signed with HMAC-SHA256 in the
+// X-Signature header, as sha256=<hex>.
+type WebhookSink struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+}
+
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:        url,
+		secret:     []byte(secret),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook " + s.url
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", string(event.Type))
+
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Subscriber handles an event in process, it must be idempotent as events may be delivered more than once
+type Subscriber func(ctx context.Context, event *Event) error
+
+// Bus delivers the events to the in-process subscribers of their type
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[Type][]Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: map[Type][]Subscriber{}}
+}
+
+// Subscribe registers a subscriber for an event type
+func (b *Bus) Subscribe(eventType Type, subscriber Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[eventType] = append(b.subscribers[eventType], subscriber)
+}
+
+func (b *Bus) Name() string {
+	return "in-process"
+}
+
+// Publish calls every subscriber of the event type, the event is delivered again if any of them fails
+func (b *Bus) Publish(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	subscribers := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// OutboxEventRecord the database model of an event waiting to be delivered
+type OutboxEventRecord struct {
+	bun.BaseModel `bun:"table:outbox_events,alias:oe"`
+	Sequence      int64           `bun:"sequence,pk,autoincrement"`
+	ID            string          `bun:"id"`
+	Type          Type            `bun:"type"`
+	AggregateType string          `bun:"aggregate_type"`
+	AggregateID   string          `bun:"aggregate_id"`
+	Payload       json.RawMessage `bun:"payload,type:jsonb"`
+	OccurredAt    time.Time       `bun:"occurred_at"`
+	Status        Status          `bun:"status"`
+	Attempts      int             `bun:"attempts"`
+	// the sinks that received the event, they are skipped by the next attempts
+	DeliveredSinks []string   `bun:"delivered_sinks,array"`
+	LastError      string     `bun:"last_error"`
+	NextAttemptAt  time.Time  `bun:"next_attempt_at"`
+	LockedUntil    *time.Time `bun:"locked_until"`
+}
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Insert stores the events with the given connection or transaction
+func (s *PgSQLStorage) Insert(ctx context.Context, db bun.IDB, events []*Event) error {
+	records := make([]OutboxEventRecord, 0, len(events))
+
+	for _, event := range events {
+		records = append(records, OutboxEventRecord{
+			ID:            event.ID,
+			Type:          event.Type,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			Payload:       event.Payload,
+			OccurredAt:    event.OccurredAt,
+			Status:        StatusPending,
+			NextAttemptAt: event.OccurredAt,
+		})
+	}
+
+	_, err := db.NewInsert().Model(&records).Exec(ctx)
+
+	return err
+}
+
+// Claim leases the head of each aggregate whose next attempt is due. Delivered events are deleted and dead
+// ones are skipped, so any older pending row of the aggregate, leased or waiting for a retry, blocks the
+// event. SKIP LOCKED keeps concurrent relays apart.
+func (s *PgSQLStorage) Claim(ctx context.Context, limit int, lease time.Duration) ([]*ClaimedEvent, error) {
+	now := time.Now().UTC()
+	var records []OutboxEventRecord
+
+	heads := s.db.NewSelect().
+		Model((*OutboxEventRecord)(nil)).
+		Column("sequence").
+		Where("status = ?", StatusPending).
+		Where("next_attempt_at <= ?", now).
+		Where("(locked_until IS NULL OR locked_until < ?)", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events AS previous
+			WHERE previous.aggregate_type = oe.aggregate_type AND previous.aggregate_id = oe.aggregate_id
+			AND previous.sequence < oe.sequence AND previous.status = ?)`, StatusPending).
+		Order("sequence ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	_, err := s.db.NewUpdate().
+		Model((*OutboxEventRecord)(nil)).
+		Set("locked_until = ?", now.Add(lease)).
+		Set("attempts = attempts + 1").
+		Where("sequence IN (?)", heads).
+		Returning("*").
+		Exec(ctx, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]*ClaimedEvent, 0, len(records))
+	for _, record := range records {
+		claimed = append(claimed, &ClaimedEvent{
+			Event: Event{
+				ID:            record.ID,
+				Type:          record.Type,
+				AggregateType: record.AggregateType,
+				AggregateID:   record.AggregateID,
+				Payload:       record.Payload,
+				OccurredAt:    record.OccurredAt,
+			},
+			Sequence:       record.Sequence,
+			Attempts:       record.Attempts,
+			DeliveredSinks: record.DeliveredSinks,
+		})
+	}
+
+	return claimed, nil
+}
+
+// MarkPublished removes a delivered event, it then stops blocking its aggregate
+func (s *PgSQLStorage) MarkPublished(ctx context.Context, sequence int64) error {
+	_, err := s.db.NewDelete().
+		Model((*OutboxEventRecord)(nil)).
+		Where("sequence = ?", sequence).
+		Exec(ctx)
+
+	return err
+}
+
+// Reschedule releases an event to be delivered again at the given time
+func (s *PgSQLStorage) Reschedule(ctx context.Context, sequence int64, deliveredSinks []string, nextAttemptAt time.Time,
+	lastError string) error {
+	_, err := s.db.NewUpdate().
+		Model((*OutboxEventRecord)(nil)).
+		Set("next_attempt_at = ?", nextAttemptAt).
+		Set("delivered_sinks = ?", pgdialect.Array(deliveredSinks)).
+		Set("locked_until = NULL").
+		Set("last_error = ?", lastError).
+		Where("sequence = ?", sequence).
+		Exec(ctx)
+
+	return err
+}
+
+// Bury moves an event to the dead status, the next events of its aggregate are then delivered
+func (s *PgSQLStorage) Bury(ctx context.Context, sequence int64, deliveredSinks []string, lastError string) error {
+	_, err := s.db.NewUpdate().
+		Model((*OutboxEventRecord)(nil)).
+		Set("status = ?", StatusDead).
+		Set("delivered_sinks = ?", pgdialect.Array(deliveredSinks)).
+		Set("locked_until = NULL").
+		Set("last_error = ?", lastError).
+		Where("sequence = ?", sequence).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,160 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds a check without a timeout
+const DefaultTimeout = time.Second
+
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// StatusDegraded a non-critical check failed, the instance still serves requests
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// CheckFunc probes a dependency, it returns an error when the dependency is unavailable
+type CheckFunc func(ctx context.Context) error
+
+// Check a named probe of a dependency. The instance is not ready when a critical check fails.
+type Check struct {
+	Name     string
+	Check    CheckFunc
+	Timeout  time.Duration
+	Critical bool
+}
+
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status       Status        `json:"status"`
+	ShuttingDown bool          `json:"shuttingDown,omitempty"`
+	Checks       []CheckResult `json:"checks"`
+}
+
+// Ready tells whether the instance may receive traffic
+func (r *Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Registry the checks of the subsystems, aggregated into the readiness of the instance
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check, it panics on a duplicated name since it is a programming error
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.checks {
+		if existing.Name == check.Name {
+			panic(fmt.Sprintf("health: check %s is already registered", check.Name))
+		}
+	}
+
+	r.checks = append(r.checks, check)
+}
+
+// BeginShutdown makes the instance not ready from now on, the checks are no longer run
+func (r *Registry) BeginShutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs the checks concurrently, each within its timeout, and aggregates their results in the order they
+// were registered
+func (r *Registry) Check(ctx context.Context) *Report {
+	if r.shuttingDown.Load() {
+		return &Report{Status: StatusDown, ShuttingDown: true, Checks: []CheckResult{}}
+	}
+
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// run returns once the timeout expires even when the check ignores its context
+func run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", check.Timeout)
+		}
+	}
+
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    StatusUp,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistryAggregatesChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []Check
+		expected Status
+	}{
+		{"all checks pass", []Check{{Name: "postgres", Check: up, Critical: true}, {Name: "email", Check: up}}, StatusUp},
+		{"a non-critical check fails", []Check{{Name: "postgres", Check: up, Critical: true}, {Name: "email", Check: down}}, StatusDegraded},
+		{"a critical check fails", []Check{{Name: "postgres", Check: down, Critical: true}, {Name: "email", Check: down}}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, check := range tt.checks {
+				registry.Register(check)
+			}
+
+			report := registry.Check(context.Background())
+
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Equal(t, tt.expected != StatusDown, report.Ready())
+			require.Len(t, report.Checks, len(tt.checks))
+			for i, check := range tt.checks {
+				assert.Equal(t, check.Name, report.Checks[i].Name, "expected the results in the order of registration")
+				assert.Equal(t, check.Critical, report.Checks[i].Critical)
+			}
+		})
+	}
+}
+
+func TestRegistryReportsFailures(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{Name: "postgres", Check: down, Critical: true})
+
+	report := registry.Check(context.Background())
+
+	assert.Equal(t, StatusDown, report.Checks[0].Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+}
+
+func TestRegistryTimesOutChecksIgnoringTheirContext(t *testing.T) {
+	registry := NewRegistry()
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	registry.Register(Check{Name: "stuck", Timeout: 20 * time.Millisecond, Critical: true, Check: func(context.Context) error {
+		<-release
+		return nil
+	}})
+
+	start := time.Now()
+	report := registry.Check(context.Background())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "timed out after 20ms", report.Checks[0].Error)
+}
+
+func TestRegistryFailsOnceShutdownBegins(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{Name: "postgres", Check: up, Critical: true})
+
+	registry.BeginShutdown()
+	report := registry.Check(context.Background())
+
+	assert.False(t, report.Ready())
+	assert.True(t, report.ShuttingDown)
+	assert.Empty(t, report.Checks)
+}
+
+func TestRegistryRejectsDuplicatedNames(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{Name: "postgres", Check: up})
+
+	assert.Panics(t, func() { registry.Register(Check{Name: "postgres", Check: up}) })
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/uptrace/bun"
+)
+
+type DefaultQueue struct {
+	db          bun.IDB
+	storage     Storage
+	maxAttempts int
+}
+
+// Enqueue stores a job outside any transaction
+func (q *DefaultQueue) Enqueue(ctx context.Context, kind string, payload any, opts ...Option) error {
+	return q.EnqueueTx(ctx, q.db, kind, payload, opts...)
+}
+
+// EnqueueTx stores a job with the given connection or transaction
+func (q *DefaultQueue) EnqueueTx(ctx context.Context, tx bun.IDB, kind string, payload any, opts ...Option) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		Kind:        kind,
+		Payload:     encoded,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	return q.storage.Insert(ctx, tx, job)
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// statusTimeout bounds the update recording the outcome of a job, it must succeed even during a shutdown
+const statusTimeout = 5 * time.Second
+
+// Pool runs the registered handlers on a fixed number of workers polling the storage
+type Pool struct {
+	storage      Storage
+	handlers     map[string]Handler
+	kinds        []string
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	// the last claim of a worker failed, e.g. the database is unreachable
+	claimFailing atomic.Bool
+	// canceled when the shutdown deadline expires, handlers still running must give up
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+}
+
+func NewPool(storage Storage, cfg config.JobsConfigurations) *Pool {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
+	return &Pool{
+		storage:      storage,
+		handlers:     map[string]Handler{},
+		workers:      withDefault(cfg.Workers, defaultWorkers),
+		pollInterval: withDefault(cfg.PollInterval, defaultPollInterval),
+		lease:        withDefault(cfg.Lease, defaultLease),
+		backoffBase:  withDefault(cfg.BackoffBase, defaultBackoffBase),
+		backoffMax:   withDefault(cfg.BackoffMax, defaultBackoffMax),
+		stop:         make(chan struct{}),
+		jobsCtx:      jobsCtx,
+		cancelJobs:   cancelJobs,
+	}
+}
+
+// Handle registers the handler of a kind, it must be called before Start
+func (p *Pool) Handle(kind string, handler Handler) {
+	if _, ok := p.handlers[kind]; !ok {
+		p.kinds = append(p.kinds, kind)
+	}
+
+	p.handlers[kind] = handler
+}
+
+// Start launches the workers, only jobs of registered kinds are claimed
+func (p *Pool) Start() {
+	if len(p.kinds) == 0 {
+		log.Warn().Msg("no job handler is registered, workers are not started")
+		return
+	}
+
+	log.Info().Msgf("starting %d job workers for %v", p.workers, p.kinds)
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+// Shutdown stops claiming jobs and waits for the running ones. When ctx expires first, the handlers
+// are canceled and their jobs are claimed again by another process once the lease expires.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Ping tells whether the workers are running and able to claim jobs
+func (p *Pool) Ping(_ context.Context) error {
+	select {
+	case <-p.stop:
+		return errors.New("job workers are stopped")
+	default:
+	}
+
+	if p.claimFailing.Load() {
+		return errors.New("job workers failed to claim jobs")
+	}
+
+	return nil
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		jobs, err := p.storage.Claim(p.jobsCtx, p.kinds, 1, p.lease)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to claim jobs")
+		}
+		p.claimFailing.Store(err != nil)
+
+		if len(jobs) == 0 {
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(p.pollInterval):
+			}
+			continue
+		}
+
+		for _, job := range jobs {
+			p.run(job)
+		}
+	}
+}
+
+func (p *Pool) run(job *Job) {
+	ctx, cancel := context.WithTimeout(p.jobsCtx, p.lease)
+	err := p.execute(ctx, job)
+	cancel()
+
+	statusCtx, cancelStatus := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancelStatus()
+
+	switch {
+	case err == nil:
+		err = p.storage.Complete(statusCtx, job.ID, job.Attempts)
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		log.Error().Err(err).Int64("job", job.ID).Str("kind", job.Kind).Int("attempts", job.Attempts).
+			Msg("job failed, moved to dead letter")
+		err = p.storage.Bury(statusCtx, job.ID, job.Attempts, err.Error())
+	default:
+		delay := p.backoff(job.Attempts)
+		log.Warn().Err(err).Int64("job", job.ID).Str("kind", job.Kind).Int("attempts", job.Attempts).
+			Msgf("job failed, retrying in %s", delay)
+		err = p.storage.Retry(statusCtx, job.ID, job.Attempts, time.Now().UTC().Add(delay), err.Error())
+	}
+
+	switch {
+	case errors.Is(err, ErrLeaseLost):
+		log.Warn().Int64("job", job.ID).Str("kind", job.Kind).Msg("job lease expired before it finished, it was claimed again")
+	case err != nil:
+		log.Error().Err(err).Int64("job", job.ID).Msg("failed to record the job outcome")
+	}
+}
+
+// execute runs the handler, a panic fails the attempt instead of the worker
+func (p *Pool) execute(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return p.handlers[job.Kind](ctx, job)
+}
+
+// backoff doubles the delay on every attempt up to the maximum, with up to 25% of jitter so failing
+// jobs enqueued together do not retry together
+func (p *Pool) backoff(attempts int) time.Duration {
+	delay := p.backoffMax
+	if attempts < 32 {
+		delay = min(p.backoffBase<<(attempts-1), p.backoffMax)
+	}
+
+	return delay + rand.N(delay/4+1)
+}
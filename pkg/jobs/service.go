@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+type Status string
+
+// ErrLeaseLost the lease of the job expired and it was claimed again, its outcome is recorded by the new claim
+var ErrLeaseLost = errors.New("the lease of the job was lost")
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	// StatusDead jobs exhausted their attempts or failed permanently, they are kept for inspection
+	StatusDead Status = "dead"
+)
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	defaultLease        = 5 * time.Minute
+	defaultMaxAttempts  = 5
+	defaultBackoffBase  = 5 * time.Second
+	defaultBackoffMax   = time.Hour
+)
+
+// Job a unit of work, the payload is the JSON encoded argument of its handler
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	CreatedAt   time.Time
+	// the payload holds a secret, e.g. a one time password, it is scrubbed when the job dies
+	Sensitive bool
+}
+
+// Handler runs a job. A returned error schedules a retry, unless it is Permanent or the attempts are exhausted.
+type Handler func(ctx context.Context, job *Job) error
+
+type Queue interface {
+	// Enqueue stores a job, it runs as soon as a worker is free unless scheduled later with an option
+	Enqueue(ctx context.Context, kind string, payload any, opts ...Option) error
+	// EnqueueTx stores a job within the caller's transaction, it only becomes visible on commit
+	EnqueueTx(ctx context.Context, tx bun.IDB, kind string, payload any, opts ...Option) error
+}
+
+type Storage interface {
+	Insert(ctx context.Context, db bun.IDB, job *Job) error
+	// Claim locks up to limit due jobs of the given kinds and increments their attempts
+	Claim(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]*Job, error)
+	// Complete removes a job that succeeded. The attempt is the one of the claim, a job claimed again since, once
+	// its lease expired, belongs to its new worker and ErrLeaseLost is returned.
+	Complete(ctx context.Context, id int64, attempt int) error
+	// Retry releases a job to run again at the given time, ErrLeaseLost when it was claimed again
+	Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastError string) error
+	// Bury moves a job to the dead status and scrubs its payload if sensitive, ErrLeaseLost when it was claimed
+	// again
+	Bury(ctx context.Context, id int64, attempt int, lastError string) error
+}
+
+// Option customizes an enqueued job
+type Option func(job *Job)
+
+// WithRunAt schedules the job at the given time
+func WithRunAt(runAt time.Time) Option {
+	return func(job *Job) { job.RunAt = runAt.UTC() }
+}
+
+// WithDelay schedules the job after the given delay
+func WithDelay(delay time.Duration) Option {
+	return func(job *Job) { job.RunAt = time.Now().UTC().Add(delay) }
+}
+
+// WithSensitivePayload scrubs the payload of the job when it dies, so that its secrets are not kept at rest
+func WithSensitivePayload() Option {
+	return func(job *Job) { job.Sensitive = true }
+}
+
+// WithMaxAttempts overrides the configured number of attempts
+func WithMaxAttempts(attempts int) Option {
+	return func(job *Job) { job.MaxAttempts = attempts }
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error so the job goes straight to the dead status
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent tells whether the error was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// TypedHandler adapts a handler of a decoded payload, a payload that does not decode is a permanent failure
+func TypedHandler[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(err)
+		}
+
+		return fn(ctx, payload)
+	}
+}
+
+// Register adds a typed handler to the pool
+func Register[T any](pool *Pool, kind string, fn func(ctx context.Context, payload T) error) {
+	pool.Handle(kind, TypedHandler(fn))
+}
+
+func NewQueueWithPgSQLStorage(db *bun.DB, cfg config.JobsConfigurations) Queue {
+	return &DefaultQueue{
+		db:          db,
+		storage:     NewPgSQLStorage(db),
+		maxAttempts: withDefault(cfg.MaxAttempts, defaultMaxAttempts),
+	}
+}
+
+func NewPoolWithPgSQLStorage(db *bun.DB, cfg config.JobsConfigurations) *Pool {
+	return NewPool(NewPgSQLStorage(db), cfg)
+}
+
+func withDefault[T int | time.Duration](value, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,280 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+type testPayload struct {
+	Email string `json:"email"`
+}
+
+var testConfig = config.JobsConfigurations{
+	Workers:      1,
+	PollInterval: 5 * time.Millisecond,
+	MaxAttempts:  3,
+	BackoffBase:  time.Second,
+	BackoffMax:   time.Minute,
+}
+
+// claimOnce hands out the job on the first claim, then nothing
+func claimOnce(storage *MockStorage, job *Job) {
+	storage.EXPECT().Claim(mock.Anything, []string{"test"}, 1, defaultLease).Return([]*Job{job}, nil).Once()
+	storage.EXPECT().Claim(mock.Anything, []string{"test"}, 1, defaultLease).Return(nil, nil).Maybe()
+}
+
+// runPool starts the pool and shuts it down once the outcome of the job is recorded
+func runPool(t *testing.T, pool *Pool, recorded chan struct{}) {
+	pool.Start()
+
+	select {
+	case <-recorded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job outcome was not recorded")
+	}
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestEnqueue(t *testing.T) {
+	storage := NewMockStorage(t)
+	queue := &DefaultQueue{storage: storage, maxAttempts: 5}
+	runAt := time.Now().Add(time.Hour)
+
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, db bun.IDB, job *Job) error {
+			assert.Equal(t, "test", job.Kind)
+			assert.JSONEq(t, `{"email":"john@example.com"}`, string(job.Payload))
+			assert.Equal(t, 2, job.MaxAttempts)
+			assert.WithinDuration(t, runAt, job.RunAt, time.Millisecond)
+			return nil
+		})
+
+	err := queue.Enqueue(context.Background(), "test", &testPayload{Email: "john@example.com"},
+		WithRunAt(runAt), WithMaxAttempts(2))
+
+	assert.NoError(t, err)
+}
+
+func TestEnqueueDefaults(t *testing.T) {
+	storage := NewMockStorage(t)
+	queue := &DefaultQueue{storage: storage, maxAttempts: 5}
+
+	storage.EXPECT().Insert(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, db bun.IDB, job *Job) error {
+			assert.Equal(t, 5, job.MaxAttempts)
+			assert.WithinDuration(t, time.Now(), job.RunAt, time.Second)
+			return nil
+		})
+
+	assert.NoError(t, queue.Enqueue(context.Background(), "test", &testPayload{}))
+}
+
+func TestPoolCompletesJob(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	recorded := make(chan struct{})
+	var received testPayload
+
+	Register(pool, "test", func(ctx context.Context, payload testPayload) error {
+		received = payload
+		return nil
+	})
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Payload: json.RawMessage(`{"email":"john@example.com"}`), Attempts: 1, MaxAttempts: 3})
+	storage.EXPECT().Complete(mock.Anything, int64(1), 1).RunAndReturn(func(ctx context.Context, id int64, attempt int) error {
+		close(recorded)
+		return nil
+	})
+
+	runPool(t, pool, recorded)
+
+	assert.Equal(t, "john@example.com", received.Email)
+}
+
+func TestPoolIgnoresJobsClaimedAgain(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	recorded := make(chan struct{})
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error { return nil })
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Attempts: 1, MaxAttempts: 3})
+	// the lease expired and another worker claimed the job, its second attempt owns it
+	storage.EXPECT().Complete(mock.Anything, int64(1), 1).RunAndReturn(func(ctx context.Context, id int64, attempt int) error {
+		close(recorded)
+		return ErrLeaseLost
+	})
+
+	runPool(t, pool, recorded)
+}
+
+func TestPoolRetriesWithBackoff(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	recorded := make(chan struct{})
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error { return errors.New("smtp is down") })
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Attempts: 2, MaxAttempts: 3})
+	storage.EXPECT().Retry(mock.Anything, int64(1), 2, mock.Anything, "smtp is down").
+		RunAndReturn(func(ctx context.Context, id int64, attempt int, runAt time.Time, lastError string) error {
+			// second attempt: twice the base delay, plus up to 25% of jitter
+			delay := time.Until(runAt)
+			assert.Greater(t, delay, 1900*time.Millisecond)
+			assert.LessOrEqual(t, delay, 2500*time.Millisecond)
+			close(recorded)
+			return nil
+		})
+
+	runPool(t, pool, recorded)
+}
+
+func TestPoolMovesJobsToDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		payload  string
+		err      error
+		message  string
+	}{
+		{name: "attempts exhausted", attempts: 3, payload: `{}`, err: errors.New("smtp is down"), message: "smtp is down"},
+		{name: "permanent error", attempts: 1, payload: `{}`, err: Permanent(errors.New("invalid address")), message: "invalid address"},
+		{name: "invalid payload", attempts: 1, payload: `[]`, message: "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockStorage(t)
+			pool := NewPool(storage, testConfig)
+			recorded := make(chan struct{})
+
+			Register(pool, "test", func(ctx context.Context, payload testPayload) error { return tt.err })
+			claimOnce(storage, &Job{ID: 1, Kind: "test", Payload: json.RawMessage(tt.payload), Attempts: tt.attempts, MaxAttempts: 3})
+			storage.EXPECT().Bury(mock.Anything, int64(1), tt.attempts, mock.Anything).
+				RunAndReturn(func(ctx context.Context, id int64, attempt int, lastError string) error {
+					assert.Contains(t, lastError, tt.message)
+					close(recorded)
+					return nil
+				})
+
+			runPool(t, pool, recorded)
+		})
+	}
+}
+
+func TestPoolRecoversFromPanics(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	recorded := make(chan struct{})
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error { panic("boom") })
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Attempts: 1, MaxAttempts: 3})
+	storage.EXPECT().Retry(mock.Anything, int64(1), 1, mock.Anything, "job panicked: boom").
+		RunAndReturn(func(ctx context.Context, id int64, attempt int, runAt time.Time, lastError string) error {
+			close(recorded)
+			return nil
+		})
+
+	runPool(t, pool, recorded)
+}
+
+func TestShutdownDrainsRunningJobs(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var completed sync.WaitGroup
+	completed.Add(1)
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Attempts: 1, MaxAttempts: 3})
+	storage.EXPECT().Complete(mock.Anything, int64(1), 1).RunAndReturn(func(ctx context.Context, id int64, attempt int) error {
+		completed.Done()
+		return nil
+	})
+
+	pool.Start()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- pool.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned while a job was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-shutdown)
+	completed.Wait()
+}
+
+func TestShutdownCancelsJobsAfterDeadline(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	started := make(chan struct{})
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	claimOnce(storage, &Job{ID: 1, Kind: "test", Attempts: 1, MaxAttempts: 3})
+	storage.EXPECT().Retry(mock.Anything, int64(1), 1, mock.Anything, "context canceled").Return(nil)
+
+	pool.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	pool := NewPool(NewMockStorage(t), testConfig)
+
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 4: 8 * time.Second, 10: time.Minute, 100: time.Minute} {
+		delay := pool.backoff(attempts)
+
+		assert.GreaterOrEqual(t, delay, expected)
+		assert.LessOrEqual(t, delay, expected+expected/4)
+	}
+}
+
+func TestPoolPing(t *testing.T) {
+	storage := NewMockStorage(t)
+	pool := NewPool(storage, testConfig)
+	claimed := make(chan struct{})
+	var once sync.Once
+
+	pool.Handle("test", func(ctx context.Context, job *Job) error { return nil })
+	storage.EXPECT().Claim(mock.Anything, []string{"test"}, 1, defaultLease).
+		RunAndReturn(func(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]*Job, error) {
+			once.Do(func() { close(claimed) })
+			return nil, errors.New("connection refused")
+		})
+
+	require.NoError(t, pool.Ping(context.Background()))
+
+	pool.Start()
+	<-claimed
+	assert.Eventually(t, func() bool { return pool.Ping(context.Background()) != nil }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.EqualError(t, pool.Ping(context.Background()), "job workers are stopped")
+	assert.NoError(t, pool.Shutdown(context.Background()), "expected a second shutdown to be a no-op")
+}
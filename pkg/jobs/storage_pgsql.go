@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/uptrace/bun"
+)
+
+// JobRecord the database model of a job
+type JobRecord struct {
+	bun.BaseModel `bun:"table:jobs,alias:j"`
+	ID            int64           `bun:"id,pk,autoincrement"`
+	Kind          string          `bun:"kind"`
+	Payload       json.RawMessage `bun:"payload,type:jsonb"`
+	Status        Status          `bun:"status"`
+	Attempts      int             `bun:"attempts"`
+	MaxAttempts   int             `bun:"max_attempts"`
+	LastError     string          `bun:"last_error"`
+	RunAt         time.Time       `bun:"run_at"`
+	LockedUntil   *time.Time      `bun:"locked_until"`
+	Sensitive     bool            `bun:"sensitive"`
+	CreatedAt     time.Time       `bun:"created_at"`
+	UpdatedAt     time.Time       `bun:"updated_at"`
+}
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Insert stores a pending job
+func (s *PgSQLStorage) Insert(ctx context.Context, db bun.IDB, job *Job) error {
+	record := &JobRecord{
+		Kind:        job.Kind,
+		Payload:     job.Payload,
+		Status:      StatusPending,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		Sensitive:   job.Sensitive,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.CreatedAt,
+	}
+
+	_, err := db.NewInsert().
+		Model(record).
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	job.ID = record.ID
+
+	return nil
+}
+
+// Claim locks due pending jobs, and running ones whose lease expired because their worker died.
+// SKIP LOCKED lets concurrent workers claim different jobs without waiting on each other.
+func (s *PgSQLStorage) Claim(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]*Job, error) {
+	now := time.Now().UTC()
+	var records []JobRecord
+
+	due := s.db.NewSelect().
+		Model((*JobRecord)(nil)).
+		Column("id").
+		Where("kind IN (?)", bun.In(kinds)).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("status = ?", StatusPending).Where("run_at <= ?", now)
+				}).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("status = ?", StatusRunning).Where("locked_until < ?", now)
+				})
+		}).
+		Order("run_at ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	_, err := s.db.NewUpdate().
+		Model((*JobRecord)(nil)).
+		Set("status = ?", StatusRunning).
+		Set("attempts = attempts + 1").
+		Set("locked_until = ?", now.Add(lease)).
+		Set("updated_at = ?", now).
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(records))
+	for _, record := range records {
+		jobs = append(jobs, &Job{
+			ID:          record.ID,
+			Kind:        record.Kind,
+			Payload:     record.Payload,
+			Attempts:    record.Attempts,
+			MaxAttempts: record.MaxAttempts,
+			RunAt:       record.RunAt,
+			CreatedAt:   record.CreatedAt,
+			Sensitive:   record.Sensitive,
+		})
+	}
+
+	return jobs, nil
+}
+
+// Complete removes a job that succeeded
+func (s *PgSQLStorage) Complete(ctx context.Context, id int64, attempt int) error {
+	res, err := s.db.NewDelete().
+		Model((*JobRecord)(nil)).
+		Where("id = ?", id).
+		Where("status = ?", StatusRunning).
+		Where("attempts = ?", attempt).
+		Exec(ctx)
+
+	return leaseHeld(res, err)
+}
+
+// Retry releases a job to run again at the given time
+func (s *PgSQLStorage) Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastError string) error {
+	res, err := s.db.NewUpdate().
+		Model((*JobRecord)(nil)).
+		Set("status = ?", StatusPending).
+		Set("run_at = ?", runAt).
+		Set("locked_until = NULL").
+		Set("last_error = ?", lastError).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", id).
+		Where("status = ?", StatusRunning).
+		Where("attempts = ?", attempt).
+		Exec(ctx)
+
+	return leaseHeld(res, err)
+}
+
+// Bury moves a job to the dead status, the payload of a sensitive job is scrubbed
+func (s *PgSQLStorage) Bury(ctx context.Context, id int64, attempt int, lastError string) error {
+	res, err := s.db.NewUpdate().
+		Model((*JobRecord)(nil)).
+		Set("status = ?", StatusDead).
+		Set("payload = CASE WHEN sensitive THEN '{}'::jsonb ELSE payload END").
+		Set("locked_until = NULL").
+		Set("last_error = ?", lastError).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", id).
+		Where("status = ?", StatusRunning).
+		Where("attempts = ?", attempt).
+		Exec(ctx)
+
+	return leaseHeld(res, err)
+}
+
+// leaseHeld the claim incremented the attempts, a job updated by no row was claimed again by another worker
+func leaseHeld(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
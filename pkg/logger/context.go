@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fields added by the context logger
+const (
+	TraceField     = "trace"
+	PrincipalField = "principal"
+	TraceIDField   = "trace_id"
+	SpanIDField    = "span_id"
+)
+
+type ctxKeyLogger int
+
+const (
+	loggerKey ctxKeyLogger = iota + 1
+	scopeKey
+)
+
+// requestScope what is learned while a request is handled, the middlewares read it once the handler returned
+type requestScope struct {
+	principalID string
+}
+
+// RequestLogger is a middleware giving the handlers a logger with the request ID and the trace of the request, it
+// must run after middleware.RequestID and the tracing middleware
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := withSpan(r.Context(), log.With().Str(TraceField, middleware.GetReqID(r.Context()))).Logger()
+
+		ctx := context.WithValue(r.Context(), loggerKey, &logger)
+		ctx = context.WithValue(ctx, scopeKey, &requestScope{})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithPrincipal adds the authenticated principal to the logger of ctx
+func WithPrincipal(ctx context.Context, principalID string) context.Context {
+	if scope, ok := ctx.Value(scopeKey).(*requestScope); ok {
+		scope.principalID = principalID
+	}
+
+	logger := Ctx(ctx).With().Str(PrincipalField, principalID).Logger()
+
+	return context.WithValue(ctx, loggerKey, &logger)
+}
+
+// RequestPrincipal returns the principal authenticated while handling the request of ctx, even when it was
+// authenticated by a handler nested in the caller
+func RequestPrincipal(ctx context.Context) string {
+	if scope, ok := ctx.Value(scopeKey).(*requestScope); ok {
+		return scope.principalID
+	}
+
+	return ""
+}
+
+// Ctx returns the logger of the request handled in ctx. Outside of requests, it is the global logger, with the trace
+// of ctx when there is one, e.g. in a job.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if ctx == nil {
+		return &log.Logger
+	}
+
+	if logger, ok := ctx.Value(loggerKey).(*zerolog.Logger); ok {
+		return logger
+	}
+
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		logger := withSpan(ctx, log.With()).Logger()
+		return &logger
+	}
+
+	return &log.Logger
+}
+
+// withSpan adds the IDs of the trace and span of ctx, so the lines can be correlated with the traces
+func withSpan(ctx context.Context, logCtx zerolog.Context) zerolog.Context {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return logCtx
+	}
+
+	return logCtx.
+		Str(TraceIDField, spanCtx.TraceID().String()).
+		Str(SpanIDField, spanCtx.SpanID().String())
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactedFields the values of these fields are never written, whatever their case
+var defaultRedactedFields = []string{
+	"password", "token", "access_token", "accessToken", "refresh_token", "refreshToken", "code", "otp", "secret",
+	"authorization", "cookie",
+}
+
+var (
+	// emails keep their first character and domain, e.g. j***@example.com
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,})`)
+	// bearer credentials and long random strings such as session tokens, secrets and hashes
+	tokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=\-]+|[A-Za-z0-9_\-$]{32,}`)
+	// UUIDs identify records, they are kept
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	// the trace and span IDs look like tokens, they are kept so the lines can be correlated with the traces
+	traceFieldPattern = regexp.MustCompile(`"(?:` + TraceIDField + `|` + SpanIDField + `)":"[0-9a-f]+"`)
+)
+
+// RedactingWriter masks the sensitive values of the JSON lines written by zerolog: the values of the sensitive
+// fields, then the emails and tokens found anywhere in the line
+type RedactingWriter struct {
+	next         io.Writer
+	fieldPattern *regexp.Regexp
+}
+
+// NewRedactingWriter the values of extraFields are redacted on top of the default fields
+func NewRedactingWriter(next io.Writer, extraFields ...string) *RedactingWriter {
+	fields := append(slices.Clone(defaultRedactedFields), extraFields...)
+
+	quoted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+
+	return &RedactingWriter{
+		next: next,
+		// a JSON string, number or boolean value of one of the fields
+		fieldPattern: regexp.MustCompile(`(?i)"(` + strings.Join(quoted, "|") + `)":("(?:[^"\\]|\\.)*"|[0-9.eE+\-]+|true|false)`),
+	}
+}
+
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.next.Write(w.Redact(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Redact returns the line with its sensitive values masked
+func (w *RedactingWriter) Redact(line []byte) []byte {
+	line = w.fieldPattern.ReplaceAll(line, []byte(`"$1":"`+redacted+`"`))
+	line = emailPattern.ReplaceAll(line, []byte("${1}***@${2}"))
+
+	kept := traceFieldPattern.FindAllIndex(line, -1)
+
+	var out []byte
+	last := 0
+	for _, match := range tokenPattern.FindAllIndex(line, -1) {
+		if uuidPattern.Match(line[match[0]:match[1]]) || within(match, kept) {
+			continue
+		}
+		out = append(out, line[last:match[0]]...)
+		out = append(out, redacted...)
+		last = match[1]
+	}
+
+	if last == 0 {
+		return line
+	}
+
+	return append(out, line[last:]...)
+}
+
+// within tells whether the match is inside one of the ranges
+func within(match []int, ranges [][]int) bool {
+	for _, r := range ranges {
+		if match[0] >= r[0] && match[1] <= r[1] {
+			return true
+		}
+	}
+
+	return false
+}
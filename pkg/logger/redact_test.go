@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactSensitiveFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(NewRedactingWriter(&out, "pin"))
+
+	logger.Info().
+		Str("code", "123456").
+		Int("otp", 654321).
+		Str("Password", `my "secret"`).
+		Str("pin", "0000").
+		Int("status", 401).
+		Msg("failed to verify code")
+
+	line := out.String()
+	assert.NotContains(t, line, "123456")
+	assert.NotContains(t, line, "654321")
+	assert.NotContains(t, line, "my \\\"secret")
+	assert.NotContains(t, line, "0000")
+	assert.Contains(t, line, `"code":"[REDACTED]"`)
+	assert.Contains(t, line, `"status":401`)
+	assert.Contains(t, line, "failed to verify code")
+}
+
+func TestRedactEmails(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(NewRedactingWriter(&out))
+
+	logger.Info().Str("email", "jane.doe@example.com").Msg("login with email and password: john+tag@mail.example.org")
+
+	line := out.String()
+	assert.NotContains(t, line, "jane.doe")
+	assert.NotContains(t, line, "john+tag")
+	assert.Contains(t, line, "j***@example.com")
+	assert.Contains(t, line, "j***@mail.example.org")
+}
+
+func TestRedactTokens(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(NewRedactingWriter(&out))
+
+	token := strings.Repeat("aZ9_", 10)
+	logger.Info().
+		Str("header", "Bearer abc.def-ghi").
+		Str("session", token).
+		Str("hash", "k1$"+strings.Repeat("ab", 32)).
+		Str("identity", "7f0c1b8e-4b1e-4f59-9d3c-1c2b3a4d5e6f").
+		Msg("session created")
+
+	line := out.String()
+	assert.NotContains(t, line, "abc.def-ghi")
+	assert.NotContains(t, line, token)
+	assert.NotContains(t, line, strings.Repeat("ab", 32))
+	assert.Contains(t, line, "7f0c1b8e-4b1e-4f59-9d3c-1c2b3a4d5e6f", "expected UUIDs to be kept")
+}
+
+func TestRedactKeepsTraceIDs(t *testing.T) {
+	path := t.TempDir() + "/app.log"
+
+	logger, err := New(config.LoggerConfigurations{Output: path})
+	require.NoError(t, err)
+
+	previous := log.Logger
+	log.Logger = logger
+	t.Cleanup(func() { log.Logger = previous })
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	token := strings.Repeat("aZ9_", 10)
+	Ctx(ctx).Info().Str("session", token).Msg("job handled")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, string(content), `"span_id":"00f067aa0ba902b7"`)
+	assert.NotContains(t, string(content), token, "expected the other tokens of the line to be redacted")
+}
+
+func TestNewRejectsInvalidConfigurations(t *testing.T) {
+	_, err := New(config.LoggerConfigurations{Level: "verbose"})
+	assert.Error(t, err, "expected error for an unknown level")
+
+	_, err = New(config.LoggerConfigurations{Format: "xml"})
+	assert.Error(t, err, "expected error for an unknown format")
+
+	_, err = New(config.LoggerConfigurations{Output: t.TempDir()})
+	assert.Error(t, err, "expected error for a directory output")
+}
+
+func TestNewWritesToFile(t *testing.T) {
+	path := t.TempDir() + "/app.log"
+
+	logger, err := New(config.LoggerConfigurations{Level: "warn", Output: path})
+	require.NoError(t, err)
+
+	logger.Info().Msg("dropped by the level")
+	logger.Warn().Str("token", "abc").Msg("written")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "dropped by the level")
+	assert.Contains(t, string(content), `"token":"[REDACTED]"`)
+}
+
+func TestSamplingKeepsWarnings(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(&out).Sample(newSampler(config.LoggerSamplingConfigurations{Burst: 2, Period: time.Hour}))
+
+	for range 10 {
+		logger.Info().Msg("sampled")
+		logger.Warn().Msg("kept")
+	}
+
+	assert.Equal(t, 2, strings.Count(out.String(), "sampled"))
+	assert.Equal(t, 10, strings.Count(out.String(), "kept"))
+}
+
+func TestContextLoggerAddsRequestAndPrincipal(t *testing.T) {
+	var out bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&out)
+	t.Cleanup(func() { log.Logger = previous })
+
+	handler := RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithPrincipal(r.Context(), "identity-1")
+		Ctx(ctx).Info().Msg("handled")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "request-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, out.String(), `"trace":"request-1"`)
+	assert.Contains(t, out.String(), `"principal":"identity-1"`)
+}
+
+func TestContextLoggerOutsideRequests(t *testing.T) {
+	assert.Same(t, &log.Logger, Ctx(context.Background()))
+}
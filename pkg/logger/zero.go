@@ -1,13 +1,106 @@
 package logger
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
 )
 
-func MustConfigure() {
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// MustConfigure replaces the global logger with the one of the configuration, every line goes through the
+// redaction before being written
+func MustConfigure(cfg config.LoggerConfigurations) {
+	logger, err := New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error configuring the logger")
+	}
+
 	zerolog.TimeFieldFormat = time.RFC3339
-	log.Logger = log.With().Caller().Logger()
+	log.Logger = logger
+}
+
+// New creates a logger from the configuration, defaults to JSON lines on stdout at the info level
+func New(cfg config.LoggerConfigurations) (zerolog.Logger, error) {
+	level := zerolog.InfoLevel
+	if cfg.Level != "" {
+		parsed, err := zerolog.ParseLevel(strings.ToLower(cfg.Level))
+		if err != nil {
+			return zerolog.Nop(), fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+		}
+		level = parsed
+	}
+
+	output, err := openOutput(cfg.Output)
+	if err != nil {
+		return zerolog.Nop(), err
+	}
+
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatJSON:
+	case FormatConsole:
+		output = zerolog.ConsoleWriter{Out: output, TimeFormat: time.RFC3339}
+	default:
+		return zerolog.Nop(), fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	logger := zerolog.New(NewRedactingWriter(output, cfg.RedactFields...)).
+		Level(level).
+		With().Timestamp().Caller().
+		Logger()
+
+	if cfg.Sampling.Enabled {
+		logger = logger.Sample(newSampler(cfg.Sampling))
+	}
+
+	return logger, nil
+}
+
+// openOutput the file outputs are opened for the lifetime of the process
+func openOutput(output string) (io.Writer, error) {
+	switch output {
+	case "", OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	default:
+		file, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+		if err != nil {
+			return nil, fmt.Errorf("opening log output: %w", err)
+		}
+		return file, nil
+	}
+}
+
+// newSampler samples the info, debug and trace lines, the warnings and errors are always written. Every period,
+// the first burst lines are written, then one out of every thereafter.
+func newSampler(cfg config.LoggerSamplingConfigurations) zerolog.Sampler {
+	period := cfg.Period
+	if period <= 0 {
+		period = time.Second
+	}
+
+	// Without a next sampler, nothing is written beyond the burst
+	sampler := &zerolog.BurstSampler{Burst: cfg.Burst, Period: period}
+	if cfg.Thereafter > 0 {
+		sampler.NextSampler = &zerolog.BasicSampler{N: cfg.Thereafter}
+	}
+
+	return zerolog.LevelSampler{
+		TraceSampler: sampler,
+		DebugSampler: sampler,
+		InfoSampler:  sampler,
+	}
 }
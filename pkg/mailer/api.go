@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/zeusito/toci/pkg/tracing"
+)
+
+// APISender sends through an HTTP API provider, the payload is the one of Resend
+type APISender struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type apiRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+func NewAPISender(url, apiKey string) *APISender {
+	return &APISender{
+		url:        url,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (s *APISender) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&apiRequest{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("email provider responded with status %d: %s", resp.StatusCode, detail)
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// Ping connects to the API, it does not send a request since it would count towards the quota of the provider
+func (s *APISender) Ping(ctx context.Context) error {
+	target, err := url.Parse(s.url)
+	if err != nil {
+		return err
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = "443"
+		if target.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(target.Hostname(), port))
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/zeusito/toci/pkg/logger"
+)
+
+// RedirectSender delivers every message to a single test address instead of the real recipients
+type RedirectSender struct {
+	next Sender
+	to   string
+}
+
+func NewRedirectSender(next Sender, to string) *RedirectSender {
+	return &RedirectSender{next: next, to: to}
+}
+
+func (s *RedirectSender) Send(ctx context.Context, msg *Message) error {
+	redirected := *msg
+	redirected.To = []string{s.to}
+	redirected.Subject = "[to " + strings.Join(msg.To, ", ") + "] " + msg.Subject
+
+	return s.next.Send(ctx, &redirected)
+}
+
+func (s *RedirectSender) Ping(ctx context.Context) error {
+	if pinger, ok := s.next.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// LogSender only logs the messages, for local development without a mail provider
+type LogSender struct{}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	// The body holds codes and links that sign in, it is only written at the debug level
+	logger.Ctx(ctx).Info().
+		Strs("to", msg.To).
+		Str("subject", msg.Subject).
+		Msg("email not sent in dev mode")
+	logger.Ctx(ctx).Debug().Msgf("body of the email not sent in dev mode:\n%s", msg.Text)
+
+	return nil
+}
+
+// DiscardSender drops the messages, used when email is disabled
+type DiscardSender struct{}
+
+func (s *DiscardSender) Send(ctx context.Context, msg *Message) error {
+	logger.Ctx(ctx).Debug().Str("subject", msg.Subject).Msg("email is disabled, message dropped")
+
+	return nil
+}
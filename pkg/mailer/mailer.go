@@ -0,0 +1,173 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+type Template string
+
+const (
+	TemplateOTP           Template = "otp"
+	TemplatePasswordReset Template = "password_reset"
+	TemplateMagicLink     Template = "magic_link"
+	TemplateInvitation    Template = "invitation"
+)
+
+// TemplateData the values available to the templates, each template uses a subset of them
+type TemplateData struct {
+	AppName          string
+	Code             string
+	Link             string
+	ExpiresIn        string
+	InviterName      string
+	OrganizationName string
+}
+
+// Invitation the details of an invitation to join an organization
+type Invitation struct {
+	InviterName      string
+	OrganizationName string
+	Link             string
+	ExpiresIn        time.Duration
+}
+
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Mailer renders the transactional emails and hands them to a Sender
+type Mailer struct {
+	sender    Sender
+	from      string
+	appName   string
+	templates map[Template]*templateSet
+}
+
+func NewMailer(sender Sender, from, appName string) (*Mailer, error) {
+	m := &Mailer{
+		sender:    sender,
+		from:      from,
+		appName:   appName,
+		templates: map[Template]*templateSet{},
+	}
+
+	for _, name := range []Template{TemplateOTP, TemplatePasswordReset, TemplateMagicLink, TemplateInvitation} {
+		text, err := texttemplate.ParseFS(templatesFS, "templates/"+string(name)+".txt")
+		if err != nil {
+			return nil, err
+		}
+
+		html, err := htmltemplate.ParseFS(templatesFS, "templates/layout.html", "templates/"+string(name)+".html")
+		if err != nil {
+			return nil, err
+		}
+
+		m.templates[name] = &templateSet{text: text, html: html}
+	}
+
+	return m, nil
+}
+
+// SendOTP sends a one time password
+func (m *Mailer) SendOTP(ctx context.Context, to, code string, expiresIn time.Duration) error {
+	return m.send(ctx, TemplateOTP, to, TemplateData{Code: code, ExpiresIn: humanizeDuration(expiresIn)})
+}
+
+// SendPasswordReset sends the code resetting a forgotten password
+func (m *Mailer) SendPasswordReset(ctx context.Context, to, code string, expiresIn time.Duration) error {
+	return m.send(ctx, TemplatePasswordReset, to, TemplateData{Code: code, ExpiresIn: humanizeDuration(expiresIn)})
+}
+
+// SendMagicLink sends a sign-in link
+func (m *Mailer) SendMagicLink(ctx context.Context, to, link string, expiresIn time.Duration) error {
+	return m.send(ctx, TemplateMagicLink, to, TemplateData{Link: link, ExpiresIn: humanizeDuration(expiresIn)})
+}
+
+// SendInvitation sends an invitation to join an organization
+func (m *Mailer) SendInvitation(ctx context.Context, to string, invitation Invitation) error {
+	return m.send(ctx, TemplateInvitation, to, TemplateData{
+		Link:             invitation.Link,
+		ExpiresIn:        humanizeDuration(invitation.ExpiresIn),
+		InviterName:      invitation.InviterName,
+		OrganizationName: invitation.OrganizationName,
+	})
+}
+
+// Ping tells whether the provider of the sender is reachable, senders without a provider always are
+func (m *Mailer) Ping(ctx context.Context) error {
+	if pinger, ok := m.sender.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// Render builds the message of a template without sending it
+func (m *Mailer) Render(name Template, to string, data TemplateData) (*Message, error) {
+	set, ok := m.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	data.AppName = m.appName
+
+	var subject, text, html bytes.Buffer
+
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	if err := set.text.ExecuteTemplate(&text, string(name)+".txt", data); err != nil {
+		return nil, err
+	}
+
+	if err := set.html.ExecuteTemplate(&html, "layout.html", data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		From:    m.from,
+		To:      []string{to},
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+func (m *Mailer) send(ctx context.Context, name Template, to string, data TemplateData) error {
+	msg, err := m.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+
+	return m.sender.Send(ctx, msg)
+}
+
+// humanizeDuration formats the validity of a code or link, e.g. "5 minutes"
+func humanizeDuration(d time.Duration) string {
+	unit, count := "minute", int(d.Round(time.Minute)/time.Minute)
+
+	switch {
+	case d >= 24*time.Hour:
+		unit, count = "day", int(d.Round(time.Hour)/(24*time.Hour))
+	case d >= time.Hour:
+		unit, count = "hour", int(d.Round(time.Minute)/time.Hour)
+	}
+
+	if count == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", count, unit)
+}
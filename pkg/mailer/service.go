@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const defaultApiURL = "https://api.resend.com/emails"
+
+// Message an email ready to be sent, with both an HTML and a plain text body
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Pinger a sender that can tell whether its provider is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NewSenderFromConfig Creates the sender of the configured provider. In dev mode, mail is redirected to
+// the test email, or only logged when there is none.
+func NewSenderFromConfig(cfg config.EmailConfigurations) (Sender, error) {
+	if !cfg.Enabled {
+		return &DiscardSender{}, nil
+	}
+
+	var sender Sender
+
+	switch cfg.Provider {
+	case "", "api":
+		if cfg.ApiKey == "" && cfg.DevMode {
+			return &LogSender{}, nil
+		}
+
+		apiURL := cfg.ApiURL
+		if apiURL == "" {
+			apiURL = defaultApiURL
+		}
+		sender = NewAPISender(apiURL, cfg.ApiKey)
+	case "smtp":
+		smtpSender, err := NewSMTPSender(cfg.SMTP)
+		if err != nil {
+			return nil, err
+		}
+		sender = smtpSender
+	default:
+		return nil, fmt.Errorf("unsupported email provider %q", cfg.Provider)
+	}
+
+	if !cfg.DevMode {
+		return sender, nil
+	}
+
+	if cfg.TestEmail == "" {
+		return &LogSender{}, nil
+	}
+
+	return &RedirectSender{next: sender, to: cfg.TestEmail}, nil
+}
+
+// validate rejects messages that cannot be sent, including header injection attempts
+func (m *Message) validate() error {
+	if len(m.To) == 0 {
+		return errors.New("message has no recipient")
+	}
+
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+
+	for _, to := range m.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient: %w", err)
+		}
+	}
+
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("subject must be a single line")
+	}
+
+	return nil
+}
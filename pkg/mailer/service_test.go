@@ -0,0 +1,307 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const testFrom = "Toci <mailer@toci.dev>"
+
+// fakeSMTPServer speaks just enough SMTP to accept messages, without STARTTLS
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpt     []string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &fakeSMTPServer{listener: listener}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	tp := textproto.NewConn(conn)
+
+	_ = tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		s.mu.Lock()
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			_ = tp.PrintfLine("250-fake\r\n250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = arg
+			_ = tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			s.from = arg
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotBytes()
+			s.data = string(data)
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func newTestMailer(t *testing.T, sender Sender) *Mailer {
+	m, err := NewMailer(sender, testFrom, "Toci")
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestSMTPSender(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	sender, err := NewSMTPSender(config.SMTPConfigurations{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "user",
+		Password: "secret",
+		TLS:      "none",
+	})
+	require.NoError(t, err)
+
+	err = newTestMailer(t, sender).SendOTP(context.Background(), "john@example.com", "123456", 5*time.Minute)
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(server.auth, "PLAIN "))
+	require.NoError(t, err)
+	assert.Equal(t, "\x00user\x00secret", string(credentials))
+	assert.Equal(t, "FROM:<mailer@toci.dev>", server.from)
+	assert.Equal(t, []string{"TO:<john@example.com>"}, server.rcpt)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	require.NoError(t, err)
+	assert.Equal(t, "Your Toci sign-in code", msg.Header.Get("Subject"))
+	assert.Equal(t, "john@example.com", msg.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var contentTypes []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "123456")
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+	}
+	assert.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, contentTypes)
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	sender, err := NewSMTPSender(config.SMTPConfigurations{Host: "127.0.0.1", Port: server.port()})
+	require.NoError(t, err)
+
+	err = newTestMailer(t, sender).SendOTP(context.Background(), "john@example.com", "123456", 5*time.Minute)
+
+	assert.ErrorContains(t, err, "STARTTLS")
+}
+
+func TestAPISender(t *testing.T) {
+	var received apiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"email-1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	m := newTestMailer(t, NewAPISender(server.URL, "api-key"))
+
+	err := m.SendMagicLink(context.Background(), "john@example.com", "https://app.example.com/magic?token=abc&x=1", 15*time.Minute)
+
+	require.NoError(t, err)
+	assert.Equal(t, testFrom, received.From)
+	assert.Equal(t, []string{"john@example.com"}, received.To)
+	assert.Equal(t, "Sign in to Toci", received.Subject)
+	assert.Contains(t, received.Text, "https://app.example.com/magic?token=abc&x=1")
+	assert.Contains(t, received.Text, "15 minutes")
+	assert.Contains(t, received.HTML, `href="https://app.example.com/magic?token=abc&amp;x=1"`)
+}
+
+func TestAPISenderReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message":"invalid from"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	err := newTestMailer(t, NewAPISender(server.URL, "api-key")).
+		SendOTP(context.Background(), "john@example.com", "123456", 5*time.Minute)
+
+	assert.ErrorContains(t, err, "422")
+}
+
+func TestInvitationEscapesHTML(t *testing.T) {
+	msg, err := newTestMailer(t, &DiscardSender{}).Render(TemplateInvitation, "john@example.com", TemplateData{
+		InviterName:      "<script>alert(1)</script>",
+		OrganizationName: "Acme",
+		Link:             "https://app.example.com/invitations/1",
+		ExpiresIn:        humanizeDuration(7 * 24 * time.Hour),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "<script>alert(1)</script> invited you to join Acme on Toci", msg.Subject)
+	assert.NotContains(t, msg.HTML, "<script>")
+	assert.Contains(t, msg.HTML, "&lt;script&gt;")
+	assert.Contains(t, msg.Text, "7 days")
+}
+
+// recordingSender keeps the last message
+type recordingSender struct {
+	msg *Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg *Message) error {
+	s.msg = msg
+	return nil
+}
+
+func TestPasswordResetTemplate(t *testing.T) {
+	msg, err := newTestMailer(t, &DiscardSender{}).Render(TemplatePasswordReset, "john@example.com", TemplateData{
+		Code:      "123456",
+		ExpiresIn: "5 minutes",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Reset your Toci password", msg.Subject)
+	assert.Contains(t, msg.Text, "123456")
+	assert.Contains(t, msg.HTML, "reset your password")
+	assert.NotContains(t, msg.Text, "sign in to")
+}
+
+func TestRedirectSender(t *testing.T) {
+	recorder := &recordingSender{}
+
+	err := newTestMailer(t, NewRedirectSender(recorder, "delivered@example.com")).
+		SendOTP(context.Background(), "john@example.com", "123456", time.Minute)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delivered@example.com"}, recorder.msg.To)
+	assert.Equal(t, "[to john@example.com] Your Toci sign-in code", recorder.msg.Subject)
+}
+
+func TestNewSenderFromConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.EmailConfigurations
+		expected Sender
+	}{
+		{name: "disabled", cfg: config.EmailConfigurations{}, expected: &DiscardSender{}},
+		{name: "api", cfg: config.EmailConfigurations{Enabled: true, ApiKey: "key"}, expected: &APISender{}},
+		{name: "dev mode without test email", cfg: config.EmailConfigurations{Enabled: true, DevMode: true, ApiKey: "key"}, expected: &LogSender{}},
+		{name: "dev mode without api key", cfg: config.EmailConfigurations{Enabled: true, DevMode: true, TestEmail: "t@example.com"}, expected: &LogSender{}},
+		{name: "dev mode with test email", cfg: config.EmailConfigurations{Enabled: true, DevMode: true, ApiKey: "key", TestEmail: "t@example.com"}, expected: &RedirectSender{}},
+		{name: "smtp", cfg: config.EmailConfigurations{Enabled: true, Provider: "smtp", SMTP: config.SMTPConfigurations{Host: "localhost", Port: 25}}, expected: &SMTPSender{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSenderFromConfig(tt.cfg)
+
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, sender)
+		})
+	}
+
+	_, err := NewSenderFromConfig(config.EmailConfigurations{Enabled: true, Provider: "pigeon"})
+	assert.Error(t, err)
+}
+
+func TestMessageValidation(t *testing.T) {
+	sender := &LogSender{}
+
+	assert.Error(t, sender.Send(context.Background(), &Message{From: testFrom}))
+	assert.Error(t, sender.Send(context.Background(), &Message{From: testFrom, To: []string{"not an address"}}))
+	assert.Error(t, sender.Send(context.Background(), &Message{
+		From:    testFrom,
+		To:      []string{"john@example.com"},
+		Subject: "hello\r\nBcc: victim@example.com",
+	}))
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		time.Minute:      "1 minute",
+		5 * time.Minute:  "5 minutes",
+		2 * time.Hour:    "2 hours",
+		48 * time.Hour:   "2 days",
+		24 * time.Hour:   "1 day",
+		90 * time.Second: "2 minutes",
+	} {
+		assert.Equal(t, expected, humanizeDuration(d), strconv.Itoa(int(d.Seconds())))
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry the metrics of the application, with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Metrics of the HTTP server and of the authentication flows
+var (
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests by chi route pattern and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	OTPIssued = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_otp_issued_total",
+		Help: "One-time passwords issued by kind.",
+	}, []string{"kind"})
+	OTPVerified = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_otp_verified_total",
+		Help: "One-time passwords verified by kind and result, success or failure.",
+	}, []string{"kind", "result"})
+	FailedLogins = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failed_logins_total",
+		Help: "Failed sign-ins by method, e.g. password, otp, passkey or external.",
+	}, []string{"method"})
+	SessionsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_sessions_created_total",
+		Help: "Sessions created.",
+	})
+	SessionsRevoked = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_sessions_revoked_total",
+		Help: "Sessions revoked.",
+	})
+	Lockouts = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_lockouts_total",
+		Help: "Identities locked after too many failed sign-ins.",
+	})
+)
+
+// Results of a verification
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// Server serves the metrics on an admin port, apart from the public API
+type Server struct {
+	srv     *http.Server
+	enabled bool
+}
+
+func NewServer(cfg config.MetricsConfigurations, gatherer prometheus.Gatherer) *Server {
+	path := cfg.Path
+	if path == "" {
+		path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+path, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	return &Server{
+		srv: &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		enabled: cfg.Enabled,
+	}
+}
+
+// Start serves the metrics until the server is shut down, it returns the error that stopped it otherwise
+func (s *Server) Start() error {
+	if !s.enabled {
+		log.Warn().Msg("metrics are disabled")
+		return nil
+	}
+
+	log.Info().Msgf("Metrics listening on port %s", s.srv.Addr)
+	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+
+	return s.srv.Shutdown(ctx)
+}
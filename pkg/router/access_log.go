@@ -0,0 +1,98 @@
+package router
+
+import (
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/logger"
+)
+
+// AccessLog is a middleware logging the completed requests with their route, status, size, latency, client and
+// principal. It must run after logger.RequestLogger and middleware.RealIP, and before middleware.Recoverer so
+// panics are logged with their 500 status.
+func AccessLog(cfg config.AccessLogConfigurations) func(http.Handler) http.Handler {
+	skipPaths := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := skipPaths[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				latency := time.Since(start)
+
+				status := ww.Status()
+				if status == 0 {
+					// nothing was written, the server answers 200
+					status = http.StatusOK
+				}
+
+				slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+				if status < http.StatusBadRequest && !slow && !sampled(cfg.SampleRate) {
+					return
+				}
+
+				var event *zerolog.Event
+				switch {
+				case status >= http.StatusInternalServerError:
+					event = logger.Ctx(r.Context()).Error()
+				case slow:
+					event = logger.Ctx(r.Context()).Warn().Bool("slow", true)
+				default:
+					event = logger.Ctx(r.Context()).Info()
+				}
+
+				route := chi.RouteContext(r.Context()).RoutePattern()
+				if route == "" {
+					// no route matched, e.g. a 404
+					route = r.URL.Path
+				}
+
+				event.
+					Str("method", r.Method).
+					Str("route", route).
+					Int("status", status).
+					Int("bytes", ww.BytesWritten()).
+					Dur("latency", latency).
+					Str("ip", clientIP(r)).
+					Str(logger.PrincipalField, logger.RequestPrincipal(r.Context())).
+					Msg("request completed")
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// sampled tells whether a request is logged under the sample rate
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+
+	return rate > 0 && rand.Float64() < rate
+}
+
+// clientIP the address of the client without its port, RealIP sets the address without one
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/logger"
+)
+
+func newAccessLogRouter(t *testing.T, cfg config.AccessLogConfigurations) (*chi.Mux, *bytes.Buffer) {
+	var out bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&out)
+	t.Cleanup(func() { log.Logger = previous })
+
+	mux := chi.NewRouter()
+	mux.Use(middleware.RequestID)
+	mux.Use(logger.RequestLogger)
+	mux.Use(middleware.RealIP)
+	mux.Use(AccessLog(cfg))
+	mux.Use(middleware.Recoverer)
+
+	mux.Get("/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = logger.WithPrincipal(r.Context(), "identity-1")
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.Get("/v1/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	mux.Get("/v1/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.Get("/health/liveness", func(w http.ResponseWriter, r *http.Request) {})
+
+	return mux, &out
+}
+
+func serve(mux http.Handler, path string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Real-IP", "203.0.113.7")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestAccessLogRecordsCompletedRequest(t *testing.T) {
+	mux, out := newAccessLogRouter(t, config.AccessLogConfigurations{SampleRate: 1})
+
+	serve(mux, "/v1/items/42")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, http.MethodGet, line["method"])
+	assert.Equal(t, "/v1/items/{id}", line["route"], "expected the route pattern instead of the path")
+	assert.EqualValues(t, http.StatusOK, line["status"])
+	assert.EqualValues(t, 5, line["bytes"])
+	assert.Equal(t, "203.0.113.7", line["ip"])
+	assert.Equal(t, "identity-1", line["principal"])
+	assert.NotEmpty(t, line["trace"])
+	assert.Contains(t, line, "latency")
+}
+
+func TestAccessLogSkipsPaths(t *testing.T) {
+	mux, out := newAccessLogRouter(t, config.AccessLogConfigurations{SampleRate: 1, SkipPaths: []string{"/health/liveness"}})
+
+	serve(mux, "/health/liveness")
+
+	assert.Empty(t, out.String())
+}
+
+func TestAccessLogSamplingKeepsFailures(t *testing.T) {
+	mux, out := newAccessLogRouter(t, config.AccessLogConfigurations{SampleRate: 0})
+
+	serve(mux, "/v1/items/42")
+	assert.Empty(t, out.String(), "expected successful requests to be sampled out")
+
+	serve(mux, "/v1/unknown")
+	assert.Contains(t, out.String(), `"status":404`)
+	assert.Contains(t, out.String(), `"route":"/v1/unknown"`)
+}
+
+func TestAccessLogPanicIsAnError(t *testing.T) {
+	mux, out := newAccessLogRouter(t, config.AccessLogConfigurations{SampleRate: 0})
+
+	serve(mux, "/v1/panic")
+
+	assert.Contains(t, out.String(), `"level":"error"`)
+	assert.Contains(t, out.String(), `"status":500`)
+}
+
+func TestAccessLogWarnsAboutSlowRequests(t *testing.T) {
+	mux, out := newAccessLogRouter(t, config.AccessLogConfigurations{SampleRate: 0, SlowThreshold: 10 * time.Millisecond})
+
+	serve(mux, "/v1/slow")
+
+	assert.Contains(t, out.String(), `"level":"warn"`)
+	assert.Contains(t, out.String(), `"slow":true`)
+}
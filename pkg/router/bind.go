@@ -8,21 +8,70 @@ import (
 
 	"github.com/zeusito/toci/pkg/terrors"
 
-	"github.com/goccy/go-json"
-
 	"github.com/rs/zerolog/log"
 
 	"github.com/go-playground/validator/v10"
 )
 
-const (
-	MIMEApplicationJSON = "application/json"
-)
-
 // use a single instance of Validate, it caches struct info
 var validate = validator.New(validator.WithRequiredStructEnabled())
 
 func BindBody[T any](r *http.Request, target *T) error {
+	if err := decodeBody(r, target); err != nil {
+		return err
+	}
+
+	return validateStruct(target)
+}
+
+// BindQuery fills the fields tagged with query, e.g. `query:"page"`, from the query string and validates the struct
+func BindQuery[T any](r *http.Request, target *T) error {
+	if err := bindQuery(r, target); err != nil {
+		return err
+	}
+
+	return validateStruct(target)
+}
+
+// BindPath fills the fields tagged with path, e.g. `path:"id"`, from the URL params of the route and validates the
+// struct
+func BindPath[T any](r *http.Request, target *T) error {
+	if err := bindPath(r, target); err != nil {
+		return err
+	}
+
+	return validateStruct(target)
+}
+
+// BindHeaders fills the fields tagged with header, e.g. `header:"If-Match"`, from the request headers and validates
+// the struct
+func BindHeaders[T any](r *http.Request, target *T) error {
+	if err := bindHeaders(r, target); err != nil {
+		return err
+	}
+
+	return validateStruct(target)
+}
+
+// BindRequest fills one struct from the body, when there is one, then the query string, the URL params and the
+// headers, a later source overriding an earlier one. The struct is validated once filled.
+func BindRequest[T any](r *http.Request, target *T) error {
+	if r.ContentLength != 0 {
+		if err := decodeBody(r, target); err != nil {
+			return err
+		}
+	}
+
+	for _, bind := range []func(*http.Request, any) error{bindQuery, bindPath, bindHeaders} {
+		if err := bind(r, target); err != nil {
+			return err
+		}
+	}
+
+	return validateStruct(target)
+}
+
+func decodeBody(r *http.Request, target any) error {
 	if r.ContentLength == 0 {
 		return terrors.PreconditionFailed("empty request body")
 	}
@@ -33,18 +82,31 @@ func BindBody[T any](r *http.Request, target *T) error {
 		return terrors.PreconditionFailed("invalid content type")
 	}
 
-	// We only support JSON for now.
-	if mediaType != MIMEApplicationJSON {
-		return terrors.PreconditionFailed("unsupported content type")
+	decoder, ok := decoderFor(mediaType)
+	if !ok {
+		return terrors.UnsupportedMediaType("unsupported content type")
 	}
 
-	// Decode the request body into the provided struct.
-	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
-		return terrors.PreconditionFailed("invalid json payload")
+	// Decode the request body into the provided struct, it is cut at the limit of the route.
+	if err := decoder.Decode(r, target); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return terrors.PayloadTooLarge(fmt.Sprintf("request body is larger than %d bytes", maxBytesErr.Limit))
+		}
+
+		var terr *terrors.Terror
+		if errors.As(err, &terr) {
+			return terr
+		}
+		return terrors.PreconditionFailed("invalid request body")
 	}
 
+	return nil
+}
+
+func validateStruct(target any) error {
 	// Validate the struct using the validator.
-	err = validate.Struct(target)
+	err := validate.Struct(target)
 	if err != nil {
 		var ve validator.ValidationErrors
 		if ok := errors.As(err, &ve); ok {
@@ -82,6 +144,8 @@ func msgForTag(tag string) string {
 		return "must be less than %s"
 	case "lte":
 		return "must be less than or equal to %s"
+	case "oneof":
+		return "must be one of the allowed values"
 	default:
 		return tag
 	}
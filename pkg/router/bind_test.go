@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -96,3 +99,161 @@ func TestBindJSONValidationFailed(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, len(err.Error()) > 0)
 }
+
+type itemStatus string
+
+type listItemsRequest struct {
+	Page     int           `query:"page" validate:"gte=1"`
+	Archived bool          `query:"archived"`
+	Since    time.Time     `query:"since"`
+	Until    *time.Time    `query:"until"`
+	Timeout  time.Duration `query:"timeout"`
+	Tags     []string      `query:"tag"`
+	IDs      []int64       `query:"id"`
+	Status   itemStatus    `query:"status" validate:"omitempty,oneof=active archived"`
+	Ignored  string
+}
+
+func TestBindQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/items?page=2&archived=true&since=2025-01-02T03:04:05Z&until=2025-02-01&timeout=1m30s&tag=a&tag=b&id=1&id=2&status=active&Ignored=x", nil)
+
+	var query listItemsRequest
+	err := BindQuery(req, &query)
+
+	assert.NoError(t, err)
+	until := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, listItemsRequest{
+		Page:     2,
+		Archived: true,
+		Since:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Until:    &until,
+		Timeout:  90 * time.Second,
+		Tags:     []string{"a", "b"},
+		IDs:      []int64{1, 2},
+		Status:   "active",
+	}, query)
+}
+
+func TestBindQueryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"not an int", "page=two", "page is invalid"},
+		{"not a bool", "page=1&archived=maybe", "archived is invalid"},
+		{"not a time", "page=1&since=yesterday", "since is invalid"},
+		{"one of the slice", "page=1&id=1&id=x", "id is invalid"},
+		{"validation", "page=0", "Page must be greater than or equal to"},
+		{"unknown enum", "page=1&status=deleted", "Status must be one of the allowed values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil)
+
+			var query listItemsRequest
+			err := BindQuery(req, &query)
+
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestBindPathAndHeaders(t *testing.T) {
+	type itemRequest struct {
+		ID      uint64   `path:"id"`
+		Slug    string   `path:"slug" validate:"required"`
+		IfMatch string   `header:"If-Match"`
+		Langs   []string `header:"accept-language"`
+	}
+
+	var bound itemRequest
+	mux := chi.NewRouter()
+	mux.Get("/items/{id}/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, BindPath(r, &bound))
+		assert.NoError(t, BindHeaders(r, &bound))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/items/42/hello%20world", nil)
+	req.Header.Set("If-Match", `"v1"`)
+	req.Header.Add("Accept-Language", "en")
+	req.Header.Add("Accept-Language", "fr")
+
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, itemRequest{ID: 42, Slug: "hello world", IfMatch: `"v1"`, Langs: []string{"en", "fr"}}, bound)
+}
+
+func TestBindPathUnescapesOnce(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/files/report%2541.pdf", expected: "report%41.pdf"},
+		{path: "/files/reports%2Fq1.pdf", expected: "reports/q1.pdf"},
+		{path: "/files/reports%2F100%25.pdf", expected: "reports/100%.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var bound struct {
+				Name string `path:"name"`
+			}
+			mux := chi.NewRouter()
+			mux.Get("/files/{name}", func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, BindPath(r, &bound))
+			})
+
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expected, bound.Name)
+		})
+	}
+}
+
+func TestBindRequest(t *testing.T) {
+	type updateItemRequest struct {
+		ID      string `path:"id" validate:"required"`
+		DryRun  bool   `query:"dryRun"`
+		IfMatch string `header:"If-Match" validate:"required"`
+		Name    string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		ifMatch  string
+		expected updateItemRequest
+		message  string
+	}{
+		{"every source", `{"name":"toci"}`, `"v1"`, updateItemRequest{ID: "42", DryRun: true, IfMatch: `"v1"`, Name: "toci"}, ""},
+		{"validated once filled", `{"name":"toci"}`, "", updateItemRequest{}, "IfMatch is required"},
+		{"no body", "", `"v1"`, updateItemRequest{}, "Name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bound updateItemRequest
+			var err error
+			mux := chi.NewRouter()
+			mux.Put("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+				err = BindRequest(r, &bound)
+			})
+			req := httptest.NewRequest(http.MethodPut, "/items/42?dryRun=true", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", MIMEApplicationJSON)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.message != "" {
+				assert.EqualError(t, err, tt.message)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, bound)
+		})
+	}
+}
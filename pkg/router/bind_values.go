@@ -0,0 +1,186 @@
+package router
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+func bindQuery(r *http.Request, target any) error {
+	query := r.URL.Query()
+
+	return bindValues(target, "query", func(name string) []string {
+		return query[name]
+	})
+}
+
+func bindPath(r *http.Request, target any) error {
+	rctx := chi.RouteContext(r.Context())
+
+	return bindValues(target, "path", func(name string) []string {
+		if rctx == nil {
+			return nil
+		}
+		value := rctx.URLParam(name)
+		if value == "" {
+			return nil
+		}
+		// The params are escaped only when chi routed on the raw path, i.e. the path holds escaped slashes
+		if r.URL.RawPath != "" {
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				value = unescaped
+			}
+		}
+		return []string{value}
+	})
+}
+
+func bindHeaders(r *http.Request, target any) error {
+	return bindValues(target, "header", r.Header.Values)
+}
+
+// bindValues sets the fields of the struct the target points to that are tagged with the tag, the fields of the
+// embedded structs included. A field missing from the values keeps its value. The slices take every value, e.g. of
+// a repeated query param.
+func bindValues(target any, tag string, lookup func(name string) []string) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return terrors.Unknown("the binding target must point to a struct")
+	}
+
+	return bindStruct(value.Elem(), tag, lookup)
+}
+
+func bindStruct(value reflect.Value, tag string, lookup func(name string) []string) error {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(value.Field(i), tag, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values := lookup(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setField(value.Field(i), values); err != nil {
+			return terrors.PreconditionFailed(fmt.Sprintf("%s is invalid", name))
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() != reflect.Slice || field.Type().Elem().Kind() == reflect.Uint8 {
+		return setValue(field, values[0])
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setValue(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+
+	return nil
+}
+
+// setValue converts the string to the type of the value. The enums are either string types, checked with the oneof
+// validation, or implement encoding.TextUnmarshaler.
+func setValue(value reflect.Value, s string) error {
+	switch value.Type() {
+	case timeType:
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(s))
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := setValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		value.Set(elem)
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// parseTime accepts RFC 3339 timestamps and dates
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, s)
+}
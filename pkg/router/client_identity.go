@@ -0,0 +1,67 @@
+package router
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/zeusito/toci/pkg/logger"
+)
+
+type ctxKeyClientIdentity int
+
+const clientIdentityKey ctxKeyClientIdentity = iota + 1
+
+// ClientIdentity the client authenticated by a certificate verified with mutual TLS
+type ClientIdentity struct {
+	// PrincipalID the first URI of the certificate, e.g. a SPIFFE ID, its common name otherwise
+	PrincipalID  string
+	CommonName   string
+	URIs         []string
+	DNSNames     []string
+	SerialNumber string
+	Certificate  *x509.Certificate
+}
+
+// ClientIdentityFromContext returns the identity of the client certificate, there is none without mutual TLS
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey).(*ClientIdentity)
+	return identity, ok
+}
+
+// ClientCertificate is a middleware exposing the verified client certificate of the connection as a principal. It
+// must run after logger.RequestLogger so the principal is logged.
+func ClientCertificate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// only the chains verified against the client CAs are trusted, not the peer certificates
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := newClientIdentity(r.TLS.VerifiedChains[0][0])
+		ctx := context.WithValue(r.Context(), clientIdentityKey, identity)
+		ctx = logger.WithPrincipal(ctx, identity.PrincipalID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newClientIdentity(certificate *x509.Certificate) *ClientIdentity {
+	identity := &ClientIdentity{
+		PrincipalID:  certificate.Subject.CommonName,
+		CommonName:   certificate.Subject.CommonName,
+		DNSNames:     certificate.DNSNames,
+		SerialNumber: certificate.SerialNumber.String(),
+		Certificate:  certificate,
+	}
+
+	for _, uri := range certificate.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	if len(identity.URIs) > 0 {
+		identity.PrincipalID = identity.URIs[0]
+	}
+
+	return identity
+}
@@ -0,0 +1,184 @@
+package router
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+const (
+	MIMEApplicationJSON    = "application/json"
+	MIMEApplicationForm    = "application/x-www-form-urlencoded"
+	MIMEMultipartForm      = "multipart/form-data"
+	MIMEApplicationMsgPack = "application/msgpack"
+)
+
+// Decoder decodes the body of a request into the target, a malformed body is a terror
+type Decoder interface {
+	Decode(r *http.Request, target any) error
+}
+
+// Encoder encodes the payload of a response
+type Encoder interface {
+	Encode(w io.Writer, payload any) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	decoders = map[string]Decoder{
+		MIMEApplicationJSON:    jsonCodec{},
+		MIMEApplicationMsgPack: msgpackCodec{},
+		MIMEApplicationForm:    formDecoder{},
+		MIMEMultipartForm:      NewMultipartDecoder(0, 0, 0),
+	}
+	encoders = map[string]Encoder{
+		MIMEApplicationJSON:    jsonCodec{},
+		MIMEApplicationMsgPack: msgpackCodec{},
+	}
+	// the first encoder is the default one, e.g. when the client accepts anything
+	encoderMediaTypes = []string{MIMEApplicationJSON, MIMEApplicationMsgPack}
+)
+
+// RegisterDecoder decodes the request bodies of the media type with the decoder, it replaces the decoder of the
+// media type if any. It must be called before the server starts.
+func RegisterDecoder(mediaType string, decoder Decoder) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	decoders[mediaType] = decoder
+}
+
+// RegisterEncoder renders the responses of the media type with the encoder, when the client accepts it. It must be
+// called before the server starts.
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if _, ok := encoders[mediaType]; !ok {
+		encoderMediaTypes = append(encoderMediaTypes, mediaType)
+	}
+	encoders[mediaType] = encoder
+}
+
+func decoderFor(mediaType string) (Decoder, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	decoder, ok := decoders[mediaType]
+	return decoder, ok
+}
+
+// negotiateEncoder returns the encoder of the media type the client prefers, following the quality values of its
+// Accept header. JSON is the default.
+func negotiateEncoder(accept string) (string, Encoder, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	if strings.TrimSpace(accept) == "" {
+		return encoderMediaTypes[0], encoders[encoderMediaTypes[0]], true
+	}
+
+	type acceptedRange struct {
+		mediaType string
+		quality   float64
+	}
+	var ranges []acceptedRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, acceptedRange{mediaType: mediaType, quality: quality})
+		}
+	}
+	// the ranges of equal quality keep the order of the header
+	slices.SortStableFunc(ranges, func(a, b acceptedRange) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, r := range ranges {
+		for _, mediaType := range encoderMediaTypes {
+			if matchesMediaRange(mediaType, r.mediaType) {
+				return mediaType, encoders[mediaType], true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+func matchesMediaRange(mediaType, mediaRange string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(r *http.Request, target any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return terrors.PreconditionFailed("invalid json payload")
+	}
+
+	return nil
+}
+
+func (jsonCodec) Encode(w io.Writer, payload any) error {
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// msgpackCodec the fields are named by their json tags, so that the same models serve both
+type msgpackCodec struct{}
+
+func (msgpackCodec) Decode(r *http.Request, target any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(target); err != nil {
+		return terrors.PreconditionFailed("invalid msgpack payload")
+	}
+
+	return nil
+}
+
+func (msgpackCodec) Encode(w io.Writer, payload any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+
+	return encoder.Encode(payload)
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/schema"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+const (
+	// the parts of a multipart body held in memory, the larger ones are written to temporary files
+	defaultMultipartMaxMemory = 32 << 20
+	defaultMultipartMaxFiles  = 10
+)
+
+var (
+	fileHeaderType  = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeadersType = reflect.TypeFor[[]*multipart.FileHeader]()
+)
+
+// newValuesDecoder decodes the values of the fields named by the tag, converting them to the types of the fields
+func newValuesDecoder(tag string) *schema.Decoder {
+	decoder := schema.NewDecoder()
+	decoder.SetAliasTag(tag)
+	decoder.IgnoreUnknownKeys(true)
+
+	return decoder
+}
+
+// use a single instance of the form decoder, it caches struct info
+var formValuesDecoder = newValuesDecoder("form")
+
+// formDecoder decodes URL encoded forms, the fields are named by their form tags
+type formDecoder struct{}
+
+func (formDecoder) Decode(r *http.Request, target any) error {
+	if err := r.ParseForm(); err != nil {
+		return formError(err)
+	}
+
+	if err := formValuesDecoder.Decode(target, r.PostForm); err != nil {
+		return terrors.PreconditionFailed("invalid form payload")
+	}
+
+	return nil
+}
+
+// MultipartDecoder decodes multipart forms, the values like URL encoded forms and the files into the fields of type
+// *multipart.FileHeader or []*multipart.FileHeader named by their form tags
+type MultipartDecoder struct {
+	maxMemory    int64
+	maxFileBytes int64
+	maxFiles     int
+}
+
+// NewMultipartDecoder a zero maxMemory or maxFiles takes the default, a zero maxFileBytes leaves the files limited by
+// the body limit only
+func NewMultipartDecoder(maxMemory, maxFileBytes int64, maxFiles int) *MultipartDecoder {
+	if maxMemory <= 0 {
+		maxMemory = defaultMultipartMaxMemory
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultMultipartMaxFiles
+	}
+
+	return &MultipartDecoder{maxMemory: maxMemory, maxFileBytes: maxFileBytes, maxFiles: maxFiles}
+}
+
+func (d *MultipartDecoder) Decode(r *http.Request, target any) error {
+	if err := r.ParseMultipartForm(d.maxMemory); err != nil {
+		return formError(err)
+	}
+
+	files := 0
+	for _, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			files++
+			if files > d.maxFiles {
+				return terrors.PayloadTooLarge(fmt.Sprintf("more than %d files", d.maxFiles))
+			}
+			if d.maxFileBytes > 0 && header.Size > d.maxFileBytes {
+				return terrors.PayloadTooLarge(fmt.Sprintf("file %s is larger than %d bytes", header.Filename, d.maxFileBytes))
+			}
+		}
+	}
+
+	if err := formValuesDecoder.Decode(target, r.MultipartForm.Value); err != nil {
+		return terrors.PreconditionFailed("invalid multipart payload")
+	}
+
+	bindFiles(reflect.ValueOf(target), r.MultipartForm.File)
+
+	return nil
+}
+
+// bindFiles sets the file fields of the struct the target points to
+func bindFiles(target reflect.Value, files map[string][]*multipart.FileHeader) {
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return
+	}
+	target = target.Elem()
+
+	for i := range target.NumField() {
+		field := target.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" || len(files[name]) == 0 {
+			continue
+		}
+
+		switch field.Type {
+		case fileHeaderType:
+			target.Field(i).Set(reflect.ValueOf(files[name][0]))
+		case fileHeadersType:
+			target.Field(i).Set(reflect.ValueOf(files[name]))
+		}
+	}
+}
+
+// formError the bodies cut at the limit of the route are a 413, the malformed ones a 400
+func formError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+
+	return terrors.PreconditionFailed("invalid form payload")
+}
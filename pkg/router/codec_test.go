@@ -0,0 +1,220 @@
+package router
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+type codecPayload struct {
+	Name  string   `json:"name" form:"name" validate:"required"`
+	Count int      `json:"count" form:"count"`
+	Tags  []string `json:"tags" form:"tag"`
+}
+
+type uploadPayload struct {
+	Title       string                  `form:"title" validate:"required"`
+	Avatar      *multipart.FileHeader   `form:"avatar"`
+	Attachments []*multipart.FileHeader `form:"attachment"`
+}
+
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string][]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range values {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	for name, contents := range files {
+		for i, content := range contents {
+			part, err := writer.CreateFormFile(name, name+string(rune('a'+i))+".txt")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/test", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func TestBindForm(t *testing.T) {
+	form := url.Values{"name": {"toci"}, "count": {"3"}, "tag": {"a", "b"}, "unknown": {"x"}}
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", MIMEApplicationForm)
+
+	var payload codecPayload
+	require.NoError(t, BindBody(req, &payload))
+
+	assert.Equal(t, codecPayload{Name: "toci", Count: 3, Tags: []string{"a", "b"}}, payload)
+}
+
+func TestBindFormInvalid(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("name=toci&count=three"))
+	req.Header.Set("Content-Type", MIMEApplicationForm)
+
+	var payload codecPayload
+	err := BindBody(req, &payload)
+
+	assert.EqualError(t, err, "invalid form payload")
+}
+
+func TestBindMsgPack(t *testing.T) {
+	body, err := msgpack.Marshal(map[string]any{"name": "toci", "count": 3})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MIMEApplicationMsgPack)
+
+	var payload codecPayload
+	require.NoError(t, BindBody(req, &payload))
+
+	assert.Equal(t, codecPayload{Name: "toci", Count: 3}, payload)
+}
+
+func TestBindMultipart(t *testing.T) {
+	req := newMultipartRequest(t, map[string]string{"title": "files"},
+		map[string][]string{"avatar": {"face"}, "attachment": {"one", "two"}})
+
+	var payload uploadPayload
+	require.NoError(t, BindBody(req, &payload))
+
+	assert.Equal(t, "files", payload.Title)
+	require.NotNil(t, payload.Avatar)
+	assert.EqualValues(t, 4, payload.Avatar.Size)
+	require.Len(t, payload.Attachments, 2)
+	file, err := payload.Attachments[1].Open()
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(content))
+}
+
+func TestBindMultipartLimits(t *testing.T) {
+	decoder := NewMultipartDecoder(0, 4, 2)
+
+	tests := []struct {
+		name    string
+		files   map[string][]string
+		message string
+	}{
+		{"file too large", map[string][]string{"avatar": {"a large face"}}, "file avatara.txt is larger than 4 bytes"},
+		{"too many files", map[string][]string{"attachment": {"1", "2", "3"}}, "more than 2 files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newMultipartRequest(t, map[string]string{"title": "files"}, tt.files)
+
+			var payload uploadPayload
+			err := decoder.Decode(req, &payload)
+
+			var terr *terrors.Terror
+			require.ErrorAs(t, err, &terr)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, terr.HttpStatusCode)
+			assert.Equal(t, tt.message, terr.ErrMessage)
+		})
+	}
+}
+
+type textDecoder struct{}
+
+func (textDecoder) Decode(r *http.Request, target any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	target.(*codecPayload).Name = string(body)
+	return nil
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder("text/plain", textDecoder{})
+	t.Cleanup(func() {
+		codecsMu.Lock()
+		delete(decoders, "text/plain")
+		codecsMu.Unlock()
+	})
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("toci"))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	var payload codecPayload
+	require.NoError(t, BindBody(req, &payload))
+
+	assert.Equal(t, "toci", payload.Name)
+}
+
+func TestRender(t *testing.T) {
+	payload := codecPayload{Name: "toci", Count: 3}
+
+	tests := []struct {
+		name      string
+		accept    string
+		status    int
+		mediaType string
+	}{
+		{"no accept header", "", http.StatusCreated, MIMEApplicationJSON},
+		{"any media type", "*/*", http.StatusCreated, MIMEApplicationJSON},
+		{"msgpack", MIMEApplicationMsgPack, http.StatusCreated, MIMEApplicationMsgPack},
+		{"by quality", "application/json;q=0.5, application/msgpack", http.StatusCreated, MIMEApplicationMsgPack},
+		{"by range", "text/html, application/*;q=0.8", http.StatusCreated, MIMEApplicationJSON},
+		{"none acceptable", "text/html, application/json;q=0", http.StatusNotAcceptable, MIMEApplicationJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			Render(rec, req, http.StatusCreated, payload)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.mediaType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			var rendered codecPayload
+			if tt.mediaType == MIMEApplicationMsgPack {
+				decoder := msgpack.NewDecoder(rec.Body)
+				decoder.SetCustomStructTag("json")
+				require.NoError(t, decoder.Decode(&rendered))
+			} else {
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rendered))
+			}
+			assert.Equal(t, payload, rendered)
+		})
+	}
+}
+
+func TestRenderErrorFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept", MIMEApplicationMsgPack)
+	rec := httptest.NewRecorder()
+
+	RenderErrorFor(rec, req, terrors.RecordNotFound("resource not found"))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, MIMEApplicationMsgPack, rec.Header().Get("Content-Type"))
+
+	var rendered map[string]string
+	require.NoError(t, msgpack.NewDecoder(rec.Body).Decode(&rendered))
+	assert.Equal(t, map[string]string{"code": "RecordNotFound", "message": "resource not found"}, rendered)
+}
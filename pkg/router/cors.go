@@ -0,0 +1,21 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/cors"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// CORS answers the preflight requests of the allowed origins and adds the CORS headers to their requests, it must
+// run before the routing and the authentication since the preflight requests carry no credentials
+func CORS(cfg config.CORSConfigurations) func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
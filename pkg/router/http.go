@@ -2,11 +2,15 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/zeusito/toci/pkg/audit"
 	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/logger"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -14,44 +18,119 @@ import (
 )
 
 type HTTPRouter struct {
-	Mux *chi.Mux
-	srv *http.Server
+	Mux    *chi.Mux
+	srv    *http.Server
+	tlsCfg config.TLSConfigurations
+	// the longest a request may run, routes overrides included
+	maxRequestTimeout time.Duration
 }
 
 func NewHTTPRouter(cfgs config.ServerConfigurations) *HTTPRouter {
 	router := chi.NewRouter()
 
+	// The bodies are decoded by their content type, see BindBody
+	RegisterDecoder(MIMEMultipartForm, NewMultipartDecoder(cfgs.Multipart.MaxMemory, cfgs.Multipart.MaxFileBytes, cfgs.Multipart.MaxFiles))
+
 	// A good base middleware stack
-	router.Use(middleware.AllowContentType("application/json"))
+	if cfgs.CORS.Enabled {
+		router.Use(CORS(cfgs.CORS))
+	}
+	if cfgs.SecurityHeaders.Enabled {
+		router.Use(SecurityHeaders(cfgs.SecurityHeaders))
+	}
 	router.Use(middleware.RequestID)
+	router.Use(Tracing)
+	router.Use(logger.RequestLogger)
+	router.Use(ClientCertificate)
 	router.Use(middleware.RealIP)
+	if cfgs.AccessLog.Enabled {
+		router.Use(AccessLog(cfgs.AccessLog))
+	}
+	router.Use(Metrics)
+	router.Use(audit.CaptureRequest)
 	router.Use(middleware.Recoverer)
-
-	// Set a timeout value on the request models (ctx), that will signal
-	// through ctx.Done() that the request has timed out and further
-	// processing should be stopped.
-	router.Use(middleware.Timeout(20 * time.Second))
+	router.Use(Limits(cfgs))
 
 	// Customizing the server
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfgs.Port),
-		Handler:      router,
-		ReadTimeout:  20 * time.Second,
-		WriteTimeout: 20 * time.Second,
+		Addr:              fmt.Sprintf(":%s", cfgs.Port),
+		Handler:           router,
+		ReadTimeout:       cfgs.ReadTimeout,
+		ReadHeaderTimeout: cfgs.ReadHeaderTimeout,
+		WriteTimeout:      cfgs.WriteTimeout,
+		IdleTimeout:       cfgs.IdleTimeout,
+		MaxHeaderBytes:    cfgs.MaxHeaderBytes,
+	}
+
+	maxRequestTimeout := cfgs.RequestTimeout
+	for _, route := range cfgs.Routes {
+		maxRequestTimeout = max(maxRequestTimeout, route.RequestTimeout)
 	}
 
 	return &HTTPRouter{
-		Mux: router,
-		srv: srv,
+		Mux:               router,
+		srv:               srv,
+		tlsCfg:            cfgs.TLS,
+		maxRequestTimeout: maxRequestTimeout,
+	}
+}
+
+// ShutdownTimeout is the time Shutdown needs to drain the requests still within their timeout, zero when the
+// requests have none
+func (s *HTTPRouter) ShutdownTimeout() time.Duration {
+	if s.maxRequestTimeout == 0 {
+		return 0
 	}
+
+	// the timed out requests still write their 504
+	return s.maxRequestTimeout + time.Second
 }
 
-func (s *HTTPRouter) Start() {
+// Start serves the requests until the server is shut down, it returns the error that stopped it otherwise, e.g. when
+// the port is already in use or the certificates cannot be loaded
+func (s *HTTPRouter) Start() error {
+	listener, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+
 	log.Info().Msgf("Server listening on port %s", s.srv.Addr)
-	_ = s.srv.ListenAndServe()
+
+	return s.serve(listener)
+}
+
+func (s *HTTPRouter) serve(listener net.Listener) error {
+	if !s.tlsCfg.Enabled {
+		err := s.srv.Serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+
+	reloader, err := newCertificateReloader(s.tlsCfg)
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	// the files are watched until the server is shut down
+	defer func() { _ = reloader.Close() }()
+
+	s.srv.TLSConfig, err = reloader.tlsConfig()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	err = s.srv.ServeTLS(listener, "", "")
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func (s *HTTPRouter) Shutdown(ctx context.Context) {
+// Shutdown stops accepting connections and waits for the in-flight requests
+func (s *HTTPRouter) Shutdown(ctx context.Context) error {
 	log.Info().Msg("Server shutting down...")
-	_ = s.srv.Shutdown(ctx)
+	return s.srv.Shutdown(ctx)
 }
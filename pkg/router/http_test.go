@@ -0,0 +1,20 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+func TestShutdownTimeoutCoversTheLongestRequest(t *testing.T) {
+	assert.Zero(t, NewHTTPRouter(config.ServerConfigurations{}).ShutdownTimeout(), "requests without a timeout")
+
+	myRouter := NewHTTPRouter(config.ServerConfigurations{
+		RequestTimeout: 20 * time.Second,
+		Routes:         []config.RouteConfigurations{{Pattern: "/v1/identities/{id}/avatar", RequestTimeout: 2 * time.Minute}},
+	})
+
+	assert.Equal(t, 2*time.Minute+time.Second, myRouter.ShutdownTimeout())
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/terrors"
+)
+
+// requestLimits applied to a request, either the defaults of the server or the override of its route
+type requestLimits struct {
+	timeout      time.Duration
+	maxBodyBytes int64
+	// the connection deadlines are extended to the timeout of an override
+	extendDeadlines bool
+	handler         http.Handler
+}
+
+// Limits cancels the context of the requests after the request timeout and rejects bodies larger than the limit
+// with a 413, before they are decoded. The routes are matched against the overrides by their pattern, so that it can
+// be used before the routing.
+func Limits(cfgs config.ServerConfigurations) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		defaults := newRequestLimits(next, cfgs.RequestTimeout, cfgs.MaxBodyBytes, false)
+
+		overrides := make([]requestLimits, len(cfgs.Routes))
+		for i, route := range cfgs.Routes {
+			timeout := route.RequestTimeout
+			if timeout == 0 {
+				timeout = cfgs.RequestTimeout
+			}
+			maxBodyBytes := route.MaxBodyBytes
+			if maxBodyBytes == 0 {
+				maxBodyBytes = cfgs.MaxBodyBytes
+			}
+			overrides[i] = newRequestLimits(next, timeout, maxBodyBytes, route.RequestTimeout > 0)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limits := defaults
+			if i := matchRoute(cfgs.Routes, r); i >= 0 {
+				limits = overrides[i]
+			}
+
+			if limits.maxBodyBytes > 0 {
+				if r.ContentLength > limits.maxBodyBytes {
+					RenderError(r.Context(), w, terrors.PayloadTooLarge(fmt.Sprintf("request body is larger than %d bytes", limits.maxBodyBytes)))
+					return
+				}
+				// The content length may be unknown, e.g. chunked bodies
+				r.Body = http.MaxBytesReader(w, r.Body, limits.maxBodyBytes)
+			}
+
+			if limits.extendDeadlines {
+				// Not supported by every writer, e.g. in tests, the server timeouts apply then
+				deadline := time.Now().Add(limits.timeout)
+				rc := http.NewResponseController(w)
+				_ = rc.SetReadDeadline(deadline)
+				_ = rc.SetWriteDeadline(deadline)
+			}
+
+			limits.handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newRequestLimits(next http.Handler, timeout time.Duration, maxBodyBytes int64, extendDeadlines bool) requestLimits {
+	handler := next
+	if timeout > 0 {
+		// Set a timeout value on the request models (ctx), that will signal
+		// through ctx.Done() that the request has timed out and further
+		// processing should be stopped.
+		handler = middleware.Timeout(timeout)(next)
+	}
+
+	return requestLimits{
+		timeout:         timeout,
+		maxBodyBytes:    maxBodyBytes,
+		extendDeadlines: extendDeadlines,
+		handler:         handler,
+	}
+}
+
+// matchRoute returns the index of the override of the route of the request, -1 when there is none
+func matchRoute(routes []config.RouteConfigurations, r *http.Request) int {
+	if len(routes) == 0 {
+		return -1
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return -1
+	}
+
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	if pattern == "" {
+		return -1
+	}
+
+	for i, route := range routes {
+		if route.Pattern == pattern && (route.Method == "" || route.Method == r.Method) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+func newLimitsRouter(cfgs config.ServerConfigurations) *chi.Mux {
+	mux := chi.NewRouter()
+	mux.Use(Limits(cfgs))
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	bind := func(w http.ResponseWriter, r *http.Request) {
+		var body payload
+		if err := BindBody(r, &body); err != nil {
+			RenderError(r.Context(), w, err)
+			return
+		}
+		_, _ = w.Write([]byte(body.Name))
+	}
+	mux.Post("/v1/items", bind)
+	mux.Post("/v1/items/{id}/upload", bind)
+	mux.Get("/v1/slow", func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		_, _ = w.Write([]byte(time.Until(deadline).Round(time.Minute).String()))
+	})
+
+	return mux
+}
+
+func post(mux http.Handler, path string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	if req.ContentLength == 0 {
+		// unknown, e.g. chunked
+		req.ContentLength = -1
+	}
+	req.Header.Set("Content-Type", MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestLimitsRejectsLargeBodies(t *testing.T) {
+	mux := newLimitsRouter(config.ServerConfigurations{MaxBodyBytes: 16})
+
+	tests := []struct {
+		name string
+		body io.Reader
+	}{
+		{"by content length", strings.NewReader(`{"name":"a name that is too long"}`)},
+		// no content length, the body is cut while it is decoded
+		{"while decoding", io.MultiReader(strings.NewReader(`{"name":"a name that is too long"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(mux, "/v1/items", tt.body)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "PayloadTooLarge", body["code"])
+		})
+	}
+}
+
+func TestLimitsAcceptsBodiesWithinTheLimit(t *testing.T) {
+	mux := newLimitsRouter(config.ServerConfigurations{MaxBodyBytes: 16})
+
+	rec := post(mux, "/v1/items", strings.NewReader(`{"name":"ok"}`))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
+
+func TestLimitsRouteOverrides(t *testing.T) {
+	mux := newLimitsRouter(config.ServerConfigurations{
+		RequestTimeout: time.Minute,
+		MaxBodyBytes:   16,
+		Routes: []config.RouteConfigurations{
+			{Method: http.MethodPost, Pattern: "/v1/items/{id}/upload", MaxBodyBytes: 1024},
+			{Pattern: "/v1/slow", RequestTimeout: 5 * time.Minute},
+		},
+	})
+
+	rec := post(mux, "/v1/items/42/upload", strings.NewReader(`{"name":"a name that is too long"}`))
+	assert.Equal(t, http.StatusOK, rec.Code, "expected the limit of the route to apply")
+
+	rec = post(mux, "/v1/items", strings.NewReader(`{"name":"a name that is too long"}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "expected the default limit to apply")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/slow", nil))
+	assert.Equal(t, "5m0s", rec.Body.String(), "expected the timeout of the route to apply")
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/zeusito/toci/pkg/metrics"
+)
+
+// unmatchedRoute labels the requests no route matched, so unknown paths do not create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics is a middleware observing the duration of the requests by method, chi route pattern and status.
+// It must run before middleware.Recoverer so panics are observed with their 500 status.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			metrics.HTTPRequestDuration.
+				WithLabelValues(r.Method, route, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/metrics"
+)
+
+func TestMetricsObservesRoutePatternAndStatus(t *testing.T) {
+	mux := chi.NewRouter()
+	mux.Use(Metrics)
+	mux.Use(middleware.Recoverer)
+	mux.Get("/v1/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Get("/v1/metrics-test/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/v1/metrics-test/1", "/v1/metrics-test/2", "/v1/metrics-test/panic", "/v1/unknown/42"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	count := func(route, status string) uint64 {
+		var m dto.Metric
+		histogram := metrics.HTTPRequestDuration.WithLabelValues(http.MethodGet, route, status).(prometheus.Histogram)
+		require.NoError(t, histogram.Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	assert.EqualValues(t, 2, count("/v1/metrics-test/{id}", "200"), "expected one series per route pattern")
+	assert.EqualValues(t, 1, count("/v1/metrics-test/panic", "500"))
+	assert.EqualValues(t, 1, count(unmatchedRoute, "404"), "expected unknown paths to share one series")
+}
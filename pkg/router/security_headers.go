@@ -0,0 +1,37 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// SecurityHeaders adds the headers hardening the responses against sniffing, framing and downgrades
+func SecurityHeaders(cfg config.SecurityHeadersConfigurations) func(http.Handler) http.Handler {
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+		"X-Frame-Options":         cfg.FrameOptions,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+	}
+	if cfg.HSTSMaxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				if value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
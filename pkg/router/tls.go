@@ -0,0 +1,178 @@
+package router
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// Client authentication modes of mutual TLS
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certificateReloader serves the latest valid certificate and client CAs, reloaded when their files change. A
+// change that cannot be loaded, e.g. a certificate written before its key, keeps the previous ones.
+type certificateReloader struct {
+	cfg         config.TLSConfigurations
+	certificate atomic.Pointer[tls.Certificate]
+	clientCAs   atomic.Pointer[x509.CertPool]
+	watcher     *fsnotify.Watcher
+	done        chan struct{}
+}
+
+func newCertificateReloader(cfg config.TLSConfigurations) (*certificateReloader, error) {
+	r := &certificateReloader{cfg: cfg, done: make(chan struct{})}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// the directories are watched since the files may be replaced, e.g. the symlinks of a Kubernetes secret
+	for _, dir := range r.directories() {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	r.watcher = watcher
+
+	go r.watch()
+
+	return r, nil
+}
+
+func (r *certificateReloader) reload() error {
+	certificate, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the certificate: %w", err)
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		bundle, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the client CA bundle: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return errors.New("client CA bundle contains no certificate")
+		}
+		r.clientCAs.Store(pool)
+	}
+
+	r.certificate.Store(&certificate)
+
+	return nil
+}
+
+func (r *certificateReloader) directories() []string {
+	dirs := map[string]struct{}{}
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		result = append(result, dir)
+	}
+
+	return result
+}
+
+func (r *certificateReloader) watch() {
+	defer close(r.done)
+
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+
+			if err := r.reload(); err != nil {
+				log.Warn().Err(err).Msg("failed to reload the TLS certificates, the previous ones are kept")
+				continue
+			}
+			log.Info().Msgf("reloaded the TLS certificates after a change of %s", event.Name)
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn().Err(err).Msg("failed to watch the TLS certificates")
+		}
+	}
+}
+
+// Close stops watching the files
+func (r *certificateReloader) Close() error {
+	err := r.watcher.Close()
+	<-r.done
+
+	return err
+}
+
+func (r *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate.Load(), nil
+}
+
+// tlsConfig the TLS configuration of the server, offering HTTP/2
+func (r *certificateReloader) tlsConfig() (*tls.Config, error) {
+	minVersion, ok := tlsVersions[r.cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS version %q", r.cfg.MinVersion)
+	}
+
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.getCertificate,
+	}
+
+	if r.cfg.ClientCAFile == "" {
+		return base, nil
+	}
+
+	switch r.cfg.ClientAuth {
+	case "", ClientAuthRequire:
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	case ClientAuthOptional:
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unsupported client authentication %q", r.cfg.ClientAuth)
+	}
+
+	// each handshake verifies the client against the latest CA bundle
+	return &tls.Config{
+		MinVersion: minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			handshake := base.Clone()
+			handshake.ClientCAs = r.clientCAs.Load()
+			return handshake, nil
+		},
+	}, nil
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// testCA issues the certificates of the tests
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{certificate: certificate, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf
+func (ca *testCA) issue(t *testing.T, serial int64, template *x509.Certificate) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) writeServerCertificate(t *testing.T, dir string, serial int64) {
+	certPEM, keyPEM := ca.issue(t, serial, &x509.Certificate{
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.key"), keyPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.crt"), certPEM, 0o600))
+}
+
+func (ca *testCA) clientCertificate(t *testing.T, template *x509.Certificate) tls.Certificate {
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	certPEM, keyPEM := ca.issue(t, 100, template)
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return certificate
+}
+
+// startTLSRouter serves a router with the TLS configuration on a random port, the handler echoes the principal
+func startTLSRouter(t *testing.T, ca *testCA, tlsCfg config.TLSConfigurations) (string, string) {
+	dir := t.TempDir()
+	ca.writeServerCertificate(t, dir, 2)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca.pem, 0o600))
+
+	tlsCfg.Enabled = true
+	tlsCfg.CertFile = filepath.Join(dir, "server.crt")
+	tlsCfg.KeyFile = filepath.Join(dir, "server.key")
+	if tlsCfg.ClientCAFile != "" {
+		tlsCfg.ClientCAFile = filepath.Join(dir, "ca.crt")
+	}
+
+	mux := chi.NewRouter()
+	mux.Use(ClientCertificate)
+	mux.Get("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := ClientIdentityFromContext(r.Context())
+		if !ok {
+			_, _ = w.Write([]byte("anonymous"))
+			return
+		}
+		_, _ = w.Write([]byte(identity.PrincipalID))
+	})
+
+	router := &HTTPRouter{Mux: mux, srv: &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}, tlsCfg: tlsCfg}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() { served <- router.serve(listener) }()
+	t.Cleanup(func() {
+		require.NoError(t, router.Shutdown(context.Background()))
+		require.NoError(t, <-served)
+	})
+
+	return "https://" + listener.Addr().String(), dir
+}
+
+func newTLSClient(ca *testCA, clientCertificates ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: clientCertificates},
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}}
+}
+
+func TestTLSServesHTTP2(t *testing.T) {
+	ca := newTestCA(t)
+	baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{})
+
+	resp, err := newTLSClient(ca).Get(baseURL + "/whoami")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor, "expected HTTP/2 to be negotiated")
+}
+
+func TestTLSRejectsVersionsBelowMinimum(t *testing.T) {
+	ca := newTestCA(t)
+	baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{MinVersion: "1.3"})
+
+	client := newTLSClient(ca)
+	client.Transport.(*http.Transport).TLSClientConfig.MaxVersion = tls.VersionTLS12
+
+	_, err := client.Get(baseURL + "/whoami")
+
+	assert.Error(t, err)
+}
+
+func TestTLSReloadsCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	baseURL, dir := startTLSRouter(t, ca, config.TLSConfigurations{})
+
+	serial := func() int64 {
+		resp, err := newTLSClient(ca).Get(baseURL + "/whoami")
+		if err != nil {
+			return 0
+		}
+		_ = resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+	require.EqualValues(t, 2, serial())
+
+	ca.writeServerCertificate(t, dir, 3)
+
+	assert.Eventually(t, func() bool { return serial() == 3 }, 2*time.Second, 10*time.Millisecond,
+		"expected the renewed certificate to be served")
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	spiffeID, _ := url.Parse("spiffe://example.org/billing")
+
+	t.Run("rejects clients without a certificate", func(t *testing.T) {
+		baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{ClientCAFile: "ca.crt"})
+
+		_, err := newTLSClient(ca).Get(baseURL + "/whoami")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects certificates of another CA", func(t *testing.T) {
+		baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{ClientCAFile: "ca.crt"})
+		other := newTestCA(t).clientCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "intruder"}})
+
+		_, err := newTLSClient(ca, other).Get(baseURL + "/whoami")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("exposes the client identity as the principal", func(t *testing.T) {
+		baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{ClientCAFile: "ca.crt"})
+		tests := []struct {
+			name        string
+			certificate *x509.Certificate
+			expected    string
+		}{
+			{"by URI", &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, URIs: []*url.URL{spiffeID}}, spiffeID.String()},
+			{"by common name", &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}, "billing"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp, err := newTLSClient(ca, ca.clientCertificate(t, tt.certificate)).Get(baseURL + "/whoami")
+				require.NoError(t, err)
+				defer func() { _ = resp.Body.Close() }()
+
+				body := make([]byte, 128)
+				n, _ := resp.Body.Read(body)
+				assert.Equal(t, tt.expected, string(body[:n]))
+			})
+		}
+	})
+
+	t.Run("serves clients without a certificate when optional", func(t *testing.T) {
+		baseURL, _ := startTLSRouter(t, ca, config.TLSConfigurations{ClientCAFile: "ca.crt", ClientAuth: ClientAuthOptional})
+
+		resp, err := newTLSClient(ca).Get(baseURL + "/whoami")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body := make([]byte, 128)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, "anonymous", string(body[:n]))
+	})
+}
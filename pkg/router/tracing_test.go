@@ -0,0 +1,96 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func newTracingRouter(t *testing.T) (*chi.Mux, *tracetest.InMemoryExporter, *bytes.Buffer) {
+	exporter := tracingtest.Exporter(t)
+
+	var out bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&out)
+	t.Cleanup(func() { log.Logger = previous })
+
+	mux := chi.NewRouter()
+	mux.Use(middleware.RequestID)
+	mux.Use(Tracing)
+	mux.Use(logger.RequestLogger)
+	mux.Use(middleware.Recoverer)
+
+	mux.Get("/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.Ctx(r.Context()).Info().Msg("handled")
+	})
+	mux.Get("/v1/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	return mux, exporter, &out
+}
+
+func TestTracingContinuesTraceparent(t *testing.T) {
+	mux, exporter, out := newTracingRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/items/42", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /v1/items/{id}", span.Name, "expected the span to be named after the route pattern")
+	assert.Equal(t, parentTraceID, span.SpanContext.TraceID().String())
+	assert.Equal(t, parentSpanID, span.Parent.SpanID().String())
+	assert.True(t, span.Parent.IsRemote())
+	assert.Contains(t, span.Attributes, semconv.HTTPRoute("/v1/items/{id}"))
+	assert.Contains(t, span.Attributes, semconv.HTTPResponseStatusCode(http.StatusOK))
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, parentTraceID, line[logger.TraceIDField], "expected the log lines to be correlated with the trace")
+	assert.Equal(t, span.SpanContext.SpanID().String(), line[logger.SpanIDField])
+}
+
+func TestTracingStartsTraceWithoutTraceparent(t *testing.T) {
+	mux, exporter, _ := newTracingRouter(t)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/items/42", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.True(t, spans[0].SpanContext.TraceID().IsValid())
+	assert.False(t, spans[0].Parent.IsValid())
+}
+
+func TestTracingRecordsServerErrors(t *testing.T) {
+	mux, exporter, _ := newTracingRouter(t)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/panic", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+}
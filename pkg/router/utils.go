@@ -1,43 +1,73 @@
 package router
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net/http"
 
 	"github.com/zeusito/toci/pkg/terrors"
 
-	"github.com/goccy/go-json"
-
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 // RenderJSON is a helper function to write a JSON response
 func RenderJSON(ctx context.Context, w http.ResponseWriter, httpStatusCode int, payload any) {
+	render(ctx, w, httpStatusCode, MIMEApplicationJSON, jsonCodec{}, payload)
+}
+
+// Render writes the response in the media type the client prefers among the registered encoders, following its
+// Accept header. A client accepting none of them gets a 406.
+func Render(w http.ResponseWriter, r *http.Request, httpStatusCode int, payload any) {
+	w.Header().Add("Vary", "Accept")
+
+	mediaType, encoder, ok := negotiateEncoder(r.Header.Get("Accept"))
+	if !ok {
+		RenderError(r.Context(), w, terrors.NotAcceptable("none of the accepted media types can be rendered"))
+		return
+	}
+
+	render(r.Context(), w, httpStatusCode, mediaType, encoder, payload)
+}
+
+func render(ctx context.Context, w http.ResponseWriter, httpStatusCode int, mediaType string, encoder Encoder, payload any) {
 	// Headers
 	w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(ctx))
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", mediaType)
 
-	js, err := json.Marshal(payload)
-
-	if err != nil {
+	// Encoded beforehand, so that a failure is still a 500
+	var body bytes.Buffer
+	if err := encoder.Encode(&body, payload); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(httpStatusCode)
-	_, _ = w.Write(js)
+	_, _ = w.Write(body.Bytes())
 }
 
 // RenderError Renders an error with some sane defaults.
 func RenderError(ctx context.Context, w http.ResponseWriter, err error) {
+	terrorToRender := asTerror(err)
+
+	RenderJSON(ctx, w, terrorToRender.HttpStatusCode, terrorToRender)
+}
+
+// RenderErrorFor renders an error like RenderError, in the media type the client prefers like Render
+func RenderErrorFor(w http.ResponseWriter, r *http.Request, err error) {
+	terrorToRender := asTerror(err)
+
+	Render(w, r, terrorToRender.HttpStatusCode, terrorToRender)
+}
+
+func asTerror(err error) *terrors.Terror {
 	var terrorToRender *terrors.Terror
 
 	if !errors.As(err, &terrorToRender) {
 		terrorToRender = terrors.Unknown(err.Error())
 	}
 
-	RenderJSON(ctx, w, terrorToRender.HttpStatusCode, terrorToRender)
+	return terrorToRender
 }
 
 func SimpleSuccessResponseBody() map[string]string {
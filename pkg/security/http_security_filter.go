@@ -3,38 +3,63 @@ package security
 import (
 	"net/http"
 
-	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/logger"
 	"github.com/zeusito/toci/pkg/security/sessions"
 )
 
-// AuthenticationFilter is a middleware that checks if the request has a valid token
+// AuthenticationFilter is a middleware that checks if the request has a valid token, either a bearer token or a
+// session cookie. The unsafe requests authenticated by a cookie must carry the CSRF token.
 func AuthenticationFilter(sessionManager sessions.Manager) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
+			token, fromCookie := SessionToken(r)
 			if token == "" {
-				log.Warn().Msg("no token provided")
+				logger.Ctx(r.Context()).Warn().Msg("no token provided")
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			// Remove the "Bearer " prefix
-			token = token[7:]
+			if fromCookie && !validCSRFToken(r) {
+				logger.Ctx(r.Context()).Warn().Msg("invalid CSRF token")
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
 
 			// Validate the token
 			record, ok := sessionManager.GetSession(r.Context(), token)
 			if !ok {
-				log.Warn().Msg("session not authenticated")
+				logger.Ctx(r.Context()).Warn().Msg("session not authenticated")
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
 			claims := sessions.ClaimsFromSession(record)
 
-			// Add claims to context
+			// Add claims to context, the lines logged while handling the request name the principal
 			ctx := sessions.AddToContext(r.Context(), claims)
+			ctx = logger.WithPrincipal(ctx, claims.PrincipalID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// AuthorizationFilter is a middleware that checks if the principal has one of the roles, it must run after the
+// AuthenticationFilter
+func AuthorizationFilter(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := sessions.ExtractClaimsFromContext(r.Context())
+
+			for _, role := range roles {
+				if claims.HasRole(role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			logger.Ctx(r.Context()).Warn().Msgf("principal %s does not have any of the roles: %v", claims.PrincipalID, roles)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
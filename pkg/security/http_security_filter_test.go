@@ -0,0 +1,102 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/security/sessions"
+)
+
+func newAuthenticatedHandler(t *testing.T) http.Handler {
+	sessionManager := sessions.NewMockManager(t)
+	sessionManager.EXPECT().GetSession(mock.Anything, "token-1").
+		Return(&sessions.Session{PrincipalID: "identity-1", ExpiresAt: time.Now().Add(time.Hour)}, true).Maybe()
+
+	return AuthenticationFilter(sessionManager)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sessions.ExtractClaimsFromContext(r.Context()).PrincipalID))
+	}))
+}
+
+func TestAuthenticationFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		bearer   string
+		session  string
+		csrf     string
+		header   string
+		expected int
+	}{
+		{"bearer token", http.MethodPost, "token-1", "", "", "", http.StatusOK},
+		{"no token", http.MethodGet, "", "", "", "", http.StatusUnauthorized},
+		{"cookie on a safe request", http.MethodGet, "", "token-1", "", "", http.StatusOK},
+		{"cookie without the CSRF token", http.MethodPost, "", "token-1", "csrf-1", "", http.StatusForbidden},
+		{"cookie with another CSRF token", http.MethodDelete, "", "token-1", "csrf-1", "csrf-2", http.StatusForbidden},
+		{"cookie with the CSRF token", http.MethodPost, "", "token-1", "csrf-1", "csrf-1", http.StatusOK},
+		// a bearer token cannot be sent by a cross-site page
+		{"bearer token with a cookie", http.MethodPost, "token-1", "token-2", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/resource", nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.session})
+			}
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.csrf})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			newAuthenticatedHandler(t).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+			if tt.expected == http.StatusOK {
+				assert.Equal(t, "identity-1", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSessionCookies(t *testing.T) {
+	cookies := NewSessionCookies(config.SessionCookieConfigurations{Enabled: true, SameSite: "strict"})
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, cookies.Set(rec, "token-1", expiresAt))
+
+	issued := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		issued[cookie.Name] = cookie
+	}
+	require.Contains(t, issued, SessionCookie)
+	require.Contains(t, issued, CSRFCookie)
+	assert.Equal(t, "token-1", issued[SessionCookie].Value)
+	assert.True(t, issued[SessionCookie].HttpOnly)
+	assert.False(t, issued[CSRFCookie].HttpOnly, "expected scripts to read the CSRF token")
+	assert.NotEmpty(t, issued[CSRFCookie].Value)
+	for _, cookie := range issued {
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.True(t, expiresAt.Equal(cookie.Expires))
+	}
+
+	rec = httptest.NewRecorder()
+	cookies.Clear(rec)
+	for _, cookie := range rec.Result().Cookies() {
+		assert.Equal(t, -1, cookie.MaxAge, "expected %s to be removed", cookie.Name)
+	}
+	assert.Len(t, rec.Result().Cookies(), 2)
+}
@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+const maxResponseSize = 1 << 20
+
+var ErrNoVerifiedEmail = errors.New("no verified email address")
+
+// tokenResponse the relevant fields of the token endpoint response, see RFC 6749 section 5.1
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// userInfo the union of the user info formats of OpenID and GitHub like providers
+type userInfo struct {
+	Subject       string          `json:"sub"`
+	ID            json.RawMessage `json:"id"`
+	Email         string          `json:"email"`
+	EmailVerified *bool           `json:"email_verified"`
+	GivenName     string          `json:"given_name"`
+	FamilyName    string          `json:"family_name"`
+	Name          string          `json:"name"`
+}
+
+type userEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// redeemCode exchanges the authorization code for tokens, the client authenticates with client_secret_post
+func (s *DefaultManager) redeemCode(ctx context.Context, p *provider, tokenURL, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokens tokenResponse
+	if err := s.doJSON(req, &tokens); err != nil {
+		return nil, err
+	}
+
+	if tokens.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+
+	return &tokens, nil
+}
+
+// fetchUserInfo resolves the identity with the access token, falling back to the emails endpoint
+// when the profile does not expose a verified email
+func (s *DefaultManager) fetchUserInfo(ctx context.Context, p *provider, accessToken string) (*Identity, error) {
+	var info userInfo
+	if err := s.getWithToken(ctx, p.userInfoURL, accessToken, &info); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:   p.name,
+		Subject:    info.Subject,
+		Email:      info.Email,
+		GivenName:  info.GivenName,
+		FamilyName: info.FamilyName,
+	}
+
+	if identity.Subject == "" && len(info.ID) > 0 {
+		identity.Subject = strings.Trim(string(info.ID), `"`)
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("user info has no subject")
+	}
+
+	if identity.GivenName == "" && identity.FamilyName == "" && info.Name != "" {
+		identity.GivenName, identity.FamilyName, _ = strings.Cut(info.Name, " ")
+	}
+
+	if info.EmailVerified != nil {
+		identity.EmailVerified = *info.EmailVerified
+	}
+
+	if identity.EmailVerified || p.emailsURL == "" {
+		return identity, nil
+	}
+
+	var emails []userEmail
+	if err := s.getWithToken(ctx, p.emailsURL, accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			identity.Email = e.Email
+			identity.EmailVerified = true
+			return identity, nil
+		}
+	}
+
+	return nil, ErrNoVerifiedEmail
+}
+
+func (s *DefaultManager) getWithToken(ctx context.Context, target, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return s.doJSON(req, out)
+}
+
+func (s *DefaultManager) doJSON(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,266 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/security/oidc"
+	"github.com/zeusito/toci/pkg/toolbox"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+type provider struct {
+	name         string
+	openID       bool
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	authorizeURL string
+	tokenURL     string
+	userInfoURL  string
+	emailsURL    string
+	responseMode string
+}
+
+type DefaultManager struct {
+	storage   Storage
+	hasher    hasher.Keyring
+	verifier  oidc.Verifier
+	providers map[string]*provider
+	// the providers the clients may sign in with an ID token of, they are issued nonces
+	openIDProviders map[string]bool
+	httpClient      *http.Client
+}
+
+// Start persists the state, nonce and PKCE verifier and returns the URL the user agent must be redirected to
+func (s *DefaultManager) Start(ctx context.Context, providerName string) (string, string, bool) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("unknown oauth provider: %s", providerName)
+		return "", "", false
+	}
+
+	authorizeURL, _, ok := s.endpoints(ctx, p)
+	if !ok {
+		return "", "", false
+	}
+
+	state := toolbox.SecureRandomString(stateLength)
+	flow := &Flow{
+		Provider:     p.name,
+		CodeVerifier: toolbox.SecureRandomString(codeVerifierLength),
+		ExpiresAt:    time.Now().UTC().Add(flowExpiration),
+	}
+	if p.openID {
+		flow.Nonce = toolbox.SecureRandomString(nonceLength)
+	}
+
+	if state == "" || flow.CodeVerifier == "" || (p.openID && flow.Nonce == "") {
+		logger.Ctx(ctx).Error().Msg("failed to generate random values")
+		return "", "", false
+	}
+
+	hashedState, err := s.hasher.Hash(state)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to hash state")
+		return "", "", false
+	}
+
+	err = s.storage.Put(ctx, hashedState, flow)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to persist oauth flow")
+		return "", "", false
+	}
+
+	// PKCE with the S256 method, see RFC 7636
+	challenge := sha256.Sum256([]byte(flow.CodeVerifier))
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(p.scopes) > 0 {
+		query.Set("scope", strings.Join(p.scopes, " "))
+	}
+	if flow.Nonce != "" {
+		query.Set("nonce", flow.Nonce)
+	}
+	if p.responseMode != "" {
+		query.Set("response_mode", p.responseMode)
+	}
+
+	separator := "?"
+	if strings.Contains(authorizeURL, "?") {
+		separator = "&"
+	}
+
+	return authorizeURL + separator + query.Encode(), state, true
+}
+
+// Exchange redeems the authorization code and resolves the identity of the user
+func (s *DefaultManager) Exchange(ctx context.Context, providerName, state, code string) (*Identity, bool) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("unknown oauth provider: %s", providerName)
+		return nil, false
+	}
+
+	hashedStates, err := s.hasher.HashAll(state)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to hash state")
+		return nil, false
+	}
+
+	// The state may have been hashed by a key that was rotated since
+	var flow *Flow
+	for _, hashedState := range hashedStates {
+		flow, err = s.storage.Take(ctx, hashedState)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("oauth flow not found or expired")
+		return nil, false
+	}
+
+	if flow.Provider != p.name {
+		logger.Ctx(ctx).Warn().Msgf("oauth flow was started for another provider: %s", flow.Provider)
+		return nil, false
+	}
+
+	_, tokenURL, ok := s.endpoints(ctx, p)
+	if !ok {
+		return nil, false
+	}
+
+	tokens, err := s.redeemCode(ctx, p, tokenURL, code, flow.CodeVerifier)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to redeem authorization code")
+		return nil, false
+	}
+
+	if p.openID {
+		return s.identityFromIDToken(ctx, p, tokens.IDToken, flow.Nonce)
+	}
+
+	identity, err := s.fetchUserInfo(ctx, p, tokens.AccessToken)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to retrieve user info")
+		return nil, false
+	}
+
+	return identity, true
+}
+
+func (s *DefaultManager) IsOpenIDProvider(providerName string) bool {
+	return s.openIDProviders[providerName]
+}
+
+// IssueNonce persists a nonce for the ID tokens obtained by the clients themselves, e.g. with a native SDK
+func (s *DefaultManager) IssueNonce(ctx context.Context, providerName string) (string, time.Time, bool) {
+	if !s.IsOpenIDProvider(providerName) {
+		logger.Ctx(ctx).Warn().Msgf("unknown openid provider: %s", providerName)
+		return "", time.Time{}, false
+	}
+
+	nonce := toolbox.SecureRandomString(nonceLength)
+	if nonce == "" {
+		logger.Ctx(ctx).Error().Msg("failed to generate random values")
+		return "", time.Time{}, false
+	}
+
+	hashedNonce, err := s.hasher.Hash(nonce)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to hash nonce")
+		return "", time.Time{}, false
+	}
+
+	expiresAt := time.Now().UTC().Add(flowExpiration)
+
+	err = s.storage.PutNonce(ctx, hashedNonce, providerName, expiresAt)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to persist nonce")
+		return "", time.Time{}, false
+	}
+
+	return nonce, expiresAt, true
+}
+
+func (s *DefaultManager) RedeemNonce(ctx context.Context, providerName, nonce string) bool {
+	if nonce == "" {
+		return false
+	}
+
+	hashedNonces, err := s.hasher.HashAll(nonce)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to hash nonce")
+		return false
+	}
+
+	// The nonce may have been hashed by a key that was rotated since
+	var provider string
+	for _, hashedNonce := range hashedNonces {
+		provider, err = s.storage.TakeNonce(ctx, hashedNonce)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("nonce not found or expired")
+		return false
+	}
+
+	if provider != providerName {
+		logger.Ctx(ctx).Warn().Msgf("nonce was issued for another provider: %s", provider)
+		return false
+	}
+
+	return true
+}
+
+func (s *DefaultManager) identityFromIDToken(ctx context.Context, p *provider, idToken, nonce string) (*Identity, bool) {
+	if idToken == "" {
+		logger.Ctx(ctx).Warn().Msg("token response has no id token")
+		return nil, false
+	}
+
+	claims, ok := s.verifier.Verify(ctx, p.name, idToken, nonce)
+	if !ok {
+		return nil, false
+	}
+
+	return &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, true
+}
+
+// endpoints resolves the authorize and token URLs, discovered for OpenID providers
+func (s *DefaultManager) endpoints(ctx context.Context, p *provider) (string, string, bool) {
+	if !p.openID {
+		return p.authorizeURL, p.tokenURL, true
+	}
+
+	endpoints, ok := s.verifier.Endpoints(ctx, p.name)
+	if !ok || endpoints.AuthorizationURL == "" || endpoints.TokenURL == "" {
+		logger.Ctx(ctx).Warn().Msgf("failed to discover endpoints of provider: %s", p.name)
+		return "", "", false
+	}
+
+	return endpoints.AuthorizationURL, endpoints.TokenURL, true
+}
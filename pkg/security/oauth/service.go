@@ -0,0 +1,144 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/security/oidc"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+	"github.com/zeusito/toci/pkg/tracing"
+)
+
+const (
+	stateLength        = 43
+	nonceLength        = 43
+	codeVerifierLength = 64
+	flowExpiration     = 10 * time.Minute
+	httpClientTimeout  = 10 * time.Second
+)
+
+// Identity the user asserted by the provider at the end of the flow
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// Flow the server-side state of an authorization request, looked up by the hashed state parameter
+type Flow struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+type Manager interface {
+	// Start builds the authorization URL, the returned state must be bound to the user agent
+	Start(ctx context.Context, provider string) (authorizeURL string, state string, ok bool)
+	// Exchange completes the flow, the state is single use
+	Exchange(ctx context.Context, provider, state, code string) (*Identity, bool)
+	// IsOpenIDProvider tells whether the ID tokens of the provider can be verified, i.e. it is a configured OIDC provider
+	IsOpenIDProvider(provider string) bool
+	// IssueNonce returns the nonce a client must have the provider put in the ID token it signs in with
+	IssueNonce(ctx context.Context, provider string) (nonce string, expiresAt time.Time, ok bool)
+	// RedeemNonce tells whether the nonce was issued for the provider and is not expired, it is single use
+	RedeemNonce(ctx context.Context, provider, nonce string) bool
+}
+
+type Storage interface {
+	Put(ctx context.Context, hashedState string, flow *Flow) error
+	// Take retrieves and removes a flow that is not expired
+	Take(ctx context.Context, hashedState string) (*Flow, error)
+	PutNonce(ctx context.Context, hashedNonce, provider string, expiresAt time.Time) error
+	// TakeNonce removes a nonce that is not expired and returns the provider it was issued for
+	TakeNonce(ctx context.Context, hashedNonce string) (string, error)
+}
+
+func NewManagerWithPgSQLStorage(db *bun.DB, theHasher hasher.Keyring, verifier oidc.Verifier,
+	oidcCfg config.OIDCConfigurations, oauthCfg config.OAuthConfigurations) (Manager, bool) {
+	providers := make(map[string]*provider)
+	openIDProviders := make(map[string]bool)
+
+	// OpenID providers discover their endpoints and return an ID token
+	for name, p := range oidcCfg.Providers {
+		// the clients may sign in with the ID tokens of the providers the verifier knows
+		if p.Issuer != "" && p.ClientID != "" {
+			openIDProviders[name] = true
+		}
+
+		if p.ClientID == "" || p.ClientSecret == "" || p.RedirectURL == "" {
+			continue
+		}
+
+		if !validResponseMode(p.ResponseMode) {
+			log.Error().Msgf("oidc provider %s has an unsupported response mode: %s", name, p.ResponseMode)
+			return nil, false
+		}
+
+		providers[name] = &provider{
+			name:         name,
+			openID:       true,
+			clientID:     p.ClientID,
+			clientSecret: p.ClientSecret,
+			redirectURL:  p.RedirectURL,
+			scopes:       p.Scopes,
+			responseMode: p.ResponseMode,
+		}
+	}
+
+	for name, p := range oauthCfg.Providers {
+		if p.ClientID == "" || p.ClientSecret == "" || p.RedirectURL == "" {
+			log.Warn().Msgf("oauth provider %s is missing the client credentials or redirect URL, skipping", name)
+			continue
+		}
+
+		if p.AuthorizeURL == "" || p.TokenURL == "" || p.UserInfoURL == "" {
+			log.Error().Msgf("oauth provider %s requires the authorize, token and user info URLs", name)
+			return nil, false
+		}
+
+		if !validResponseMode(p.ResponseMode) {
+			log.Error().Msgf("oauth provider %s has an unsupported response mode: %s", name, p.ResponseMode)
+			return nil, false
+		}
+
+		if _, exists := providers[name]; exists {
+			log.Error().Msgf("oauth provider %s is also configured as an oidc provider", name)
+			return nil, false
+		}
+
+		providers[name] = &provider{
+			name:         name,
+			clientID:     p.ClientID,
+			clientSecret: p.ClientSecret,
+			redirectURL:  p.RedirectURL,
+			scopes:       p.Scopes,
+			authorizeURL: p.AuthorizeURL,
+			tokenURL:     p.TokenURL,
+			userInfoURL:  p.UserInfoURL,
+			emailsURL:    p.EmailsURL,
+			responseMode: p.ResponseMode,
+		}
+	}
+
+	return &DefaultManager{
+		storage:         NewPgSQLStorage(db),
+		hasher:          theHasher,
+		verifier:        verifier,
+		providers:       providers,
+		openIDProviders: openIDProviders,
+		httpClient:      &http.Client{Timeout: httpClientTimeout, Transport: tracing.NewTransport(http.DefaultTransport)},
+	}, true
+}
+
+// validResponseMode the callback handles the code in the query string or in a posted form
+func validResponseMode(mode string) bool {
+	return mode == "" || mode == "query" || mode == "form_post"
+}
@@ -0,0 +1,342 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/security/oidc"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+const (
+	testClientID     = "client-id"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://app.example.com/v1/auth/oauth/github/callback"
+	testCode         = "auth-code"
+)
+
+// testProvider is a GitHub like provider that only accepts the code with the matching PKCE verifier
+type testProvider struct {
+	server        *httptest.Server
+	challenge     string
+	emailVerified bool
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	p := &testProvider{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+		if r.PostForm.Get("code") != testCode || r.PostForm.Get("client_secret") != testClientSecret ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access-token",
+			"token_type":   "bearer",
+			"id_token":     "id-token",
+		})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 1234, "name": "John Doe", "email": nil})
+	})
+	mux.HandleFunc("/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "other@example.com", "primary": false, "verified": true},
+			{"email": "john@example.com", "primary": true, "verified": p.emailVerified},
+		})
+	})
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// memoryStorage keeps a single flow, enough to drive one authorization request
+func memoryStorage(t *testing.T) *MockStorage {
+	storage := NewMockStorage(t)
+	flows := map[string]*Flow{}
+
+	storage.EXPECT().Put(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, hashedState string, flow *Flow) error {
+			flows[hashedState] = flow
+			return nil
+		}).Maybe()
+	storage.EXPECT().Take(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, hashedState string) (*Flow, error) {
+			flow, ok := flows[hashedState]
+			if !ok {
+				return nil, sql.ErrNoRows
+			}
+			delete(flows, hashedState)
+			return flow, nil
+		}).Maybe()
+
+	nonces := map[string]string{}
+
+	storage.EXPECT().PutNonce(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, hashedNonce, provider string, expiresAt time.Time) error {
+			nonces[hashedNonce] = provider
+			return nil
+		}).Maybe()
+	storage.EXPECT().TakeNonce(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, hashedNonce string) (string, error) {
+			provider, ok := nonces[hashedNonce]
+			if !ok {
+				return "", sql.ErrNoRows
+			}
+			delete(nonces, hashedNonce)
+			return provider, nil
+		}).Maybe()
+
+	return storage
+}
+
+func setupManager(t *testing.T, p *testProvider, verifier oidc.Verifier) *DefaultManager {
+	theHasher, err := hasher.NewHmacSHA256KeyringFromConfig(config.HasherConfigurations{
+		SHASecret: "dGhpcy1pcy1hLXRlc3Qtc2VjcmV0LWtleS0xMjM0NTY3OA==",
+	})
+	require.NoError(t, err)
+
+	return &DefaultManager{
+		storage:  memoryStorage(t),
+		hasher:   theHasher,
+		verifier: verifier,
+		providers: map[string]*provider{
+			"github": {
+				name:         "github",
+				clientID:     testClientID,
+				clientSecret: testClientSecret,
+				redirectURL:  testRedirectURL,
+				scopes:       []string{"read:user", "user:email"},
+				authorizeURL: p.server.URL + "/authorize",
+				tokenURL:     p.server.URL + "/token",
+				userInfoURL:  p.server.URL + "/user",
+				emailsURL:    p.server.URL + "/emails",
+			},
+			"google": {
+				name:         "google",
+				openID:       true,
+				clientID:     testClientID,
+				clientSecret: testClientSecret,
+				redirectURL:  testRedirectURL,
+				scopes:       []string{"openid", "email"},
+			},
+		},
+		openIDProviders: map[string]bool{"google": true},
+		httpClient:      p.server.Client(),
+	}
+}
+
+func start(t *testing.T, manager Manager, p *testProvider, provider string) (url.Values, string) {
+	authorizeURL, state, ok := manager.Start(context.Background(), provider)
+	require.True(t, ok)
+
+	parsed, err := url.Parse(authorizeURL)
+	require.NoError(t, err)
+
+	query := parsed.Query()
+	p.challenge = query.Get("code_challenge")
+
+	return query, state
+}
+
+func TestStartBuildsAuthorizationURL(t *testing.T) {
+	p := newTestProvider(t)
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+
+	query, state := start(t, manager, p, "github")
+
+	assert.NotEmpty(t, state)
+	assert.Equal(t, state, query.Get("state"))
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientID, query.Get("client_id"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "read:user user:email", query.Get("scope"))
+	assert.Empty(t, query.Get("nonce"))
+	assert.Empty(t, query.Get("response_mode"))
+}
+
+func TestStartRequestsFormPost(t *testing.T) {
+	p := newTestProvider(t)
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+	manager.providers["github"].responseMode = "form_post"
+
+	query, _ := start(t, manager, p, "github")
+
+	assert.Equal(t, "form_post", query.Get("response_mode"))
+}
+
+func TestStartUnknownProvider(t *testing.T) {
+	p := newTestProvider(t)
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+
+	authorizeURL, state, ok := manager.Start(context.Background(), "unknown")
+
+	assert.False(t, ok)
+	assert.Empty(t, authorizeURL)
+	assert.Empty(t, state)
+}
+
+func TestExchangeOAuthProvider(t *testing.T) {
+	p := newTestProvider(t)
+	p.emailVerified = true
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+	_, state := start(t, manager, p, "github")
+
+	identity, ok := manager.Exchange(context.Background(), "github", state, testCode)
+
+	require.True(t, ok)
+	assert.Equal(t, "github", identity.Provider)
+	assert.Equal(t, "1234", identity.Subject)
+	assert.Equal(t, "john@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "John", identity.GivenName)
+	assert.Equal(t, "Doe", identity.FamilyName)
+}
+
+func TestExchangeRequiresVerifiedEmail(t *testing.T) {
+	p := newTestProvider(t)
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+	_, state := start(t, manager, p, "github")
+
+	identity, ok := manager.Exchange(context.Background(), "github", state, testCode)
+
+	assert.False(t, ok)
+	assert.Nil(t, identity)
+}
+
+func TestExchangeStateIsSingleUse(t *testing.T) {
+	p := newTestProvider(t)
+	p.emailVerified = true
+	manager := setupManager(t, p, oidc.NewMockVerifier(t))
+	_, state := start(t, manager, p, "github")
+
+	_, ok := manager.Exchange(context.Background(), "github", state, testCode)
+	require.True(t, ok)
+
+	identity, ok := manager.Exchange(context.Background(), "github", state, testCode)
+
+	assert.False(t, ok)
+	assert.Nil(t, identity)
+}
+
+func TestExchangeRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		state    string
+		code     string
+		mutate   func(p *testProvider)
+	}{
+		{name: "unknown state", provider: "github", state: "forged-state", code: testCode},
+		{name: "another provider", provider: "google", code: testCode},
+		{name: "invalid code", provider: "github", code: "other-code"},
+		{name: "pkce mismatch", provider: "github", code: testCode, mutate: func(p *testProvider) { p.challenge = "other" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			p.emailVerified = true
+			manager := setupManager(t, p, oidc.NewMockVerifier(t))
+			_, state := start(t, manager, p, "github")
+			if tt.state != "" {
+				state = tt.state
+			}
+			if tt.mutate != nil {
+				tt.mutate(p)
+			}
+
+			identity, ok := manager.Exchange(context.Background(), tt.provider, state, tt.code)
+
+			assert.False(t, ok)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestExchangeOpenIDProvider(t *testing.T) {
+	p := newTestProvider(t)
+	verifier := oidc.NewMockVerifier(t)
+	verifier.EXPECT().Endpoints(mock.Anything, "google").Return(&oidc.Endpoints{
+		AuthorizationURL: p.server.URL + "/authorize",
+		TokenURL:         p.server.URL + "/token",
+	}, true)
+	manager := setupManager(t, p, verifier)
+
+	query, state := start(t, manager, p, "google")
+	require.NotEmpty(t, query.Get("nonce"))
+
+	verifier.EXPECT().Verify(mock.Anything, "google", "id-token", query.Get("nonce")).Return(&oidc.Claims{
+		Subject:       "subject-1",
+		Email:         "john@example.com",
+		EmailVerified: true,
+	}, true)
+
+	identity, ok := manager.Exchange(context.Background(), "google", state, testCode)
+
+	require.True(t, ok)
+	assert.Equal(t, "google", identity.Provider)
+	assert.Equal(t, "subject-1", identity.Subject)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestNonceIsSingleUse(t *testing.T) {
+	manager := setupManager(t, newTestProvider(t), oidc.NewMockVerifier(t))
+	ctx := context.Background()
+
+	nonce, expiresAt, ok := manager.IssueNonce(ctx, "google")
+	require.True(t, ok)
+	assert.NotEmpty(t, nonce)
+	assert.WithinDuration(t, time.Now().Add(flowExpiration), expiresAt, time.Minute)
+
+	assert.True(t, manager.RedeemNonce(ctx, "google", nonce))
+	assert.False(t, manager.RedeemNonce(ctx, "google", nonce), "expected a nonce to be redeemed once")
+}
+
+func TestNonceRejectsInvalidRequests(t *testing.T) {
+	manager := setupManager(t, newTestProvider(t), oidc.NewMockVerifier(t))
+	ctx := context.Background()
+
+	nonce, _, ok := manager.IssueNonce(ctx, "google")
+	require.True(t, ok)
+
+	assert.False(t, manager.RedeemNonce(ctx, "google", ""), "expected an empty nonce to be rejected")
+	assert.False(t, manager.RedeemNonce(ctx, "google", "chosen-by-the-client"), "expected an unknown nonce to be rejected")
+	assert.False(t, manager.RedeemNonce(ctx, "apple", nonce), "expected a nonce of another provider to be rejected")
+}
+
+func TestIssueNonceUnknownProvider(t *testing.T) {
+	manager := setupManager(t, newTestProvider(t), oidc.NewMockVerifier(t))
+	// nothing may be persisted
+	manager.storage = NewMockStorage(t)
+
+	nonce, _, ok := manager.IssueNonce(context.Background(), "github")
+
+	assert.False(t, ok, "expected a provider without ID tokens to be rejected")
+	assert.Empty(t, nonce)
+}
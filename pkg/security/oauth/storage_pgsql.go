@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FlowRecord the database model for a pending authorization request
+type FlowRecord struct {
+	bun.BaseModel `bun:"table:oauth_flows,alias:of"`
+	ID            string    `bun:"id,pk"`
+	Provider      string    `bun:"provider"`
+	Nonce         string    `bun:"nonce"`
+	CodeVerifier  string    `bun:"code_verifier"`
+	ExpiresAt     time.Time `bun:"expires_at"`
+	CreatedAt     time.Time `bun:"created_at"`
+}
+
+// NonceRecord the database model for a nonce issued to a client signing in with an ID token
+type NonceRecord struct {
+	bun.BaseModel `bun:"table:oidc_nonces,alias:on"`
+	ID            string    `bun:"id,pk"`
+	Provider      string    `bun:"provider"`
+	ExpiresAt     time.Time `bun:"expires_at"`
+	CreatedAt     time.Time `bun:"created_at"`
+}
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Put stores a new flow keyed by the hashed state
+func (s *PgSQLStorage) Put(ctx context.Context, hashedState string, flow *Flow) error {
+	_, err := s.db.NewInsert().
+		Model(&FlowRecord{
+			ID:           hashedState,
+			Provider:     flow.Provider,
+			Nonce:        flow.Nonce,
+			CodeVerifier: flow.CodeVerifier,
+			ExpiresAt:    flow.ExpiresAt,
+			CreatedAt:    time.Now().UTC(),
+		}).
+		Exec(ctx)
+
+	return err
+}
+
+// Take deletes the flow and returns it, so a state can only be redeemed once. The expired flows are purged along.
+func (s *PgSQLStorage) Take(ctx context.Context, hashedState string) (*Flow, error) {
+	var records []FlowRecord
+	now := time.Now().UTC()
+
+	err := s.db.NewDelete().
+		Model(&records).
+		Where("id = ? OR expires_at <= ?", hashedState, now).
+		Returning("*").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(records, func(record FlowRecord) bool {
+		return record.ID == hashedState && record.ExpiresAt.After(now)
+	})
+	if index < 0 {
+		return nil, sql.ErrNoRows
+	}
+	record := records[index]
+
+	return &Flow{
+		Provider:     record.Provider,
+		Nonce:        record.Nonce,
+		CodeVerifier: record.CodeVerifier,
+		ExpiresAt:    record.ExpiresAt,
+	}, nil
+}
+
+// PutNonce stores a new nonce keyed by its hash
+func (s *PgSQLStorage) PutNonce(ctx context.Context, hashedNonce, provider string, expiresAt time.Time) error {
+	_, err := s.db.NewInsert().
+		Model(&NonceRecord{
+			ID:        hashedNonce,
+			Provider:  provider,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now().UTC(),
+		}).
+		Exec(ctx)
+
+	return err
+}
+
+// TakeNonce deletes the nonce and returns its provider, so a nonce can only be redeemed once. The expired nonces are
+// purged along.
+func (s *PgSQLStorage) TakeNonce(ctx context.Context, hashedNonce string) (string, error) {
+	var records []NonceRecord
+	now := time.Now().UTC()
+
+	err := s.db.NewDelete().
+		Model(&records).
+		Where("id = ? OR expires_at <= ?", hashedNonce, now).
+		Returning("*").
+		Scan(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	index := slices.IndexFunc(records, func(record NonceRecord) bool {
+		return record.ID == hashedNonce && record.ExpiresAt.After(now)
+	})
+	if index < 0 {
+		return "", sql.ErrNoRows
+	}
+
+	return records[index].Provider, nil
+}
@@ -0,0 +1,114 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/zeusito/toci/pkg/logger"
+)
+
+type DefaultVerifier struct {
+	providers  map[string]*provider
+	httpClient *http.Client
+}
+
+// Verify validates the signature, issuer, audience, expiration and nonce of an ID token
+func (v *DefaultVerifier) Verify(ctx context.Context, providerName, rawIDToken, nonce string) (*Claims, bool) {
+	p, ok := v.providers[providerName]
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("unknown oidc provider: %s", providerName)
+		return nil, false
+	}
+
+	token, err := parseToken(rawIDToken)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to parse id token")
+		return nil, false
+	}
+
+	key, err := p.getKey(ctx, v.httpClient, token.header.Kid)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to retrieve signing key")
+		return nil, false
+	}
+
+	if !token.verifySignature(key) {
+		logger.Ctx(ctx).Warn().Msg("id token signature is invalid")
+		return nil, false
+	}
+
+	claims := token.claims
+	now := time.Now()
+
+	if claims.Issuer != p.issuer {
+		logger.Ctx(ctx).Warn().Msgf("unexpected issuer: %s", claims.Issuer)
+		return nil, false
+	}
+
+	if !claims.Audience.contains(p.clientID) {
+		logger.Ctx(ctx).Warn().Msg("id token was not issued for this client")
+		return nil, false
+	}
+
+	// With several audiences, the authorized party must be us
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.clientID {
+		logger.Ctx(ctx).Warn().Msg("id token authorized party does not match")
+		return nil, false
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if claims.ExpiresAt == 0 || now.After(expiresAt.Add(clockSkewLeeway)) {
+		logger.Ctx(ctx).Warn().Msg("id token is expired")
+		return nil, false
+	}
+
+	if claims.NotBefore != 0 && now.Add(clockSkewLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		logger.Ctx(ctx).Warn().Msg("id token is not valid yet")
+		return nil, false
+	}
+
+	// The nonce binds the token to a request of ours, a token without one could have been issued to anyone
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(nonce), []byte(claims.Nonce)) != 1 {
+		logger.Ctx(ctx).Warn().Msg("id token nonce does not match")
+		return nil, false
+	}
+
+	if claims.Subject == "" {
+		logger.Ctx(ctx).Warn().Msg("id token has no subject")
+		return nil, false
+	}
+
+	return &Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Nonce:         claims.Nonce,
+		ExpiresAt:     expiresAt,
+	}, true
+}
+
+// Endpoints returns the endpoints discovered for the given provider
+func (v *DefaultVerifier) Endpoints(ctx context.Context, providerName string) (*Endpoints, bool) {
+	p, ok := v.providers[providerName]
+	if !ok {
+		logger.Ctx(ctx).Warn().Msgf("unknown oidc provider: %s", providerName)
+		return nil, false
+	}
+
+	metadata, err := p.getMetadata(ctx, v.httpClient)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to retrieve provider metadata")
+		return nil, false
+	}
+
+	return &Endpoints{
+		AuthorizationURL: metadata.AuthorizationEndpoint,
+		TokenURL:         metadata.TokenEndpoint,
+		UserInfoURL:      metadata.UserInfoEndpoint,
+	}, true
+}
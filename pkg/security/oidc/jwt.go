@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+var errMalformedToken = errors.New("malformed token")
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// tokenClaims the registered and standard claims of an ID token
+type tokenClaims struct {
+	Issuer        string       `json:"iss"`
+	Subject       string       `json:"sub"`
+	Audience      audience     `json:"aud"`
+	AuthorizedBy  string       `json:"azp"`
+	ExpiresAt     int64        `json:"exp"`
+	IssuedAt      int64        `json:"iat"`
+	NotBefore     int64        `json:"nbf"`
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	GivenName     string       `json:"given_name"`
+	FamilyName    string       `json:"family_name"`
+}
+
+// audience the aud claim is either a single string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// flexibleBool some providers send email_verified as the string "true"
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+type parsedToken struct {
+	header       tokenHeader
+	claims       tokenClaims
+	signingInput []byte
+	signature    []byte
+}
+
+func parseToken(raw string) (*parsedToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	token := &parsedToken{
+		signingInput: []byte(parts[0] + "." + parts[1]),
+		signature:    signature,
+	}
+
+	if err := json.Unmarshal(headerJSON, &token.header); err != nil {
+		return nil, errMalformedToken
+	}
+
+	if err := json.Unmarshal(claimsJSON, &token.claims); err != nil {
+		return nil, errMalformedToken
+	}
+
+	return token, nil
+}
+
+// verifySignature checks the JWS signature, the key type must match the algorithm in the header
+func (t *parsedToken) verifySignature(key crypto.PublicKey) bool {
+	switch t.header.Alg {
+	case "RS256", "RS384", "RS512":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hash, digest := digestFor(t.header.Alg, t.signingInput)
+		return rsa.VerifyPKCS1v15(pub, hash, digest, t.signature) == nil
+
+	case "ES256", "ES384":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		// JWS uses the raw r||s encoding rather than ASN.1
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		_, digest := digestFor(t.header.Alg, t.signingInput)
+		return ecdsa.Verify(pub, digest, r, s)
+
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(pub, t.signingInput, t.signature)
+	}
+
+	// "none" and symmetric algorithms are never accepted
+	return false
+}
+
+func digestFor(alg string, data []byte) (crypto.Hash, []byte) {
+	switch alg[2:] {
+	case "384":
+		sum := sha512.Sum384(data)
+		return crypto.SHA384, sum[:]
+	case "512":
+		sum := sha512.Sum512(data)
+		return crypto.SHA512, sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return crypto.SHA256, sum[:]
+	}
+}
@@ -0,0 +1,61 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/tracing"
+)
+
+const (
+	metadataTTL       = time.Hour
+	minKeysRefresh    = time.Minute
+	clockSkewLeeway   = time.Minute
+	httpClientTimeout = 10 * time.Second
+)
+
+// Claims the verified subset of the ID token claims
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Nonce         string
+	ExpiresAt     time.Time
+}
+
+// Endpoints the provider endpoints published in its discovery document
+type Endpoints struct {
+	AuthorizationURL string
+	TokenURL         string
+	UserInfoURL      string
+}
+
+type Verifier interface {
+	// Verify validates the ID token issued by the given provider, its nonce must be the given one
+	Verify(ctx context.Context, provider, rawIDToken, nonce string) (*Claims, bool)
+	Endpoints(ctx context.Context, provider string) (*Endpoints, bool)
+}
+
+func NewVerifier(cfg config.OIDCConfigurations) (Verifier, bool) {
+	providers := make(map[string]*provider, len(cfg.Providers))
+
+	for name, p := range cfg.Providers {
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Warn().Msgf("oidc provider %s is missing the issuer or client ID, skipping", name)
+			continue
+		}
+
+		providers[name] = &provider{issuer: p.Issuer, clientID: p.ClientID}
+	}
+
+	return &DefaultVerifier{
+		providers:  providers,
+		httpClient: &http.Client{Timeout: httpClientTimeout, Transport: tracing.NewTransport(http.DefaultTransport)},
+	}, true
+}
@@ -0,0 +1,231 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const testClientID = "my-client-id"
+
+// testIssuer is a local OpenID provider serving discovery and JWKS documents
+type testIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	jwksCalls atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksCalls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": issuer.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+			}},
+		})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims() map[string]any {
+	return map[string]any{
+		"iss":            i.server.URL,
+		"sub":            "subject-1",
+		"aud":            testClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "nonce-1",
+		"email":          "John@Example.com",
+		"email_verified": true,
+		"given_name":     "John",
+		"family_name":    "Doe",
+	}
+}
+
+func (i *testIssuer) verifier(t *testing.T) Verifier {
+	verifier, ok := NewVerifier(config.OIDCConfigurations{
+		Providers: map[string]config.OIDCProviderConfigurations{
+			"local": {Issuer: i.server.URL, ClientID: testClientID},
+		},
+	})
+	require.True(t, ok)
+
+	return verifier
+}
+
+func TestVerifyValidToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+
+	claims, ok := verifier.Verify(ctx, "local", issuer.sign(t, issuer.kid, issuer.claims()), "nonce-1")
+
+	require.True(t, ok)
+	assert.Equal(t, "subject-1", claims.Subject)
+	assert.Equal(t, "John@Example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "John", claims.GivenName)
+}
+
+func TestVerifyCachesKeys(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+	token := issuer.sign(t, issuer.kid, issuer.claims())
+
+	for i := 0; i < 3; i++ {
+		_, ok := verifier.Verify(ctx, "local", token, "nonce-1")
+		assert.True(t, ok)
+	}
+
+	assert.Equal(t, int32(1), issuer.jwksCalls.Load())
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+
+	tests := []struct {
+		name     string
+		provider string
+		mutate   func(claims map[string]any)
+		kid      string
+		nonce    string
+		noNonce  bool
+	}{
+		{name: "unknown provider", provider: "other"},
+		{name: "wrong issuer", mutate: func(c map[string]any) { c["iss"] = "https://evil.example.com" }},
+		{name: "wrong audience", mutate: func(c map[string]any) { c["aud"] = "someone-else" }},
+		{name: "multiple audiences without azp", mutate: func(c map[string]any) { c["aud"] = []string{testClientID, "other"} }},
+		{name: "expired", mutate: func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "not yet valid", mutate: func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+		{name: "nonce mismatch", nonce: "another-nonce"},
+		{name: "nonce missing", noNonce: true},
+		{name: "nonce missing in the token", mutate: func(c map[string]any) { delete(c, "nonce") }},
+		{name: "unknown key", kid: "key-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issuer.claims()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+			provider := "local"
+			if tt.provider != "" {
+				provider = tt.provider
+			}
+			kid := issuer.kid
+			if tt.kid != "" {
+				kid = tt.kid
+			}
+
+			nonce := "nonce-1"
+			if tt.nonce != "" || tt.noNonce {
+				nonce = tt.nonce
+			}
+
+			result, ok := verifier.Verify(ctx, provider, issuer.sign(t, kid, claims), nonce)
+
+			assert.False(t, ok)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestVerifyRejectsTamperedSignature(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+
+	token := issuer.sign(t, issuer.kid, issuer.claims())
+	forged := issuer.sign(t, issuer.kid, map[string]any{"sub": "admin"})
+
+	// Keep the original signature on a different payload
+	tampered := forged[:strings.LastIndex(forged, ".")] + token[strings.LastIndex(token, "."):]
+
+	claims, ok := verifier.Verify(ctx, "local", tampered, "nonce-1")
+
+	assert.False(t, ok)
+	assert.Nil(t, claims)
+}
+
+func TestVerifyRejectsAlgNone(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": issuer.kid})
+	payload, _ := json.Marshal(issuer.claims())
+	token := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+
+	claims, ok := verifier.Verify(ctx, "local", token, "nonce-1")
+
+	assert.False(t, ok)
+	assert.Nil(t, claims)
+}
+
+func TestEndpoints(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier(t)
+
+	endpoints, ok := verifier.Endpoints(ctx, "local")
+
+	require.True(t, ok)
+	assert.Equal(t, issuer.server.URL+"/authorize", endpoints.AuthorizationURL)
+	assert.Equal(t, issuer.server.URL+"/token", endpoints.TokenURL)
+}
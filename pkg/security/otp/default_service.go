@@ -4,7 +4,8 @@ import (
 	"context"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/metrics"
 	"github.com/zeusito/toci/pkg/toolbox"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
 )
@@ -22,17 +23,19 @@ func (s *DefaultManager) GenerateCode(ctx context.Context, length int, kind Code
 
 	hashedCode, err := s.hashingAlgo.Hash(code)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to hash code")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to hash code")
 		return "", false
 	}
 
 	// Persist the OTP
 	err = s.storage.Put(ctx, kind, principal, hashedCode, now.Add(s.expirationDuration))
 	if err != nil {
-		log.Error().Err(err).Msg("failed to persist OTP")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to persist OTP")
 		return "", false
 	}
 
+	metrics.OTPIssued.WithLabelValues(string(kind)).Inc()
+
 	return code, true
 }
 
@@ -40,24 +43,22 @@ func (s *DefaultManager) GenerateCode(ctx context.Context, length int, kind Code
 // By default, only the last code from the combined kind and principal is valid.
 // Expiration is checked at the storage level.
 func (s *DefaultManager) VerifyCode(ctx context.Context, kind CodeKind, principal string, code string) bool {
-	hashedCode, err := s.hashingAlgo.Hash(code)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to hash code")
-		return false
-	}
-
 	record, err := s.storage.Get(ctx, kind, principal)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to retrieve OTP")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to retrieve OTP")
+		metrics.OTPVerified.WithLabelValues(string(kind), metrics.ResultFailure).Inc()
 		return false
 	}
 
-	// check if the hashes match
-	if record.ID != hashedCode {
-		log.Error().Msg("hashes do not match")
+	// check if the hashes match, the code may have been hashed by a key that was rotated since
+	if !s.hashingAlgo.Verify(code, record.ID) {
+		logger.Ctx(ctx).Error().Msg("hashes do not match")
+		metrics.OTPVerified.WithLabelValues(string(kind), metrics.ResultFailure).Inc()
 		return false
 	}
 
+	metrics.OTPVerified.WithLabelValues(string(kind), metrics.ResultSuccess).Inc()
+
 	return true
 }
 
@@ -65,7 +66,7 @@ func (s *DefaultManager) VerifyCode(ctx context.Context, kind CodeKind, principa
 func (s *DefaultManager) Remove(ctx context.Context, kind CodeKind, principal string) bool {
 	err := s.storage.Remove(ctx, kind, principal)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to remove OTP")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to remove OTP")
 		return false
 	}
 
@@ -4,7 +4,6 @@ import (
 	"context"
 	"time"
 
-	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
 )
@@ -14,6 +13,7 @@ type CodeKind string
 const (
 	CodeKindUserPassword     CodeKind = "user_password"
 	CodeKindEmployeePassword CodeKind = "employee_password"
+	CodeKindPasswordReset    CodeKind = "password_reset"
 )
 
 // otpData internal struct used to store OTP data, not exposed to the outside world
@@ -36,18 +36,15 @@ type Storage interface {
 	Remove(ctx context.Context, kind CodeKind, principal string) error
 }
 
-func NewManagerWithPgSQLStorage(db *bun.DB, hasherSecret string) (Manager, bool) {
-	theHasher, err := hasher.NewHmacSHA256(hasherSecret)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create hasher")
-		return nil, false
-	}
+// DefaultExpiration how long a generated code remains valid
+const DefaultExpiration = 5 * time.Minute
 
+func NewManagerWithPgSQLStorage(db *bun.DB, theHasher hasher.Hasher) (Manager, bool) {
 	storage := NewPgSQLStore(db)
 
-	return &DefaultManager{
+	return NewTracingManager(&DefaultManager{
 		hashingAlgo:        theHasher,
 		storage:            storage,
-		expirationDuration: 5 * time.Minute,
-	}, true
+		expirationDuration: DefaultExpiration,
+	}), true
 }
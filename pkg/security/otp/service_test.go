@@ -8,7 +8,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
+	"github.com/zeusito/toci/pkg/tracing/tracingtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestDefaultManager_GenerateOTP(t *testing.T) {
@@ -77,9 +82,6 @@ func TestDefaultManager_Validate(t *testing.T) {
 		}
 
 		// Expectations
-		mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).
-			Return(hashedCode, nil).Times(1)
-
 		mockStorage.EXPECT().Get(ctx, kind, principal).
 			Return(&otpData{
 				ID:        hashedCode,
@@ -88,12 +90,14 @@ func TestDefaultManager_Validate(t *testing.T) {
 				ExpiresAt: time.Now().UTC().Add(time.Minute),
 			}, nil).Times(1)
 
+		mockHasher.EXPECT().Verify(code, hashedCode).Return(true).Times(1)
+
 		ok := manager.VerifyCode(ctx, kind, principal, code)
 
 		assert.True(t, ok)
 	})
 
-	t.Run("validation fails when hashing fails", func(t *testing.T) {
+	t.Run("validation fails when the hashes do not match", func(t *testing.T) {
 		mockStorage := NewMockStorage(t)
 		mockHasher := hasher.NewMockHasher(t)
 		manager := &DefaultManager{
@@ -103,8 +107,15 @@ func TestDefaultManager_Validate(t *testing.T) {
 		}
 
 		// Expectations
-		mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).
-			Return("", errors.New("hashing error")).Times(1)
+		mockStorage.EXPECT().Get(ctx, kind, principal).
+			Return(&otpData{
+				ID:        hashedCode,
+				Kind:      kind,
+				Principal: principal,
+				ExpiresAt: time.Now().UTC().Add(time.Minute),
+			}, nil).Times(1)
+
+		mockHasher.EXPECT().Verify(code, hashedCode).Return(false).Times(1)
 
 		ok := manager.VerifyCode(ctx, kind, principal, code)
 
@@ -121,9 +132,6 @@ func TestDefaultManager_Validate(t *testing.T) {
 		}
 
 		// Expectations
-		mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).
-			Return(hashedCode, nil).Times(1)
-
 		mockStorage.EXPECT().Get(ctx, kind, principal).
 			Return(nil, errors.New("record not found")).Times(1)
 
@@ -171,3 +179,44 @@ func TestDefaultManager_Remove(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestTracingManager(t *testing.T) {
+	kind := CodeKindUserPassword
+	principal := "john@example.com"
+
+	t.Run("starts a span passed down to the manager", func(t *testing.T) {
+		exporter := tracingtest.Exporter(t)
+		next := NewMockManager(t)
+		manager := NewTracingManager(next)
+
+		next.EXPECT().GenerateCode(mock.MatchedBy(func(ctx context.Context) bool {
+			return trace.SpanContextFromContext(ctx).IsValid()
+		}), 6, kind, principal).Return("123456", true)
+
+		code, ok := manager.GenerateCode(context.Background(), 6, kind, principal)
+
+		assert.True(t, ok)
+		assert.Equal(t, "123456", code)
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "otp.GenerateCode", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, attribute.String("otp.kind", string(kind)))
+		assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	})
+
+	t.Run("marks the span of a failed call as an error", func(t *testing.T) {
+		exporter := tracingtest.Exporter(t)
+		next := NewMockManager(t)
+		manager := NewTracingManager(next)
+
+		next.EXPECT().VerifyCode(mock.Anything, kind, principal, "000000").Return(false)
+
+		ok := manager.VerifyCode(context.Background(), kind, principal, "000000")
+
+		assert.False(t, ok)
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "otp.VerifyCode", spans[0].Name)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
@@ -0,0 +1,49 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/zeusito/toci/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/zeusito/toci/pkg/security/otp")
+
+// TracingManager starts a span around each call of the manager it decorates
+type TracingManager struct {
+	next Manager
+}
+
+func NewTracingManager(next Manager) Manager {
+	return &TracingManager{next: next}
+}
+
+func (m *TracingManager) GenerateCode(ctx context.Context, length int, kind CodeKind, principal string) (string, bool) {
+	ctx, span := tracer.Start(ctx, "otp.GenerateCode", trace.WithAttributes(kindAttribute(kind)))
+	code, ok := m.next.GenerateCode(ctx, length, kind, principal)
+	tracing.End(span, ok)
+
+	return code, ok
+}
+
+func (m *TracingManager) VerifyCode(ctx context.Context, kind CodeKind, principal string, code string) bool {
+	ctx, span := tracer.Start(ctx, "otp.VerifyCode", trace.WithAttributes(kindAttribute(kind)))
+	ok := m.next.VerifyCode(ctx, kind, principal, code)
+	tracing.End(span, ok)
+
+	return ok
+}
+
+func (m *TracingManager) Remove(ctx context.Context, kind CodeKind, principal string) bool {
+	ctx, span := tracer.Start(ctx, "otp.Remove", trace.WithAttributes(kindAttribute(kind)))
+	ok := m.next.Remove(ctx, kind, principal)
+	tracing.End(span, ok)
+
+	return ok
+}
+
+func kindAttribute(kind CodeKind) attribute.KeyValue {
+	return attribute.String("otp.kind", string(kind))
+}
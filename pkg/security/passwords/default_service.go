@@ -0,0 +1,139 @@
+package passwords
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+type policy struct {
+	minLength   int
+	maxLength   int
+	historySize int
+	breached    map[string]struct{}
+}
+
+// validate checks the rules that do not depend on the principal
+func (p *policy) validate(password string) error {
+	length := utf8.RuneCountInString(password)
+
+	if length < p.minLength {
+		return ErrTooShort
+	}
+
+	if length > p.maxLength {
+		return ErrTooLong
+	}
+
+	if _, ok := p.breached[password]; ok {
+		return ErrBreached
+	}
+
+	return nil
+}
+
+type DefaultManager struct {
+	storage Storage
+	hasher  hasher.Hasher
+	policy  *policy
+	// verified when the principal has no password, so both cases take the same time
+	dummyHash string
+}
+
+// Set validates and stores a new password, rejecting the current and the recent ones
+func (m *DefaultManager) Set(ctx context.Context, principalID, password string) error {
+	err := m.policy.validate(password)
+	if err != nil {
+		return err
+	}
+
+	previous, err := m.recentHashes(ctx, principalID)
+	if err != nil {
+		return err
+	}
+
+	for _, hashed := range previous {
+		if m.hasher.Verify(password, hashed) {
+			return ErrReused
+		}
+	}
+
+	hashed, err := m.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return m.storage.Put(ctx, principalID, hashed, m.policy.historySize)
+}
+
+// Verify compares the password against the stored hash
+func (m *DefaultManager) Verify(ctx context.Context, principalID, password string) bool {
+	credential, err := m.storage.Get(ctx, principalID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.Ctx(ctx).Warn().Err(err).Msg("failed to retrieve password")
+		}
+		_ = m.hasher.Verify(password, m.dummyHash)
+		return false
+	}
+
+	if !m.hasher.Verify(password, credential.HashedPassword) {
+		return false
+	}
+
+	// The plain password is only known now, upgrade legacy hashes and outdated parameters
+	if m.hasher.NeedsRehash(credential.HashedPassword) {
+		m.rehash(ctx, principalID, password, credential.HashedPassword)
+	}
+
+	return true
+}
+
+// rehash is best effort, the login must not fail because of it
+func (m *DefaultManager) rehash(ctx context.Context, principalID, password, currentHash string) {
+	newHash, err := m.hasher.Hash(password)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to rehash password")
+		return
+	}
+
+	err = m.storage.Rehash(ctx, principalID, currentHash, newHash)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to store rehashed password")
+	}
+}
+
+// Exists tells whether the principal has a password
+func (m *DefaultManager) Exists(ctx context.Context, principalID string) bool {
+	_, err := m.storage.Get(ctx, principalID)
+
+	return err == nil
+}
+
+// recentHashes returns the current hash followed by the ones in the history
+func (m *DefaultManager) recentHashes(ctx context.Context, principalID string) ([]string, error) {
+	var hashes []string
+
+	credential, err := m.storage.Get(ctx, principalID)
+	switch {
+	case err == nil:
+		hashes = append(hashes, credential.HashedPassword)
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	if m.policy.historySize <= 0 {
+		return hashes, nil
+	}
+
+	history, err := m.storage.ListHistory(ctx, principalID, m.policy.historySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(hashes, history...), nil
+}
@@ -0,0 +1,129 @@
+package passwords
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/toolbox"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+const (
+	defaultMinLength = 12
+	defaultMaxLength = 128
+)
+
+var (
+	ErrTooShort = errors.New("password is too short")
+	ErrTooLong  = errors.New("password is too long")
+	ErrBreached = errors.New("password appears in a list of breached passwords")
+	ErrReused   = errors.New("password was used recently")
+)
+
+// Credential the password of a principal, only the hash is ever stored
+type Credential struct {
+	PrincipalID    string
+	HashedPassword string
+	UpdatedAt      time.Time
+}
+
+type Manager interface {
+	// Set validates the password against the policy and replaces the current one. Policy violations
+	// are reported with one of the Err* errors.
+	Set(ctx context.Context, principalID, password string) error
+	Verify(ctx context.Context, principalID, password string) bool
+	Exists(ctx context.Context, principalID string) bool
+}
+
+type Storage interface {
+	Get(ctx context.Context, principalID string) (*Credential, error)
+	// Put replaces the current password, the previous one is kept in the history
+	Put(ctx context.Context, principalID, hashedPassword string, historySize int) error
+	// Rehash replaces the current hash only if it is still the given one
+	Rehash(ctx context.Context, principalID, currentHash, newHash string) error
+	// ListHistory returns the most recent previous hashes, newest first
+	ListHistory(ctx context.Context, principalID string, limit int) ([]string, error)
+}
+
+func NewManagerWithPgSQLStorage(db *bun.DB, cfg config.PasswordConfigurations, hasherCfg config.Argon2IdConfigurations) (Manager, bool) {
+	policy, ok := newPolicy(cfg)
+	if !ok {
+		return nil, false
+	}
+
+	theHasher, err := hasher.NewArgon2IdHasher(hasherCfg.Time, hasherCfg.MemoryKiB, hasherCfg.Threads, hasherCfg.KeyLength)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create hasher")
+		return nil, false
+	}
+
+	dummyHash, err := theHasher.Hash(toolbox.SecureRandomString(defaultMinLength))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create the dummy password hash")
+		return nil, false
+	}
+
+	return &DefaultManager{
+		storage:   NewPgSQLStorage(db),
+		hasher:    theHasher,
+		policy:    policy,
+		dummyHash: dummyHash,
+	}, true
+}
+
+func newPolicy(cfg config.PasswordConfigurations) (*policy, bool) {
+	p := &policy{
+		minLength:   cfg.MinLength,
+		maxLength:   cfg.MaxLength,
+		historySize: cfg.HistorySize,
+		breached:    map[string]struct{}{},
+	}
+
+	if p.minLength <= 0 {
+		p.minLength = defaultMinLength
+	}
+
+	if p.maxLength <= 0 {
+		p.maxLength = defaultMaxLength
+	}
+
+	if p.minLength > p.maxLength {
+		log.Error().Msgf("password min length %d is greater than the max length %d", p.minLength, p.maxLength)
+		return nil, false
+	}
+
+	if cfg.BreachedListFile == "" {
+		return p, true
+	}
+
+	file, err := os.Open(cfg.BreachedListFile)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to open the breached passwords list")
+		return nil, false
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			p.breached[line] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to read the breached passwords list")
+		return nil, false
+	}
+
+	log.Info().Msgf("loaded %d breached passwords", len(p.breached))
+
+	return p, true
+}
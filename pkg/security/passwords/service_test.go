@@ -0,0 +1,113 @@
+package passwords
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/toolbox/hasher"
+)
+
+func setupManager(t *testing.T, cfg config.PasswordConfigurations) (*DefaultManager, *MockStorage) {
+	policy, ok := newPolicy(cfg)
+	require.True(t, ok)
+
+	theHasher := hasher.NewArgon2IdHasherWithSaneDefaults()
+	dummyHash, err := theHasher.Hash("dummy-password")
+	require.NoError(t, err)
+
+	storage := NewMockStorage(t)
+
+	return &DefaultManager{storage: storage, hasher: theHasher, policy: policy, dummyHash: dummyHash}, storage
+}
+
+func TestNewPolicyLoadsBreachedList(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(file, []byte("password1234\n\n  qwertyuiop12  \n"), 0o600))
+
+	policy, ok := newPolicy(config.PasswordConfigurations{BreachedListFile: file})
+
+	require.True(t, ok)
+	assert.Equal(t, defaultMinLength, policy.minLength)
+	assert.Len(t, policy.breached, 2)
+	assert.ErrorIs(t, policy.validate("qwertyuiop12"), ErrBreached)
+}
+
+func TestNewPolicyInvalid(t *testing.T) {
+	_, ok := newPolicy(config.PasswordConfigurations{MinLength: 20, MaxLength: 10})
+	assert.False(t, ok)
+
+	_, ok = newPolicy(config.PasswordConfigurations{BreachedListFile: "/does/not/exist"})
+	assert.False(t, ok)
+}
+
+func TestSetRejectsPolicyViolations(t *testing.T) {
+	manager, _ := setupManager(t, config.PasswordConfigurations{MinLength: 8, MaxLength: 16})
+
+	assert.ErrorIs(t, manager.Set(context.Background(), "1", "short"), ErrTooShort)
+	assert.ErrorIs(t, manager.Set(context.Background(), "1", "this-password-is-too-long"), ErrTooLong)
+}
+
+func TestSetRejectsRecentPasswords(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := setupManager(t, config.PasswordConfigurations{HistorySize: 2})
+
+	current, err := manager.hasher.Hash("current-password")
+	require.NoError(t, err)
+	previous, err := manager.hasher.Hash("previous-password")
+	require.NoError(t, err)
+
+	storage.EXPECT().Get(ctx, "1").Return(&Credential{PrincipalID: "1", HashedPassword: current}, nil)
+	storage.EXPECT().ListHistory(ctx, "1", 2).Return([]string{previous}, nil)
+
+	assert.ErrorIs(t, manager.Set(ctx, "1", "current-password"), ErrReused)
+	assert.ErrorIs(t, manager.Set(ctx, "1", "previous-password"), ErrReused)
+}
+
+func TestSetStoresNewPassword(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := setupManager(t, config.PasswordConfigurations{HistorySize: 2})
+
+	storage.EXPECT().Get(ctx, "1").Return(nil, sql.ErrNoRows)
+	storage.EXPECT().ListHistory(ctx, "1", 2).Return(nil, nil)
+	storage.EXPECT().Put(ctx, "1", mock.MatchedBy(func(hashed string) bool {
+		return manager.hasher.Verify("a-brand-new-password", hashed)
+	}), 2).Return(nil)
+
+	assert.NoError(t, manager.Set(ctx, "1", "a-brand-new-password"))
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := setupManager(t, config.PasswordConfigurations{})
+
+	hashed, err := manager.hasher.Hash("current-password")
+	require.NoError(t, err)
+
+	storage.EXPECT().Get(ctx, "1").Return(&Credential{PrincipalID: "1", HashedPassword: hashed}, nil)
+	storage.EXPECT().Get(ctx, "2").Return(nil, sql.ErrNoRows)
+
+	assert.True(t, manager.Verify(ctx, "1", "current-password"))
+	assert.False(t, manager.Verify(ctx, "1", "wrong-password"))
+	assert.False(t, manager.Verify(ctx, "2", "current-password"))
+}
+
+func TestVerifyRehashesOutdatedHash(t *testing.T) {
+	ctx := context.Background()
+	manager, storage := setupManager(t, config.PasswordConfigurations{})
+
+	outdated := "$argon2id$v=19$m=16,t=2,p=1$N1FkeWl6S0RaTzZPVkpTdQ$NZwZ/YEeSD+oKh3TOMqDDg"
+
+	storage.EXPECT().Get(ctx, "1").Return(&Credential{PrincipalID: "1", HashedPassword: outdated}, nil)
+	storage.EXPECT().Rehash(ctx, "1", outdated, mock.MatchedBy(func(hashed string) bool {
+		return !manager.hasher.NeedsRehash(hashed) && manager.hasher.Verify("1234567890", hashed)
+	})).Return(nil)
+
+	assert.True(t, manager.Verify(ctx, "1", "1234567890"))
+}
@@ -0,0 +1,137 @@
+package passwords
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PasswordRecord the database model for the current password of an identity
+type PasswordRecord struct {
+	bun.BaseModel  `bun:"table:identity_passwords,alias:ipw"`
+	IdentityID     string    `bun:"identity_id,pk"`
+	HashedPassword string    `bun:"hashed_password"`
+	UpdatedAt      time.Time `bun:"updated_at"`
+}
+
+// PasswordHistoryRecord the database model for a previous password of an identity
+type PasswordHistoryRecord struct {
+	bun.BaseModel  `bun:"table:identity_password_history,alias:iph"`
+	ID             int64     `bun:"id,pk,autoincrement"`
+	IdentityID     string    `bun:"identity_id"`
+	HashedPassword string    `bun:"hashed_password"`
+	CreatedAt      time.Time `bun:"created_at"`
+}
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Get retrieves the current password of an identity
+func (s *PgSQLStorage) Get(ctx context.Context, principalID string) (*Credential, error) {
+	var record PasswordRecord
+
+	err := s.db.NewSelect().
+		Model(&record).
+		Where("identity_id = ?", principalID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		PrincipalID:    record.IdentityID,
+		HashedPassword: record.HashedPassword,
+		UpdatedAt:      record.UpdatedAt,
+	}, nil
+}
+
+// Put replaces the current password, moving the previous one to the history and trimming it
+func (s *PgSQLStorage) Put(ctx context.Context, principalID, hashedPassword string, historySize int) error {
+	now := time.Now().UTC()
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var previous PasswordRecord
+
+		// Lock the current password so concurrent changes are serialized
+		err := tx.NewSelect().
+			Model(&previous).
+			Where("identity_id = ?", principalID).
+			For("UPDATE").
+			Scan(ctx)
+
+		if err == nil && historySize > 0 {
+			_, err = tx.NewInsert().
+				Model(&PasswordHistoryRecord{
+					IdentityID:     principalID,
+					HashedPassword: previous.HashedPassword,
+					CreatedAt:      previous.UpdatedAt,
+				}).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.NewInsert().
+			Model(&PasswordRecord{
+				IdentityID:     principalID,
+				HashedPassword: hashedPassword,
+				UpdatedAt:      now,
+			}).
+			On("CONFLICT (identity_id) DO UPDATE").
+			Set("hashed_password = EXCLUDED.hashed_password").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Keep only the most recent entries
+		_, err = tx.NewDelete().
+			Model((*PasswordHistoryRecord)(nil)).
+			Where("identity_id = ?", principalID).
+			Where("id NOT IN (?)", tx.NewSelect().
+				Model((*PasswordHistoryRecord)(nil)).
+				Column("id").
+				Where("identity_id = ?", principalID).
+				Order("id DESC").
+				Limit(historySize)).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+// Rehash replaces the current hash, unless the password was changed in the meantime
+func (s *PgSQLStorage) Rehash(ctx context.Context, principalID, currentHash, newHash string) error {
+	_, err := s.db.NewUpdate().
+		Model((*PasswordRecord)(nil)).
+		Set("hashed_password = ?", newHash).
+		Where("identity_id = ?", principalID).
+		Where("hashed_password = ?", currentHash).
+		Exec(ctx)
+
+	return err
+}
+
+// ListHistory returns the most recent previous hashes, newest first
+func (s *PgSQLStorage) ListHistory(ctx context.Context, principalID string, limit int) ([]string, error) {
+	var hashes []string
+
+	err := s.db.NewSelect().
+		Model((*PasswordHistoryRecord)(nil)).
+		Column("hashed_password").
+		Where("identity_id = ?", principalID).
+		Order("id DESC").
+		Limit(limit).
+		Scan(ctx, &hashes)
+
+	return hashes, err
+}
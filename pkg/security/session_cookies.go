@@ -0,0 +1,108 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeusito/toci/pkg/config"
+)
+
+const (
+	// SessionCookie holds the session token, it is not readable by scripts
+	SessionCookie = "session"
+	// CSRFCookie holds the double-submit token, scripts echo it in the CSRFHeader of the unsafe requests
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// SessionCookies issues the session of browser clients in cookies
+type SessionCookies struct {
+	cfg config.SessionCookieConfigurations
+}
+
+func NewSessionCookies(cfg config.SessionCookieConfigurations) *SessionCookies {
+	return &SessionCookies{cfg: cfg}
+}
+
+func (c *SessionCookies) Enabled() bool {
+	return c.cfg.Enabled
+}
+
+// Set issues the session token and a new CSRF token, both expire with the session
+func (c *SessionCookies) Set(w http.ResponseWriter, token string, expiresAt time.Time) error {
+	csrfToken := make([]byte, 32)
+	if _, err := rand.Read(csrfToken); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, c.cookie(SessionCookie, token, expiresAt, true))
+	http.SetCookie(w, c.cookie(CSRFCookie, base64.RawURLEncoding.EncodeToString(csrfToken), expiresAt, false))
+
+	return nil
+}
+
+// Clear removes the cookies of the session, e.g. on logout
+func (c *SessionCookies) Clear(w http.ResponseWriter) {
+	for _, cookie := range []*http.Cookie{c.cookie(SessionCookie, "", time.Time{}, true), c.cookie(CSRFCookie, "", time.Time{}, false)} {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+func (c *SessionCookies) cookie(name, value string, expiresAt time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.cfg.Domain,
+		Expires:  expiresAt,
+		HttpOnly: httpOnly,
+		Secure:   true,
+		SameSite: sameSite(c.cfg.SameSite),
+	}
+}
+
+func sameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// SessionToken returns the bearer token of the request, or the token of its session cookie. The latter is sent by
+// the browser on its own, so the request must pass the CSRF check.
+func SessionToken(r *http.Request) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token, false
+	}
+
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+
+	return cookie.Value, true
+}
+
+// validCSRFToken the unsafe requests must echo the CSRF cookie in the header, which a cross-site page cannot read
+func validCSRFToken(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Header.Get(CSRFHeader))) == 1
+}
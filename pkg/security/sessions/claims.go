@@ -9,6 +9,12 @@ type ctxKeyAuthClaims int
 
 const PrincipalClaimsKey ctxKeyAuthClaims = 1
 
+// Roles granted to the principals
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // PrincipalClaims represents the claims of a principal, customize it as needed
 type PrincipalClaims struct {
 	IsAuthenticated bool     `json:"isAuthenticated"`
@@ -2,24 +2,28 @@ package sessions
 
 import (
 	"context"
+	"strconv"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/metrics"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
 )
 
 type DefaultManager struct {
 	storage     Storage
-	tokenHasher hasher.Hasher
+	tokenHasher hasher.Keyring
+	recorder    audit.Recorder
 }
 
 func (s *DefaultManager) CreateSession(ctx context.Context, data Session, expiresAt time.Time) (string, bool) {
-	log.Info().Msgf("Creating new session for principal %s", data.PrincipalID)
+	logger.Ctx(ctx).Info().Msgf("Creating new session for principal %s", data.PrincipalID)
 
 	// Create a new opaque token
 	token, hashedToken, err := NewOpaqueToken(s.tokenHasher)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to create new opaque token")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to create new opaque token")
 		return "", false
 	}
 
@@ -33,32 +37,57 @@ func (s *DefaultManager) CreateSession(ctx context.Context, data Session, expire
 
 	err = s.storage.Set(ctx, hashedToken, sessionData)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to persist session in storage")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to persist session in storage")
 		return "", false
 	}
 
+	metrics.SessionsCreated.Inc()
+	s.recorder.Record(ctx, audit.Event{
+		Action:     audit.ActionSessionCreate,
+		ActorID:    data.PrincipalID,
+		TargetType: audit.TargetIdentity,
+		TargetID:   data.PrincipalID,
+		Result:     audit.ResultSuccess,
+		Metadata:   map[string]string{"expiresAt": expiresAt.UTC().Format(time.RFC3339)},
+	})
+
 	// Return the opaque token string
 	return token, true
 }
 
 func (s *DefaultManager) GetSession(ctx context.Context, token string) (*Session, bool) {
-	log.Info().Msgf("Getting session from token...")
+	logger.Ctx(ctx).Info().Msgf("Getting session from token...")
 
-	hashedToken, err := s.tokenHasher.Hash(token)
+	hashedTokens, err := s.tokenHasher.HashAll(token)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to decode token")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to decode token")
 		return nil, false
 	}
 
-	record, err := s.storage.Get(ctx, hashedToken)
+	// The token may have been hashed by a key that was rotated since
+	var record *Session
+	for i, hashedToken := range hashedTokens {
+		record, err = s.storage.Get(ctx, hashedToken)
+		if err != nil {
+			continue
+		}
+
+		// Lazily re-key the session with the primary key
+		if i > 0 {
+			if rekeyErr := s.storage.Rekey(ctx, hashedToken, hashedTokens[0]); rekeyErr != nil {
+				logger.Ctx(ctx).Warn().Err(rekeyErr).Msg("Failed to re-key session")
+			}
+		}
+		break
+	}
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to get session from storage")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to get session from storage")
 		return nil, false
 	}
 
 	// verify if the session is expired
 	if record.ExpiresAt.Before(time.Now().UTC()) {
-		log.Warn().Msg("Session is expired")
+		logger.Ctx(ctx).Warn().Msg("Session is expired")
 		return nil, false
 	}
 
@@ -66,26 +95,63 @@ func (s *DefaultManager) GetSession(ctx context.Context, token string) (*Session
 }
 
 func (s *DefaultManager) RemoveSession(ctx context.Context, token string) bool {
-	log.Info().Msgf("Removing session...")
+	logger.Ctx(ctx).Info().Msgf("Removing session...")
 
-	hashedToken, err := s.tokenHasher.Hash(token)
+	hashedTokens, err := s.tokenHasher.HashAll(token)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to decode token")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to decode token")
 		return false
 	}
 
-	// Remove the session from storage
-	err = s.storage.Remove(ctx, hashedToken)
+	// Remove the session from storage, whichever key hashed it
+	for _, hashedToken := range hashedTokens {
+		principalID, err := s.storage.Remove(ctx, hashedToken)
+		if err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msg("Failed to remove session from storage")
+			return false
+		}
+
+		if principalID != "" {
+			metrics.SessionsRevoked.Inc()
+			s.recorder.Record(ctx, audit.Event{
+				Action:     audit.ActionSessionRevoke,
+				ActorID:    principalID,
+				TargetType: audit.TargetIdentity,
+				TargetID:   principalID,
+				Result:     audit.ResultSuccess,
+			})
+		}
+	}
+
+	return true
+}
+
+func (s *DefaultManager) RemoveAllSessions(ctx context.Context, principalID string) bool {
+	logger.Ctx(ctx).Info().Msgf("Removing all sessions of principal %s", principalID)
+
+	removed, err := s.storage.RemoveAll(ctx, principalID)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to remove session from storage")
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to remove sessions from storage")
 		return false
 	}
 
+	if removed > 0 {
+		metrics.SessionsRevoked.Add(float64(removed))
+		s.recorder.Record(ctx, audit.Event{
+			Action:     audit.ActionSessionRevoke,
+			ActorID:    principalID,
+			TargetType: audit.TargetIdentity,
+			TargetID:   principalID,
+			Result:     audit.ResultSuccess,
+			Metadata:   map[string]string{"sessions": strconv.Itoa(removed)},
+		})
+	}
+
 	return true
 }
 
 func (s *DefaultManager) CleanUpExpiredSessions(ctx context.Context) {
-	log.Info().Msg("Cleaning up expired sessions...")
+	logger.Ctx(ctx).Info().Msg("Cleaning up expired sessions...")
 
 	// TODO: Implement clean up expired sessions
 }
@@ -218,6 +218,63 @@ func (_c *MockManager_GetSession_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
+// RemoveAllSessions provides a mock function for the type MockManager
+func (_mock *MockManager) RemoveAllSessions(ctx context.Context, principalID string) bool {
+	ret := _mock.Called(ctx, principalID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAllSessions")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = returnFunc(ctx, principalID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockManager_RemoveAllSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAllSessions'
+type MockManager_RemoveAllSessions_Call struct {
+	*mock.Call
+}
+
+// RemoveAllSessions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+func (_e *MockManager_Expecter) RemoveAllSessions(ctx interface{}, principalID interface{}) *MockManager_RemoveAllSessions_Call {
+	return &MockManager_RemoveAllSessions_Call{Call: _e.mock.On("RemoveAllSessions", ctx, principalID)}
+}
+
+func (_c *MockManager_RemoveAllSessions_Call) Run(run func(ctx context.Context, principalID string)) *MockManager_RemoveAllSessions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockManager_RemoveAllSessions_Call) Return(b bool) *MockManager_RemoveAllSessions_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockManager_RemoveAllSessions_Call) RunAndReturn(run func(ctx context.Context, principalID string) bool) *MockManager_RemoveAllSessions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RemoveSession provides a mock function for the type MockManager
 func (_mock *MockManager) RemoveSession(ctx context.Context, token string) bool {
 	ret := _mock.Called(ctx, token)
@@ -370,21 +427,93 @@ func (_c *MockStorage_Get_Call) RunAndReturn(run func(ctx context.Context, hashe
 	return _c
 }
 
+// Rekey provides a mock function for the type MockStorage
+func (_mock *MockStorage) Rekey(ctx context.Context, hashedID string, newHashedID string) error {
+	ret := _mock.Called(ctx, hashedID, newHashedID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rekey")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, hashedID, newHashedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStorage_Rekey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rekey'
+type MockStorage_Rekey_Call struct {
+	*mock.Call
+}
+
+// Rekey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hashedID string
+//   - newHashedID string
+func (_e *MockStorage_Expecter) Rekey(ctx interface{}, hashedID interface{}, newHashedID interface{}) *MockStorage_Rekey_Call {
+	return &MockStorage_Rekey_Call{Call: _e.mock.On("Rekey", ctx, hashedID, newHashedID)}
+}
+
+func (_c *MockStorage_Rekey_Call) Run(run func(ctx context.Context, hashedID string, newHashedID string)) *MockStorage_Rekey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStorage_Rekey_Call) Return(err error) *MockStorage_Rekey_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStorage_Rekey_Call) RunAndReturn(run func(ctx context.Context, hashedID string, newHashedID string) error) *MockStorage_Rekey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Remove provides a mock function for the type MockStorage
-func (_mock *MockStorage) Remove(ctx context.Context, hashedID string) error {
+func (_mock *MockStorage) Remove(ctx context.Context, hashedID string) (string, error) {
 	ret := _mock.Called(ctx, hashedID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Remove")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return returnFunc(ctx, hashedID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
 		r0 = returnFunc(ctx, hashedID)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, hashedID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
 // MockStorage_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
@@ -417,12 +546,78 @@ func (_c *MockStorage_Remove_Call) Run(run func(ctx context.Context, hashedID st
 	return _c
 }
 
-func (_c *MockStorage_Remove_Call) Return(err error) *MockStorage_Remove_Call {
-	_c.Call.Return(err)
+func (_c *MockStorage_Remove_Call) Return(s string, err error) *MockStorage_Remove_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockStorage_Remove_Call) RunAndReturn(run func(ctx context.Context, hashedID string) (string, error)) *MockStorage_Remove_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveAll provides a mock function for the type MockStorage
+func (_mock *MockStorage) RemoveAll(ctx context.Context, principalID string) (int, error) {
+	ret := _mock.Called(ctx, principalID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAll")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, principalID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, principalID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, principalID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_RemoveAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAll'
+type MockStorage_RemoveAll_Call struct {
+	*mock.Call
+}
+
+// RemoveAll is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principalID string
+func (_e *MockStorage_Expecter) RemoveAll(ctx interface{}, principalID interface{}) *MockStorage_RemoveAll_Call {
+	return &MockStorage_RemoveAll_Call{Call: _e.mock.On("RemoveAll", ctx, principalID)}
+}
+
+func (_c *MockStorage_RemoveAll_Call) Run(run func(ctx context.Context, principalID string)) *MockStorage_RemoveAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStorage_RemoveAll_Call) Return(n int, err error) *MockStorage_RemoveAll_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockStorage_Remove_Call) RunAndReturn(run func(ctx context.Context, hashedID string) error) *MockStorage_Remove_Call {
+func (_c *MockStorage_RemoveAll_Call) RunAndReturn(run func(ctx context.Context, principalID string) (int, error)) *MockStorage_RemoveAll_Call {
 	_c.Call.Return(run)
 	return _c
 }
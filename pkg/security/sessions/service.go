@@ -4,8 +4,9 @@ import (
 	"context"
 	"time"
 
-	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/audit"
+	"github.com/zeusito/toci/pkg/events"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
 )
 
@@ -22,24 +23,26 @@ type Manager interface {
 	CreateSession(ctx context.Context, data Session, expiresAt time.Time) (string, bool)
 	GetSession(ctx context.Context, token string) (*Session, bool)
 	RemoveSession(ctx context.Context, token string) bool
+	// RemoveAllSessions revokes every session of the principal, e.g. once its password was reset
+	RemoveAllSessions(ctx context.Context, principalID string) bool
 	CleanUpExpiredSessions(ctx context.Context)
 }
 
 type Storage interface {
 	Set(ctx context.Context, hashedID string, data *Session) error
 	Get(ctx context.Context, hashedID string) (*Session, error)
-	Remove(ctx context.Context, hashedID string) error
+	// Remove returns the principal of the removed session, empty when there was none
+	Remove(ctx context.Context, hashedID string) (string, error)
+	// RemoveAll removes the sessions of a principal and returns how many there were
+	RemoveAll(ctx context.Context, principalID string) (int, error)
+	// Rekey replaces the hashed ID of a session, used when the hashing key was rotated
+	Rekey(ctx context.Context, hashedID, newHashedID string) error
 }
 
-func NewManagerWithPgSQLStorage(db *bun.DB, hasherSecret string) (Manager, bool) {
-	theHasher, err := hasher.NewHmacSHA256(hasherSecret)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create hasher")
-		return nil, false
-	}
-
-	return &DefaultManager{
-		storage:     NewPgSQLStorage(db),
+func NewManagerWithPgSQLStorage(db *bun.DB, theHasher hasher.Keyring, outbox events.Outbox, recorder audit.Recorder) (Manager, bool) {
+	return NewTracingManager(&DefaultManager{
+		storage:     NewPgSQLStorage(db, outbox),
 		tokenHasher: theHasher,
-	}, true
+		recorder:    recorder,
+	}), true
 }
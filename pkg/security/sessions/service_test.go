@@ -8,15 +8,17 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/zeusito/toci/pkg/audit"
 	"github.com/zeusito/toci/pkg/toolbox/hasher"
 )
 
 func TestNewSessionFailedToCreateToken(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 
 	ctx := context.Background()
@@ -40,10 +42,11 @@ func TestNewSessionFailedToCreateToken(t *testing.T) {
 
 func TestNewSessionFailedToPersist(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 
 	ctx := context.Background()
@@ -70,10 +73,11 @@ func TestNewSessionFailedToPersist(t *testing.T) {
 
 func TestNewSession(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 
 	ctx := context.Background()
@@ -90,6 +94,10 @@ func TestNewSession(t *testing.T) {
 	mockStorage.EXPECT().Set(ctx, mock.AnythingOfType("string"), mock.Anything).
 		Return(nil).Once()
 
+	service.recorder.(*audit.MockRecorder).EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionSessionCreate && event.ActorID == "aud_id"
+	})).Once()
+
 	// Execute
 	token, ok := service.CreateSession(ctx, sessionData, expiration)
 
@@ -99,16 +107,17 @@ func TestNewSession(t *testing.T) {
 
 func TestGetSessionFailedToHashToken(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).
-		Return("", errors.New("failed to hash")).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).
+		Return(nil, errors.New("failed to hash")).Once()
 
 	// Execute
 	record, ok := service.GetSession(ctx, "token")
@@ -119,15 +128,16 @@ func TestGetSessionFailedToHashToken(t *testing.T) {
 
 func TestGetSessionFailedToRetrieve(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).Return("hashed_token", nil).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"hashed_token"}, nil).Once()
 
 	mockStorage.EXPECT().Get(ctx, mock.AnythingOfType("string")).
 		Return(nil, errors.New("failed to retrieve")).Once()
@@ -141,15 +151,16 @@ func TestGetSessionFailedToRetrieve(t *testing.T) {
 
 func TestGetSessionAlreadyExpired(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).Return("hashed_token", nil).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"hashed_token"}, nil).Once()
 
 	mockStorage.EXPECT().Get(ctx, mock.AnythingOfType("string")).
 		Return(&Session{ExpiresAt: time.Now().Add(-time.Hour)}, nil).Once()
@@ -163,15 +174,16 @@ func TestGetSessionAlreadyExpired(t *testing.T) {
 
 func TestGetSession(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).Return("hashed_token", nil).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"hashed_token"}, nil).Once()
 
 	mockStorage.EXPECT().Get(ctx, mock.AnythingOfType("string")).
 		Return(&Session{PrincipalID: "aud_id", Metadata: SessionMetadata{"role1": "role2"}, ExpiresAt: time.Now().Add(time.Hour)}, nil).Once()
@@ -185,16 +197,17 @@ func TestGetSession(t *testing.T) {
 
 func TestDeleteSessionFailedToHashToken(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).
-		Return("", errors.New("failed to hash")).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).
+		Return(nil, errors.New("failed to hash")).Once()
 
 	// Execute
 	ok := service.RemoveSession(ctx, "token")
@@ -204,18 +217,19 @@ func TestDeleteSessionFailedToHashToken(t *testing.T) {
 
 func TestDeleteSessionFailedToRetrieve(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).Return("hashed_token", nil).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"hashed_token"}, nil).Once()
 
 	mockStorage.EXPECT().Remove(ctx, mock.AnythingOfType("string")).
-		Return(errors.New("failed to remove")).Once()
+		Return("", errors.New("failed to remove")).Once()
 
 	// Execute
 	ok := service.RemoveSession(ctx, "token")
@@ -225,21 +239,91 @@ func TestDeleteSessionFailedToRetrieve(t *testing.T) {
 
 func TestDeleteSession(t *testing.T) {
 	mockStorage := NewMockStorage(t)
-	mockHasher := hasher.NewMockHasher(t)
+	mockHasher := hasher.NewMockKeyring(t)
 	service := &DefaultManager{
 		storage:     mockStorage,
 		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
 	}
 	ctx := context.Background()
 
 	// Expectations
-	mockHasher.EXPECT().Hash(mock.AnythingOfType("string")).Return("hashed_token", nil).Once()
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"hashed_token"}, nil).Once()
 
 	mockStorage.EXPECT().Remove(ctx, mock.AnythingOfType("string")).
-		Return(nil).Once()
+		Return("aud_id", nil).Once()
+
+	service.recorder.(*audit.MockRecorder).EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionSessionRevoke && event.TargetID == "aud_id"
+	})).Once()
 
 	// Execute
 	ok := service.RemoveSession(ctx, "token")
 
 	assert.True(t, ok)
 }
+
+func TestRemoveAllSessions(t *testing.T) {
+	mockStorage := NewMockStorage(t)
+	service := &DefaultManager{
+		storage:     mockStorage,
+		tokenHasher: hasher.NewMockKeyring(t),
+		recorder:    audit.NewMockRecorder(t),
+	}
+	ctx := context.Background()
+
+	// Expectations
+	mockStorage.EXPECT().RemoveAll(ctx, "aud_id").Return(3, nil).Once()
+
+	service.recorder.(*audit.MockRecorder).EXPECT().Record(ctx, mock.MatchedBy(func(event audit.Event) bool {
+		return event.Action == audit.ActionSessionRevoke && event.TargetID == "aud_id" && event.Metadata["sessions"] == "3"
+	})).Once()
+
+	// Execute
+	ok := service.RemoveAllSessions(ctx, "aud_id")
+
+	assert.True(t, ok)
+}
+
+func TestRemoveAllSessionsFailedToRemove(t *testing.T) {
+	mockStorage := NewMockStorage(t)
+	service := &DefaultManager{
+		storage:     mockStorage,
+		tokenHasher: hasher.NewMockKeyring(t),
+		recorder:    audit.NewMockRecorder(t),
+	}
+	ctx := context.Background()
+
+	// Expectations
+	mockStorage.EXPECT().RemoveAll(ctx, "aud_id").Return(0, errors.New("failed to remove")).Once()
+
+	// Execute
+	ok := service.RemoveAllSessions(ctx, "aud_id")
+
+	assert.False(t, ok)
+}
+
+func TestGetSessionRekeysRotatedToken(t *testing.T) {
+	mockStorage := NewMockStorage(t)
+	mockHasher := hasher.NewMockKeyring(t)
+	service := &DefaultManager{
+		storage:     mockStorage,
+		tokenHasher: mockHasher,
+		recorder:    audit.NewMockRecorder(t),
+	}
+	ctx := context.Background()
+
+	// Expectations, the session was hashed by the retired key
+	mockHasher.EXPECT().HashAll(mock.AnythingOfType("string")).Return([]string{"new$hashed_token", "old$hashed_token"}, nil).Once()
+
+	mockStorage.EXPECT().Get(ctx, "new$hashed_token").Return(nil, errors.New("not found")).Once()
+	mockStorage.EXPECT().Get(ctx, "old$hashed_token").
+		Return(&Session{PrincipalID: "aud_id", ExpiresAt: time.Now().Add(time.Hour)}, nil).Once()
+	mockStorage.EXPECT().Rekey(ctx, "old$hashed_token", "new$hashed_token").Return(nil).Once()
+
+	// Execute
+	record, ok := service.GetSession(ctx, "token")
+
+	assert.True(t, ok)
+	assert.Equal(t, "aud_id", record.PrincipalID)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/events"
 )
 
 // PrincipalSessionRecord the database model for a session
@@ -19,14 +20,15 @@ type PrincipalSessionRecord struct {
 }
 
 type PgSQLStorage struct {
-	db *bun.DB
+	db     *bun.DB
+	outbox events.Outbox
 }
 
-func NewPgSQLStorage(db *bun.DB) Storage {
-	return &PgSQLStorage{db: db}
+func NewPgSQLStorage(db *bun.DB, outbox events.Outbox) Storage {
+	return &PgSQLStorage{db: db, outbox: outbox}
 }
 
-// Set stores a session in the database
+// Set stores a session in the database, together with its SessionCreated event
 func (s *PgSQLStorage) Set(ctx context.Context, hashedID string, data *Session) error {
 	record := &PrincipalSessionRecord{
 		ID:          hashedID,
@@ -36,9 +38,19 @@ func (s *PgSQLStorage) Set(ctx context.Context, hashedID string, data *Session)
 		CreatedAt:   data.CreatedAt,
 	}
 
-	_, err := s.db.NewInsert().Model(record).Exec(ctx)
+	event, err := events.NewSessionCreated(data.PrincipalID, data.ExpiresAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(record).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Append(ctx, tx, event)
+	})
 }
 
 // Get retrieves a session from the database, if it exists and is not expired
@@ -63,10 +75,73 @@ func (s *PgSQLStorage) Get(ctx context.Context, hashedID string) (*Session, erro
 	}, nil
 }
 
-// Remove removes a session from the database
-func (s *PgSQLStorage) Remove(ctx context.Context, hashedID string) error {
-	_, err := s.db.NewDelete().
+// Remove removes a session from the database, a SessionRevoked event is recorded when it existed
+func (s *PgSQLStorage) Remove(ctx context.Context, hashedID string) (string, error) {
+	var principalIDs []string
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*PrincipalSessionRecord)(nil)).
+			Where("id = ?", hashedID).
+			Returning("principal_id").
+			Exec(ctx, &principalIDs)
+		if err != nil || len(principalIDs) == 0 {
+			return err
+		}
+
+		event, err := events.NewSessionRevoked(principalIDs[0])
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Append(ctx, tx, event)
+	})
+	if err != nil || len(principalIDs) == 0 {
+		return "", err
+	}
+
+	return principalIDs[0], nil
+}
+
+// RemoveAll removes the sessions of a principal, a SessionRevoked event is recorded for each of them
+func (s *PgSQLStorage) RemoveAll(ctx context.Context, principalID string) (int, error) {
+	var hashedIDs []string
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*PrincipalSessionRecord)(nil)).
+			Where("principal_id = ?", principalID).
+			Returning("id").
+			Exec(ctx, &hashedIDs)
+		if err != nil {
+			return err
+		}
+
+		for range hashedIDs {
+			event, err := events.NewSessionRevoked(principalID)
+			if err != nil {
+				return err
+			}
+
+			if err := s.outbox.Append(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(hashedIDs), nil
+}
+
+// Rekey replaces the hashed ID of a session
+func (s *PgSQLStorage) Rekey(ctx context.Context, hashedID, newHashedID string) error {
+	_, err := s.db.NewUpdate().
 		Model((*PrincipalSessionRecord)(nil)).
+		Set("id = ?", newHashedID).
 		Where("id = ?", hashedID).
 		Exec(ctx)
 
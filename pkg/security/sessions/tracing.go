@@ -0,0 +1,59 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeusito/toci/pkg/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/zeusito/toci/pkg/security/sessions")
+
+// TracingManager starts a span around each call of the manager it decorates
+type TracingManager struct {
+	next Manager
+}
+
+func NewTracingManager(next Manager) Manager {
+	return &TracingManager{next: next}
+}
+
+func (m *TracingManager) CreateSession(ctx context.Context, data Session, expiresAt time.Time) (string, bool) {
+	ctx, span := tracer.Start(ctx, "sessions.CreateSession")
+	token, ok := m.next.CreateSession(ctx, data, expiresAt)
+	tracing.End(span, ok)
+
+	return token, ok
+}
+
+func (m *TracingManager) GetSession(ctx context.Context, token string) (*Session, bool) {
+	ctx, span := tracer.Start(ctx, "sessions.GetSession")
+	session, ok := m.next.GetSession(ctx, token)
+	tracing.End(span, ok)
+
+	return session, ok
+}
+
+func (m *TracingManager) RemoveSession(ctx context.Context, token string) bool {
+	ctx, span := tracer.Start(ctx, "sessions.RemoveSession")
+	ok := m.next.RemoveSession(ctx, token)
+	tracing.End(span, ok)
+
+	return ok
+}
+
+func (m *TracingManager) RemoveAllSessions(ctx context.Context, principalID string) bool {
+	ctx, span := tracer.Start(ctx, "sessions.RemoveAllSessions")
+	ok := m.next.RemoveAllSessions(ctx, principalID)
+	tracing.End(span, ok)
+
+	return ok
+}
+
+func (m *TracingManager) CleanUpExpiredSessions(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "sessions.CleanUpExpiredSessions")
+	defer span.End()
+
+	m.next.CleanUpExpiredSessions(ctx)
+}
@@ -0,0 +1,92 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	flagUserPresent            byte = 0x01
+	flagAttestedCredentialData byte = 0x40
+	flagExtensionData          byte = 0x80
+
+	minAuthenticatorDataLength = 37
+	maxCredentialIDLength      = 1023
+)
+
+var errMalformedAuthenticatorData = errors.New("malformed authenticator data")
+
+type attestedCredentialData struct {
+	aaguid       []byte
+	credentialID []byte
+	publicKey    *credentialPublicKey
+	rawPublicKey []byte
+}
+
+// authenticatorData see https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+type authenticatorData struct {
+	rpIDHash   []byte
+	flags      byte
+	signCount  uint32
+	credential *attestedCredentialData
+}
+
+func (d *authenticatorData) userPresent() bool {
+	return d.flags&flagUserPresent != 0
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < minAuthenticatorDataLength {
+		return nil, errMalformedAuthenticatorData
+	}
+
+	result := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[minAuthenticatorDataLength:]
+
+	if result.flags&flagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return nil, errMalformedAuthenticatorData
+		}
+
+		credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		if credentialIDLength > maxCredentialIDLength || len(rest) < 18+credentialIDLength {
+			return nil, errMalformedAuthenticatorData
+		}
+
+		credential := &attestedCredentialData{
+			aaguid:       rest[:16],
+			credentialID: rest[18 : 18+credentialIDLength],
+		}
+		rest = rest[18+credentialIDLength:]
+
+		publicKey, remaining, err := parseCOSEKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		credential.publicKey = publicKey
+		credential.rawPublicKey = rest[:len(rest)-len(remaining)]
+		rest = remaining
+
+		result.credential = credential
+	}
+
+	// Extensions are not requested, but authenticators may still send them
+	if result.flags&flagExtensionData != 0 {
+		_, remaining, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, errMalformedAuthenticatorData
+		}
+		rest = remaining
+	}
+
+	if len(rest) != 0 {
+		return nil, errMalformedAuthenticatorData
+	}
+
+	return result, nil
+}
@@ -0,0 +1,290 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/zeusito/toci/pkg/logger"
+	"github.com/zeusito/toci/pkg/security/otp"
+)
+
+type DefaultManager struct {
+	storage    Storage
+	challenges otp.Manager
+	rpID       string
+	rpName     string
+	origins    []string
+}
+
+// BeginRegistration starts a registration ceremony for the given user, existing credentials are excluded
+func (s *DefaultManager) BeginRegistration(ctx context.Context, user User) (*CredentialCreationOptions, bool) {
+	existing, err := s.storage.ListByPrincipal(ctx, user.ID)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to list existing credentials")
+		return nil, false
+	}
+
+	challenge, ok := s.challenges.GenerateCode(ctx, challengeLength, challengeKindRegistration, user.ID)
+	if !ok {
+		logger.Ctx(ctx).Error().Msg("failed to generate registration challenge")
+		return nil, false
+	}
+
+	params := make([]CredentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return &CredentialCreationOptions{
+		Challenge:    URLEncodedBase64(challenge),
+		RelyingParty: RelyingPartyEntity{ID: s.rpID, Name: s.rpName},
+		User: UserEntity{
+			ID:          URLEncodedBase64(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            ceremonyTimeout.Milliseconds(),
+		ExcludeCredentials: toDescriptors(existing),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}, true
+}
+
+// FinishRegistration verifies the attestation returned by the client and stores the new credential.
+// Attestation statements are not verified, since "none" conveyance is requested.
+func (s *DefaultManager) FinishRegistration(ctx context.Context, user User, response RegistrationResponse) (*Credential, error) {
+	if !s.verifyClientData(ctx, response.Response.ClientDataJSON, "webauthn.create", challengeKindRegistration, user.ID) {
+		return nil, ErrInvalidRegistration
+	}
+
+	item, _, err := decodeCBOR(response.Response.AttestationObject)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to decode attestation object")
+		return nil, ErrInvalidRegistration
+	}
+
+	attestation, ok := item.(map[any]any)
+	if !ok {
+		logger.Ctx(ctx).Warn().Msg("attestation object is not a map")
+		return nil, ErrInvalidRegistration
+	}
+
+	rawAuthData, _ := attestation["authData"].([]byte)
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to parse authenticator data")
+		return nil, ErrInvalidRegistration
+	}
+
+	if !s.verifyAuthenticatorData(ctx, authData) {
+		return nil, ErrInvalidRegistration
+	}
+
+	if authData.credential == nil || !bytes.Equal(authData.credential.credentialID, response.RawID) {
+		logger.Ctx(ctx).Warn().Msg("attested credential data is missing or does not match the credential ID")
+		return nil, ErrInvalidRegistration
+	}
+
+	// A credential ID can only be registered once
+	_, err = s.storage.Get(ctx, response.RawID)
+	if err == nil {
+		logger.Ctx(ctx).Warn().Msg("credential is already registered")
+		return nil, ErrCredentialRegistered
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to look up credential")
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	credential := &Credential{
+		ID:          authData.credential.credentialID,
+		PrincipalID: user.ID,
+		PublicKey:   authData.credential.rawPublicKey,
+		SignCount:   authData.signCount,
+		Transports:  response.Response.Transports,
+		AAGUID:      authData.credential.aaguid,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+	}
+
+	err = s.storage.Put(ctx, credential)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to persist credential")
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// BeginLogin starts an authentication ceremony, restricted to the credentials of the given principal
+func (s *DefaultManager) BeginLogin(ctx context.Context, principalID string) (*CredentialRequestOptions, bool) {
+	credentials, err := s.storage.ListByPrincipal(ctx, principalID)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to list credentials")
+		return nil, false
+	}
+
+	if len(credentials) == 0 {
+		logger.Ctx(ctx).Warn().Msg("principal has no registered credentials")
+		return nil, false
+	}
+
+	challenge, ok := s.challenges.GenerateCode(ctx, challengeLength, challengeKindAssertion, principalID)
+	if !ok {
+		logger.Ctx(ctx).Error().Msg("failed to generate assertion challenge")
+		return nil, false
+	}
+
+	return &CredentialRequestOptions{
+		Challenge:        URLEncodedBase64(challenge),
+		Timeout:          ceremonyTimeout.Milliseconds(),
+		RPID:             s.rpID,
+		AllowCredentials: toDescriptors(credentials),
+		UserVerification: "preferred",
+	}, true
+}
+
+// FinishLogin verifies an assertion signed by one of the principal's credentials
+func (s *DefaultManager) FinishLogin(ctx context.Context, principalID string, response AssertionResponse) (*Credential, bool) {
+	credential, err := s.storage.Get(ctx, response.RawID)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to retrieve credential")
+		return nil, false
+	}
+
+	if credential.PrincipalID != principalID {
+		logger.Ctx(ctx).Warn().Msg("credential does not belong to principal")
+		return nil, false
+	}
+
+	if len(response.Response.UserHandle) > 0 && string(response.Response.UserHandle) != principalID {
+		logger.Ctx(ctx).Warn().Msg("user handle does not match principal")
+		return nil, false
+	}
+
+	if !s.verifyClientData(ctx, response.Response.ClientDataJSON, "webauthn.get", challengeKindAssertion, principalID) {
+		return nil, false
+	}
+
+	authData, err := parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to parse authenticator data")
+		return nil, false
+	}
+
+	if !s.verifyAuthenticatorData(ctx, authData) {
+		return nil, false
+	}
+
+	publicKey, _, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to parse stored public key")
+		return nil, false
+	}
+
+	// The signature covers the authenticator data followed by the hash of the client data
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte{}, response.Response.AuthenticatorData...), clientDataHash[:]...)
+	if !publicKey.verify(signed, response.Response.Signature) {
+		logger.Ctx(ctx).Warn().Msg("assertion signature is invalid")
+		return nil, false
+	}
+
+	// A counter that does not move forward means the authenticator may have been cloned
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		logger.Ctx(ctx).Warn().Msg("signature counter did not increase, possible cloned authenticator")
+		return nil, false
+	}
+
+	now := time.Now().UTC()
+	err = s.storage.UpdateSignCount(ctx, credential.ID, authData.signCount, now)
+	if errors.Is(err, ErrStaleSignCount) {
+		logger.Ctx(ctx).Warn().Msg("signature counter was already used, possible cloned authenticator")
+		return nil, false
+	}
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to update signature counter")
+		return nil, false
+	}
+
+	credential.SignCount = authData.signCount
+	credential.LastUsedAt = now
+
+	return credential, true
+}
+
+// verifyClientData checks the ceremony type, origin and challenge. Challenges are single use.
+func (s *DefaultManager) verifyClientData(ctx context.Context, raw []byte, ceremony string, kind otp.CodeKind, principalID string) bool {
+	var clientData collectedClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("failed to decode client data")
+		return false
+	}
+
+	if clientData.Type != ceremony {
+		logger.Ctx(ctx).Warn().Msgf("unexpected ceremony type: %s", clientData.Type)
+		return false
+	}
+
+	if !slices.Contains(s.origins, clientData.Origin) {
+		logger.Ctx(ctx).Warn().Msgf("origin is not allowed: %s", clientData.Origin)
+		return false
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	if err != nil || len(challenge) == 0 {
+		logger.Ctx(ctx).Warn().Msg("failed to decode challenge")
+		return false
+	}
+
+	if !s.challenges.VerifyCode(ctx, kind, principalID, string(challenge)) {
+		logger.Ctx(ctx).Warn().Msg("challenge does not match")
+		return false
+	}
+
+	if !s.challenges.Remove(ctx, kind, principalID) {
+		return false
+	}
+
+	return true
+}
+
+func (s *DefaultManager) verifyAuthenticatorData(ctx context.Context, authData *authenticatorData) bool {
+	expectedHash := sha256.Sum256([]byte(s.rpID))
+	if !bytes.Equal(authData.rpIDHash, expectedHash[:]) {
+		logger.Ctx(ctx).Warn().Msg("relying party ID hash does not match")
+		return false
+	}
+
+	if !authData.userPresent() {
+		logger.Ctx(ctx).Warn().Msg("user presence flag is not set")
+		return false
+	}
+
+	return true
+}
+
+func toDescriptors(credentials []Credential) []CredentialDescriptor {
+	descriptors := make([]CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		descriptors = append(descriptors, CredentialDescriptor{
+			Type:       "public-key",
+			ID:         c.ID,
+			Transports: c.Transports,
+		})
+	}
+
+	return descriptors
+}
@@ -0,0 +1,114 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// URLEncodedBase64 is a byte slice that is serialized as unpadded base64url, as mandated by the WebAuthn JSON encoding
+type URLEncodedBase64 []byte
+
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string           `json:"type"`
+	ID         URLEncodedBase64 `json:"id"`
+	Transports []string         `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CredentialCreationOptions is handed to navigator.credentials.create() on the client
+type CredentialCreationOptions struct {
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	RelyingParty           RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// CredentialRequestOptions is handed to navigator.credentials.get() on the client
+type CredentialRequestOptions struct {
+	Challenge        URLEncodedBase64       `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON" validate:"required"`
+	AttestationObject URLEncodedBase64 `json:"attestationObject" validate:"required"`
+	Transports        []string         `json:"transports"`
+}
+
+// RegistrationResponse is the JSON serialization of the PublicKeyCredential returned by navigator.credentials.create()
+type RegistrationResponse struct {
+	ID       string              `json:"id" validate:"required"`
+	RawID    URLEncodedBase64    `json:"rawId" validate:"required"`
+	Type     string              `json:"type" validate:"required,eq=public-key"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AuthenticatorAssertionResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData URLEncodedBase64 `json:"authenticatorData" validate:"required"`
+	Signature         URLEncodedBase64 `json:"signature" validate:"required"`
+	UserHandle        URLEncodedBase64 `json:"userHandle"`
+}
+
+// AssertionResponse is the JSON serialization of the PublicKeyCredential returned by navigator.credentials.get()
+type AssertionResponse struct {
+	ID       string                         `json:"id" validate:"required"`
+	RawID    URLEncodedBase64               `json:"rawId" validate:"required"`
+	Type     string                         `json:"type" validate:"required,eq=public-key"`
+	Response AuthenticatorAssertionResponse `json:"response"`
+}
+
+// collectedClientData the subset of CollectedClientData the relying party must check
+type collectedClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
@@ -0,0 +1,87 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/zeusito/toci/pkg/config"
+	"github.com/zeusito/toci/pkg/security/otp"
+)
+
+// Challenges are stored through the OTP manager, so they are hashed at rest and expire like any other code
+const (
+	challengeKindRegistration otp.CodeKind = "webauthn_registration"
+	challengeKindAssertion    otp.CodeKind = "webauthn_assertion"
+	challengeLength                        = 32
+	ceremonyTimeout                        = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidRegistration the registration response does not verify, e.g. its origin or challenge is wrong
+	ErrInvalidRegistration = errors.New("the passkey registration could not be verified")
+	// ErrCredentialRegistered the credential ID is registered already
+	ErrCredentialRegistered = errors.New("the credential is already registered")
+)
+
+// ErrStaleSignCount the stored signature counter is not lower than the one asserted, another assertion with the
+// same or a higher counter was recorded first, e.g. by a cloned authenticator
+var ErrStaleSignCount = errors.New("the signature counter was not increased")
+
+// User is the account a credential is being registered for
+type User struct {
+	ID          string
+	Name        string
+	DisplayName string
+}
+
+// Credential is a registered public key credential (passkey)
+type Credential struct {
+	ID          []byte
+	PrincipalID string
+	PublicKey   []byte // COSE encoded
+	SignCount   uint32
+	Transports  []string
+	AAGUID      []byte
+	CreatedAt   time.Time
+	LastUsedAt  time.Time
+}
+
+type Manager interface {
+	BeginRegistration(ctx context.Context, user User) (*CredentialCreationOptions, bool)
+	// FinishRegistration returns ErrInvalidRegistration or ErrCredentialRegistered when the client is at fault, the
+	// errors of the storage otherwise
+	FinishRegistration(ctx context.Context, user User, response RegistrationResponse) (*Credential, error)
+	BeginLogin(ctx context.Context, principalID string) (*CredentialRequestOptions, bool)
+	FinishLogin(ctx context.Context, principalID string, response AssertionResponse) (*Credential, bool)
+}
+
+type Storage interface {
+	Put(ctx context.Context, credential *Credential) error
+	Get(ctx context.Context, credentialID []byte) (*Credential, error)
+	ListByPrincipal(ctx context.Context, principalID string) ([]Credential, error)
+	// UpdateSignCount records a successful assertion, ErrStaleSignCount when the stored counter is not lower
+	UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, lastUsedAt time.Time) error
+}
+
+func NewManagerWithPgSQLStorage(db *bun.DB, otpManager otp.Manager, cfg config.WebAuthnConfigurations) (Manager, bool) {
+	if cfg.RPID == "" || len(cfg.Origins) == 0 {
+		log.Error().Msg("webauthn relying party ID and origins are required")
+		return nil, false
+	}
+
+	rpName := cfg.RPName
+	if rpName == "" {
+		rpName = cfg.RPID
+	}
+
+	return &DefaultManager{
+		storage:    NewPgSQLStorage(db),
+		challenges: otpManager,
+		rpID:       cfg.RPID,
+		rpName:     rpName,
+		origins:    cfg.Origins,
+	}, true
+}
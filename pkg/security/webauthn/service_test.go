@@ -0,0 +1,420 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/security/otp"
+)
+
+const (
+	testRPID      = "example.com"
+	testOrigin    = "https://example.com"
+	testChallenge = "an-unpredictable-challenge-value"
+)
+
+// softAuthenticator is an in-process ES256 authenticator used to drive the ceremonies
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	return cborEncode(map[int64]any{1: coseKeyTypeEC2, 3: algES256, -1: coseCurveP256, -2: x, -3: y})
+}
+
+func (a *softAuthenticator) authData(rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		data = append(data, make([]byte, 16)...) // zero AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+
+	return data
+}
+
+func clientDataJSON(ceremony, challenge, origin string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString([]byte(challenge)),
+		"origin":    origin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) create(rpID, challenge, origin string) RegistrationResponse {
+	authData := a.authData(rpID, flagUserPresent|flagAttestedCredentialData, true)
+	attestation := cborEncode(map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": authData})
+
+	return RegistrationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: AttestationResponse{
+			ClientDataJSON:    clientDataJSON("webauthn.create", challenge, origin),
+			AttestationObject: attestation,
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+func (a *softAuthenticator) get(t *testing.T, rpID, challenge, origin string) AssertionResponse {
+	a.signCount++
+	authData := a.authData(rpID, flagUserPresent, false)
+	clientData := clientDataJSON("webauthn.get", challenge, origin)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	return AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: AuthenticatorAssertionResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         signature,
+		},
+	}
+}
+
+func (a *softAuthenticator) credential(principalID string) *Credential {
+	return &Credential{ID: a.credentialID, PrincipalID: principalID, PublicKey: a.coseKey(), SignCount: a.signCount}
+}
+
+// cborEncode a canonical-enough CBOR encoder for the handful of types authenticators emit
+func cborEncode(v any) []byte {
+	header := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+	}
+
+	switch t := v.(type) {
+	case int64:
+		if t >= 0 {
+			return header(0, uint64(t))
+		}
+		return header(1, uint64(-1-t))
+	case []byte:
+		return append(header(2, uint64(len(t))), t...)
+	case string:
+		return append(header(3, uint64(len(t))), t...)
+	case map[int64]any:
+		out := header(5, uint64(len(t)))
+		keys := make([]int64, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		for _, k := range keys {
+			out = append(out, cborEncode(k)...)
+			out = append(out, cborEncode(t[k])...)
+		}
+		return out
+	case map[string]any:
+		out := header(5, uint64(len(t)))
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = append(out, cborEncode(k)...)
+			out = append(out, cborEncode(t[k])...)
+		}
+		return out
+	}
+
+	panic("unsupported cbor type")
+}
+
+func newTestManager(t *testing.T) (*DefaultManager, *MockStorage, *otp.MockManager) {
+	mockStorage := NewMockStorage(t)
+	mockChallenges := otp.NewMockManager(t)
+
+	return &DefaultManager{
+		storage:    mockStorage,
+		challenges: mockChallenges,
+		rpID:       testRPID,
+		rpName:     "Example",
+		origins:    []string{testOrigin},
+	}, mockStorage, mockChallenges
+}
+
+func TestBeginRegistration(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	user := User{ID: "user-1", Name: "john@example.com", DisplayName: "John"}
+	existing := Credential{ID: []byte("existing"), PrincipalID: "user-1"}
+
+	// Expectations
+	mockStorage.EXPECT().ListByPrincipal(ctx, "user-1").Return([]Credential{existing}, nil).Once()
+	mockChallenges.EXPECT().GenerateCode(ctx, challengeLength, challengeKindRegistration, "user-1").
+		Return(testChallenge, true).Once()
+
+	options, ok := manager.BeginRegistration(ctx, user)
+
+	assert.True(t, ok)
+	assert.Equal(t, []byte(testChallenge), []byte(options.Challenge))
+	assert.Equal(t, testRPID, options.RelyingParty.ID)
+	assert.Equal(t, []byte("user-1"), []byte(options.User.ID))
+	assert.Len(t, options.ExcludeCredentials, 1)
+	assert.Equal(t, "none", options.Attestation)
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+	user := User{ID: "user-1", Name: "john@example.com"}
+
+	// Registration
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindRegistration, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindRegistration, "user-1").Return(true).Once()
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(nil, sql.ErrNoRows).Once()
+	mockStorage.EXPECT().Put(ctx, mock.AnythingOfType("*webauthn.Credential")).Return(nil).Once()
+
+	credential, err := manager.FinishRegistration(ctx, user, authenticator.create(testRPID, testChallenge, testOrigin))
+
+	require.NoError(t, err)
+	assert.Equal(t, authenticator.credentialID, credential.ID)
+	assert.Equal(t, "user-1", credential.PrincipalID)
+	assert.Equal(t, []string{"internal"}, credential.Transports)
+
+	// Login
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(credential, nil).Once()
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindAssertion, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindAssertion, "user-1").Return(true).Once()
+	mockStorage.EXPECT().UpdateSignCount(ctx, authenticator.credentialID, uint32(1), mock.AnythingOfType("time.Time")).
+		Return(nil).Once()
+
+	loggedIn, ok := manager.FinishLogin(ctx, "user-1", authenticator.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), loggedIn.SignCount)
+}
+
+func TestFinishRegistrationWrongOrigin(t *testing.T) {
+	ctx := context.Background()
+	manager, _, _ := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	credential, err := manager.FinishRegistration(ctx, User{ID: "user-1"},
+		authenticator.create(testRPID, testChallenge, "https://evil.example.org"))
+
+	assert.ErrorIs(t, err, ErrInvalidRegistration)
+	assert.Nil(t, credential)
+}
+
+func TestFinishRegistrationWrongChallenge(t *testing.T) {
+	ctx := context.Background()
+	manager, _, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindRegistration, "user-1", "stale").Return(false).Once()
+
+	credential, err := manager.FinishRegistration(ctx, User{ID: "user-1"}, authenticator.create(testRPID, "stale", testOrigin))
+
+	assert.ErrorIs(t, err, ErrInvalidRegistration)
+	assert.Nil(t, credential)
+}
+
+func TestFinishRegistrationWrongRelyingParty(t *testing.T) {
+	ctx := context.Background()
+	manager, _, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindRegistration, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindRegistration, "user-1").Return(true).Once()
+
+	credential, err := manager.FinishRegistration(ctx, User{ID: "user-1"},
+		authenticator.create("evil.example.org", testChallenge, testOrigin))
+
+	assert.ErrorIs(t, err, ErrInvalidRegistration)
+	assert.Nil(t, credential)
+}
+
+func TestFinishRegistrationAlreadyRegistered(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindRegistration, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindRegistration, "user-1").Return(true).Once()
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(authenticator.credential("user-2"), nil).Once()
+
+	credential, err := manager.FinishRegistration(ctx, User{ID: "user-1"}, authenticator.create(testRPID, testChallenge, testOrigin))
+
+	assert.ErrorIs(t, err, ErrCredentialRegistered)
+	assert.Nil(t, credential)
+}
+
+func TestFinishRegistrationStorageUnavailable(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+	outage := errors.New("connection refused")
+
+	// Expectations
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindRegistration, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindRegistration, "user-1").Return(true).Once()
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(nil, outage).Once()
+
+	credential, err := manager.FinishRegistration(ctx, User{ID: "user-1"}, authenticator.create(testRPID, testChallenge, testOrigin))
+
+	assert.ErrorIs(t, err, outage)
+	assert.NotErrorIs(t, err, ErrCredentialRegistered)
+	assert.Nil(t, credential)
+}
+
+func TestBeginLoginWithoutCredentials(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, _ := newTestManager(t)
+
+	// Expectations
+	mockStorage.EXPECT().ListByPrincipal(ctx, "user-1").Return([]Credential{}, nil).Once()
+
+	options, ok := manager.BeginLogin(ctx, "user-1")
+
+	assert.False(t, ok)
+	assert.Nil(t, options)
+}
+
+func TestFinishLoginCredentialOfAnotherPrincipal(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, _ := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(authenticator.credential("user-2"), nil).Once()
+
+	credential, ok := manager.FinishLogin(ctx, "user-1", authenticator.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.False(t, ok)
+	assert.Nil(t, credential)
+}
+
+func TestFinishLoginInvalidSignature(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+	stored := authenticator.credential("user-1")
+
+	// Sign with a different key than the one registered
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialID = authenticator.credentialID
+
+	// Expectations
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(stored, nil).Once()
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindAssertion, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindAssertion, "user-1").Return(true).Once()
+
+	credential, ok := manager.FinishLogin(ctx, "user-1", impostor.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.False(t, ok)
+	assert.Nil(t, credential)
+}
+
+func TestFinishLoginSignCountRegression(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+	stored := authenticator.credential("user-1")
+	stored.SignCount = 10
+
+	// Expectations
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(stored, nil).Once()
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindAssertion, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindAssertion, "user-1").Return(true).Once()
+
+	credential, ok := manager.FinishLogin(ctx, "user-1", authenticator.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.False(t, ok)
+	assert.Nil(t, credential)
+}
+
+func TestFinishLoginSignCountUsedConcurrently(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, mockChallenges := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations, another assertion with the same counter was recorded since the credential was read
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(authenticator.credential("user-1"), nil).Once()
+	mockChallenges.EXPECT().VerifyCode(ctx, challengeKindAssertion, "user-1", testChallenge).Return(true).Once()
+	mockChallenges.EXPECT().Remove(ctx, challengeKindAssertion, "user-1").Return(true).Once()
+	mockStorage.EXPECT().UpdateSignCount(ctx, authenticator.credentialID, uint32(1), mock.AnythingOfType("time.Time")).
+		Return(ErrStaleSignCount).Once()
+
+	credential, ok := manager.FinishLogin(ctx, "user-1", authenticator.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.False(t, ok)
+	assert.Nil(t, credential)
+}
+
+func TestFinishLoginUnknownCredential(t *testing.T) {
+	ctx := context.Background()
+	manager, mockStorage, _ := newTestManager(t)
+	authenticator := newSoftAuthenticator(t)
+
+	// Expectations
+	mockStorage.EXPECT().Get(ctx, mock.Anything).Return(nil, errors.New("record not found")).Once()
+
+	credential, ok := manager.FinishLogin(ctx, "user-1", authenticator.get(t, testRPID, testChallenge, testOrigin))
+
+	assert.False(t, ok)
+	assert.Nil(t, credential)
+}
+
+func TestDecodeCBORRejectsTruncatedInput(t *testing.T) {
+	encoded := cborEncode(map[string]any{"fmt": "none"})
+
+	_, _, err := decodeCBOR(encoded[:len(encoded)-1])
+
+	assert.Error(t, err)
+}
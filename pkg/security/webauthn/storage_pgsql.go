@@ -0,0 +1,129 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CredentialRecord the database model for a passkey, linked to an identity
+type CredentialRecord struct {
+	bun.BaseModel `bun:"table:webauthn_credentials,alias:wc"`
+	ID            []byte    `bun:"id,pk"`
+	IdentityID    string    `bun:"identity_id"`
+	PublicKey     []byte    `bun:"public_key"`
+	SignCount     int64     `bun:"sign_count"`
+	Transports    []string  `bun:"transports,array"`
+	AAGUID        []byte    `bun:"aaguid"`
+	LastUsedAt    time.Time `bun:"last_used_at"`
+	CreatedAt     time.Time `bun:"created_at"`
+}
+
+type PgSQLStorage struct {
+	db *bun.DB
+}
+
+func NewPgSQLStorage(db *bun.DB) Storage {
+	return &PgSQLStorage{db: db}
+}
+
+// Put stores a new credential
+func (s *PgSQLStorage) Put(ctx context.Context, credential *Credential) error {
+	transports := credential.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+
+	_, err := s.db.NewInsert().
+		Model(&CredentialRecord{
+			ID:         credential.ID,
+			IdentityID: credential.PrincipalID,
+			PublicKey:  credential.PublicKey,
+			SignCount:  int64(credential.SignCount),
+			Transports: transports,
+			AAGUID:     credential.AAGUID,
+			LastUsedAt: credential.LastUsedAt,
+			CreatedAt:  credential.CreatedAt,
+		}).
+		Exec(ctx)
+
+	return err
+}
+
+// Get retrieves a credential by its ID
+func (s *PgSQLStorage) Get(ctx context.Context, credentialID []byte) (*Credential, error) {
+	var record CredentialRecord
+
+	err := s.db.NewSelect().
+		Model(&record).
+		Where("id = ?", credentialID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credential := toCredential(record)
+	return &credential, nil
+}
+
+// ListByPrincipal retrieves all the credentials registered by an identity
+func (s *PgSQLStorage) ListByPrincipal(ctx context.Context, principalID string) ([]Credential, error) {
+	var records []CredentialRecord
+
+	err := s.db.NewSelect().
+		Model(&records).
+		Where("identity_id = ?", principalID).
+		Order("created_at ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]Credential, 0, len(records))
+	for _, record := range records {
+		credentials = append(credentials, toCredential(record))
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount records a successful assertion. The counter only moves forward, so of two assertions racing with
+// the same counter only one is recorded. The authenticators without a counter always assert 0.
+func (s *PgSQLStorage) UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, lastUsedAt time.Time) error {
+	res, err := s.db.NewUpdate().
+		Model((*CredentialRecord)(nil)).
+		Set("sign_count = ?", int64(signCount)).
+		Set("last_used_at = ?", lastUsedAt).
+		Where("id = ?", credentialID).
+		Where("(sign_count < ? OR (sign_count = 0 AND ? = 0))", int64(signCount), int64(signCount)).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStaleSignCount
+	}
+
+	return nil
+}
+
+func toCredential(record CredentialRecord) Credential {
+	return Credential{
+		ID:          record.ID,
+		PrincipalID: record.IdentityID,
+		PublicKey:   record.PublicKey,
+		SignCount:   uint32(record.SignCount), //nolint:gosec // stored from an uint32
+		Transports:  record.Transports,
+		AAGUID:      record.AAGUID,
+		CreatedAt:   record.CreatedAt,
+		LastUsedAt:  record.LastUsedAt,
+	}
+}
@@ -49,3 +49,19 @@ func UnAuthorized(message string) *Terror {
 		HttpStatusCode: http.StatusUnauthorized,
 	}
 }
+
+func PayloadTooLarge(message string) *Terror {
+	return &Terror{
+		ErrCode:        "PayloadTooLarge",
+		ErrMessage:     message,
+		HttpStatusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+func NotAcceptable(message string) *Terror {
+	return &Terror{
+		ErrCode:        "NotAcceptable",
+		ErrMessage:     message,
+		HttpStatusCode: http.StatusNotAcceptable,
+	}
+}
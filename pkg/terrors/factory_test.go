@@ -36,6 +36,20 @@ func TestUnknown(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode, "Unknown should return the correct http status code")
 }
 
+func TestPayloadTooLarge(t *testing.T) {
+	err := PayloadTooLarge("test")
+	assert.Equal(t, "test", err.ErrMessage, "PayloadTooLarge should return the correct message")
+	assert.Equal(t, "PayloadTooLarge", err.ErrCode, "PayloadTooLarge should return the correct code")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.HttpStatusCode, "PayloadTooLarge should return the correct http status code")
+}
+
+func TestNotAcceptable(t *testing.T) {
+	err := NotAcceptable("test")
+	assert.Equal(t, "test", err.ErrMessage, "NotAcceptable should return the correct message")
+	assert.Equal(t, "NotAcceptable", err.ErrCode, "NotAcceptable should return the correct code")
+	assert.Equal(t, http.StatusNotAcceptable, err.HttpStatusCode, "NotAcceptable should return the correct http status code")
+}
+
 func TestTypeAssertion(t *testing.T) {
 	var err error = PreconditionFailed("test")
 
@@ -0,0 +1,237 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zeusito/toci/pkg/config"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+type Algorithm string
+
+const (
+	AlgorithmAES256GCM         Algorithm = "aes-256-gcm"
+	AlgorithmXChaCha20Poly1305 Algorithm = "xchacha20-poly1305"
+)
+
+const (
+	envelopeVersion   = "v1"
+	envelopeSeparator = "."
+	keySize           = 32
+)
+
+var (
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+	ErrUnknownKey        = errors.New("unknown encryption key")
+	ErrDecryptionFailed  = errors.New("decryption failed")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// Key a key encryption key, it only ever encrypts the data keys
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+	Secret    []byte
+	// Retired keys only decrypt existing values
+	Retired bool
+}
+
+// Keyring seals values with the primary key and opens them with the key they were sealed with.
+//
+// Every value gets its own random data key, sealed by the key encryption key. The envelope reads
+// v1.<key ID>.<algorithm>.<sealed data key>.<sealed value>, both sealed parts being a nonce followed
+// by the ciphertext, base64 url encoded. The associated data, usually the row ID, authenticates the
+// value without being stored, so a value copied to another row does not open.
+type Keyring struct {
+	primary string
+	keys    map[string]Key
+}
+
+// NewKeyring Creates a keyring, the primary key must be one of the keys and must not be retired
+func NewKeyring(primary string, keys ...Key) (*Keyring, error) {
+	keyring := &Keyring{primary: primary, keys: map[string]Key{}}
+
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("invalid key ID %q", key.ID)
+		}
+
+		if len(key.Secret) != keySize {
+			return nil, fmt.Errorf("key %s must be %d bytes", key.ID, keySize)
+		}
+
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmAES256GCM
+		}
+
+		if _, err := newAEAD(key.Algorithm, key.Secret); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.ID, err)
+		}
+
+		keyring.keys[key.ID] = key
+	}
+
+	key, ok := keyring.keys[primary]
+	if !ok {
+		return nil, fmt.Errorf("primary key %q is not configured", primary)
+	}
+
+	if key.Retired {
+		return nil, fmt.Errorf("primary key %s is retired", primary)
+	}
+
+	return keyring, nil
+}
+
+// NewKeyringFromConfig Creates a keyring from the configured base64 encoded keys
+func NewKeyringFromConfig(cfg config.EncryptionConfigurations) (*Keyring, error) {
+	keys := make([]Key, 0, len(cfg.Keys))
+
+	for id, keyCfg := range cfg.Keys {
+		encoded, err := keyCfg.Load()
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s: %w", id, err)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding key %s: %w", id, err)
+		}
+
+		keys = append(keys, Key{
+			ID:        id,
+			Algorithm: Algorithm(keyCfg.Algorithm),
+			Secret:    secret,
+			Retired:   keyCfg.Retired,
+		})
+	}
+
+	return NewKeyring(cfg.PrimaryKey, keys...)
+}
+
+// Encrypt seals the plaintext with a fresh data key wrapped by the primary key
+func (k *Keyring) Encrypt(plaintext, associatedData []byte) (string, error) {
+	key := k.keys[k.primary]
+	header := strings.Join([]string{envelopeVersion, key.ID, string(key.Algorithm)}, envelopeSeparator)
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	sealedKey, err := seal(key.Algorithm, key.Secret, dataKey, []byte(header))
+	if err != nil {
+		return "", err
+	}
+
+	sealedValue, err := seal(key.Algorithm, dataKey, plaintext, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		header,
+		base64.RawURLEncoding.EncodeToString(sealedKey),
+		base64.RawURLEncoding.EncodeToString(sealedValue),
+	}, envelopeSeparator), nil
+}
+
+// Decrypt opens an envelope sealed by any key of the keyring, retired ones included
+func (k *Keyring) Decrypt(envelope string, associatedData []byte) ([]byte, error) {
+	parts := strings.Split(envelope, envelopeSeparator)
+	if len(parts) != 5 || parts[0] != envelopeVersion {
+		return nil, ErrMalformedEnvelope
+	}
+
+	key, ok := k.keys[parts[1]]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	// The algorithm is the one of the key, the header must not pick another one
+	algorithm := Algorithm(parts[2])
+	if algorithm != key.Algorithm {
+		return nil, ErrMalformedEnvelope
+	}
+	header := strings.Join(parts[:3], envelopeSeparator)
+
+	sealedKey, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrMalformedEnvelope
+	}
+
+	sealedValue, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrMalformedEnvelope
+	}
+
+	dataKey, err := open(algorithm, key.Secret, sealedKey, []byte(header))
+	if err != nil {
+		return nil, err
+	}
+
+	return open(algorithm, dataKey, sealedValue, associatedData)
+}
+
+// NeedsReencrypt tells whether the envelope was sealed by another key than the primary one
+func (k *Keyring) NeedsReencrypt(envelope string) bool {
+	parts := strings.SplitN(envelope, envelopeSeparator, 3)
+
+	return len(parts) < 2 || parts[1] != k.primary
+}
+
+func newAEAD(algorithm Algorithm, key []byte) (cipher.AEAD, error) {
+	switch algorithm {
+	case AlgorithmAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case AlgorithmXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// seal returns the random nonce followed by the ciphertext
+func seal(algorithm Algorithm, key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(algorithm Algorithm, key, sealed, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(algorithm, key)
+	if err != nil {
+		return nil, ErrMalformedEnvelope
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], associatedData)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,224 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+)
+
+func testKey(id string, algorithm Algorithm, fill byte) Key {
+	return Key{ID: id, Algorithm: algorithm, Secret: bytes.Repeat([]byte{fill}, keySize)}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, algorithm := range []Algorithm{AlgorithmAES256GCM, AlgorithmXChaCha20Poly1305} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			keyring, err := NewKeyring("k1", testKey("k1", algorithm, 1))
+			require.NoError(t, err)
+
+			envelope, err := keyring.Encrypt([]byte("top secret"), []byte("row-1"))
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(envelope, "v1.k1."+string(algorithm)+"."))
+			assert.NotContains(t, envelope, "top secret")
+
+			plaintext, err := keyring.Decrypt(envelope, []byte("row-1"))
+			require.NoError(t, err)
+			assert.Equal(t, "top secret", string(plaintext))
+
+			other, err := keyring.Encrypt([]byte("top secret"), []byte("row-1"))
+			require.NoError(t, err)
+			assert.NotEqual(t, envelope, other)
+		})
+	}
+}
+
+func TestDecryptRejectsAnotherRow(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey("k1", AlgorithmAES256GCM, 1))
+	require.NoError(t, err)
+
+	envelope, err := keyring.Encrypt([]byte("top secret"), []byte("row-1"))
+	require.NoError(t, err)
+
+	_, err = keyring.Decrypt(envelope, []byte("row-2"))
+
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestDecryptRejectsTamperedEnvelopes(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey("k1", AlgorithmAES256GCM, 1), testKey("k2", AlgorithmAES256GCM, 2))
+	require.NoError(t, err)
+
+	envelope, err := keyring.Encrypt([]byte("top secret"), []byte("row-1"))
+	require.NoError(t, err)
+	parts := strings.Split(envelope, ".")
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[4])
+	require.NoError(t, err)
+	sealed[len(sealed)-1] ^= 1
+
+	tests := []struct {
+		name     string
+		envelope string
+		err      error
+	}{
+		{name: "flipped bit", envelope: strings.Join(append(parts[:4:4], base64.RawURLEncoding.EncodeToString(sealed)), "."), err: ErrDecryptionFailed},
+		{name: "swapped key", envelope: strings.Join(append([]string{"v1", "k2"}, parts[2:]...), "."), err: ErrDecryptionFailed},
+		{name: "unknown key", envelope: strings.Join(append([]string{"v1", "k9"}, parts[2:]...), "."), err: ErrUnknownKey},
+		{name: "unknown version", envelope: "v0" + envelope[2:], err: ErrMalformedEnvelope},
+		{name: "algorithm of another key", envelope: strings.Join(append([]string{"v1", "k1", string(AlgorithmXChaCha20Poly1305)}, parts[3:]...), "."), err: ErrMalformedEnvelope},
+		{name: "unknown algorithm", envelope: strings.Join(append([]string{"v1", "k1", "rot13"}, parts[3:]...), "."), err: ErrMalformedEnvelope},
+		{name: "truncated", envelope: strings.Join(parts[:4], "."), err: ErrMalformedEnvelope},
+		{name: "plaintext", envelope: "top secret", err: ErrMalformedEnvelope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := keyring.Decrypt(tt.envelope, []byte("row-1"))
+
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := NewKeyring("k1", testKey("k1", AlgorithmAES256GCM, 1))
+	require.NoError(t, err)
+
+	envelope, err := old.Encrypt([]byte("top secret"), []byte("row-1"))
+	require.NoError(t, err)
+
+	retired := testKey("k1", AlgorithmAES256GCM, 1)
+	retired.Retired = true
+	rotated, err := NewKeyring("k2", retired, testKey("k2", AlgorithmXChaCha20Poly1305, 2))
+	require.NoError(t, err)
+
+	plaintext, err := rotated.Decrypt(envelope, []byte("row-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "top secret", string(plaintext))
+	assert.True(t, rotated.NeedsReencrypt(envelope))
+
+	envelope, err = rotated.Encrypt(plaintext, []byte("row-1"))
+	require.NoError(t, err)
+	assert.False(t, rotated.NeedsReencrypt(envelope))
+	assert.True(t, strings.HasPrefix(envelope, "v1.k2.xchacha20-poly1305."))
+}
+
+func TestNewKeyringFromConfig(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, keySize))
+	t.Setenv("TEST_ENCRYPTION_KEY", secret)
+
+	keyring, err := NewKeyringFromConfig(config.EncryptionConfigurations{
+		PrimaryKey: "k2",
+		Keys: map[string]config.EncryptionKeyConfigurations{
+			"k1": {SecretSource: config.SecretSource{Secret: secret}, Retired: true},
+			"k2": {SecretSource: config.SecretSource{SecretEnv: "TEST_ENCRYPTION_KEY"}, Algorithm: "xchacha20-poly1305"},
+		},
+	})
+	require.NoError(t, err)
+
+	envelope, err := keyring.Encrypt([]byte("top secret"), nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(envelope, "v1.k2.xchacha20-poly1305."))
+}
+
+func TestNewKeyringRejectsInvalidKeys(t *testing.T) {
+	retired := testKey("k1", AlgorithmAES256GCM, 1)
+	retired.Retired = true
+
+	tests := []struct {
+		name    string
+		primary string
+		keys    []Key
+	}{
+		{name: "no keys", primary: "k1"},
+		{name: "missing primary", primary: "k2", keys: []Key{testKey("k1", AlgorithmAES256GCM, 1)}},
+		{name: "retired primary", primary: "k1", keys: []Key{retired}},
+		{name: "invalid id", primary: "k.1", keys: []Key{testKey("k.1", AlgorithmAES256GCM, 1)}},
+		{name: "short secret", primary: "k1", keys: []Key{{ID: "k1", Secret: []byte("short")}}},
+		{name: "unknown algorithm", primary: "k1", keys: []Key{testKey("k1", "rot13", 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.primary, tt.keys...)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptedStringRoundTrip(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey("k1", AlgorithmAES256GCM, 1))
+	require.NoError(t, err)
+	UseKeyring(keyring)
+	t.Cleanup(func() { UseKeyring(nil) })
+
+	written, err := NewEncryptedString("row-1", "top secret").Value()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(written.(string), "v1.k1."))
+
+	t.Run("bound before scan", func(t *testing.T) {
+		field := EncryptedString{}
+		require.NoError(t, field.Bind("row-1"))
+
+		require.NoError(t, field.Scan([]byte(written.(string))))
+
+		assert.Equal(t, "top secret", field.Plaintext())
+		assert.False(t, field.NeedsReencrypt())
+	})
+
+	t.Run("bound after scan", func(t *testing.T) {
+		field := EncryptedString{}
+		require.NoError(t, field.Scan(written))
+		assert.Empty(t, field.Plaintext())
+
+		require.NoError(t, field.Bind("row-1"))
+
+		assert.Equal(t, "top secret", field.Plaintext())
+	})
+
+	t.Run("another row", func(t *testing.T) {
+		field := EncryptedString{}
+		require.NoError(t, field.Scan(written))
+
+		assert.ErrorIs(t, field.Bind("row-2"), ErrDecryptionFailed)
+	})
+
+	t.Run("unopened value is written back as is", func(t *testing.T) {
+		field := EncryptedString{}
+		require.NoError(t, field.Scan(written))
+
+		value, err := field.Value()
+
+		require.NoError(t, err)
+		assert.Equal(t, written, value)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		field := EncryptedString{}
+		require.NoError(t, field.Scan(nil))
+
+		value, err := field.Value()
+
+		require.NoError(t, err)
+		assert.Nil(t, value)
+	})
+}
+
+func TestEncryptedStringRequiresRowAndKeyring(t *testing.T) {
+	_, err := NewEncryptedString("row-1", "top secret").Value()
+	assert.ErrorIs(t, err, ErrNoKeyring)
+
+	keyring, err := NewKeyring("k1", testKey("k1", AlgorithmAES256GCM, 1))
+	require.NoError(t, err)
+	UseKeyring(keyring)
+	t.Cleanup(func() { UseKeyring(nil) })
+
+	_, err = NewEncryptedString("", "top secret").Value()
+	assert.ErrorIs(t, err, ErrUnbound)
+}
@@ -0,0 +1,129 @@
+package crypto
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// defaultKeyring is used by the field types, drivers give no other way to reach it from Value and Scan
+var defaultKeyring atomic.Pointer[Keyring]
+
+var (
+	ErrNoKeyring = errors.New("no encryption keyring is configured")
+	ErrUnbound   = errors.New("encrypted field is not bound to a row")
+)
+
+// UseKeyring sets the keyring of the field types, it is called once at startup
+func UseKeyring(keyring *Keyring) {
+	defaultKeyring.Store(keyring)
+}
+
+// EncryptedString a string column stored encrypted, bound to the ID of its row.
+//
+// The value is encrypted when written and decrypted when scanned. The row ID is the associated data,
+// so it must be known on both sides: bind it before writing, and before scanning when the ID is
+// already known, otherwise call Bind once the row is scanned, e.g. from an AfterScanRow hook:
+//
+//	type Record struct {
+//		ID     string               `bun:"id,pk"`
+//		Secret crypto.EncryptedString `bun:"secret"`
+//	}
+//
+//	func (r *Record) AfterScanRow(ctx context.Context) error { return r.Secret.Bind(r.ID) }
+type EncryptedString struct {
+	rowID     string
+	plaintext string
+	envelope  string
+	opened    bool
+}
+
+// NewEncryptedString returns a field holding the plaintext, ready to be written
+func NewEncryptedString(rowID, plaintext string) EncryptedString {
+	return EncryptedString{rowID: rowID, plaintext: plaintext, opened: true}
+}
+
+// Plaintext returns the decrypted value, empty while the field is not bound
+func (e EncryptedString) Plaintext() string {
+	return e.plaintext
+}
+
+// Bind sets the row ID, a value scanned earlier is decrypted with it
+func (e *EncryptedString) Bind(rowID string) error {
+	e.rowID = rowID
+
+	if e.opened || e.envelope == "" {
+		return nil
+	}
+
+	return e.open()
+}
+
+// Value implements driver.Valuer, the plaintext is sealed with the primary key
+func (e EncryptedString) Value() (driver.Value, error) {
+	if !e.opened {
+		// never decrypted, write it back as it was read
+		if e.envelope == "" {
+			return nil, nil
+		}
+		return e.envelope, nil
+	}
+
+	if e.rowID == "" {
+		return nil, ErrUnbound
+	}
+
+	keyring := defaultKeyring.Load()
+	if keyring == nil {
+		return nil, ErrNoKeyring
+	}
+
+	return keyring.Encrypt([]byte(e.plaintext), []byte(e.rowID))
+}
+
+// Scan implements sql.Scanner, the value is decrypted right away when the field is already bound
+func (e *EncryptedString) Scan(src any) error {
+	e.plaintext, e.opened = "", false
+
+	switch v := src.(type) {
+	case nil:
+		e.envelope = ""
+		return nil
+	case string:
+		e.envelope = v
+	case []byte:
+		e.envelope = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into an encrypted string", src)
+	}
+
+	if e.rowID == "" {
+		return nil
+	}
+
+	return e.open()
+}
+
+// NeedsReencrypt tells whether the scanned value was sealed by a key that is not the primary one
+func (e EncryptedString) NeedsReencrypt() bool {
+	keyring := defaultKeyring.Load()
+
+	return keyring != nil && e.envelope != "" && keyring.NeedsReencrypt(e.envelope)
+}
+
+func (e *EncryptedString) open() error {
+	keyring := defaultKeyring.Load()
+	if keyring == nil {
+		return ErrNoKeyring
+	}
+
+	plaintext, err := keyring.Decrypt(e.envelope, []byte(e.rowID))
+	if err != nil {
+		return err
+	}
+
+	e.plaintext, e.opened = string(plaintext), true
+
+	return nil
+}
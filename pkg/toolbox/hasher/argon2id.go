@@ -2,6 +2,7 @@ package hasher
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"strings"
@@ -39,61 +40,75 @@ func (h *Argon2IdHasher) Hash(data string) (string, error) {
 	return encodedHash, nil
 }
 
-// Verify verifies if the given data matches the stored hash.
+// Verify verifies if the given data matches the stored hash. Hashes imported from legacy systems
+// (bcrypt and scrypt) are verified too, NeedsRehash reports them.
 func (h *Argon2IdHasher) Verify(data, hashedData string) bool {
+	if !strings.HasPrefix(hashedData, "$argon2id$") {
+		return verifyLegacy(data, hashedData)
+	}
+
+	params, salt, hash, ok := parseArgon2Id(hashedData)
+	if !ok {
+		return false
+	}
+
+	//nolint:gosec
+	calculatedHash := argon2.IDKey([]byte(data), salt, params.time, params.memory, params.threads, uint32(len(hash)))
+
+	return subtle.ConstantTimeCompare(hash, calculatedHash) == 1
+}
+
+// NeedsRehash tells whether the hash was not produced with the current algorithm and parameters
+func (h *Argon2IdHasher) NeedsRehash(hashedData string) bool {
+	params, _, hash, ok := parseArgon2Id(hashedData)
+	if !ok {
+		return true
+	}
+
+	//nolint:gosec
+	return params.time != h.time || params.memory != h.memory || params.threads != h.threads || uint32(len(hash)) != h.keyLen
+}
+
+// parseArgon2Id decodes a PHC string such as "$argon2id$v=19$m=65536,t=1,p=4$c29tZXNhbHQ$aGFzaA"
+func parseArgon2Id(hashedData string) (*Argon2IdHasher, []byte, []byte, bool) {
 	var (
 		version int
-		memory  uint32
-		time    uint32
-		threads uint8
+		params  Argon2IdHasher
 	)
 
 	vals := strings.Split(hashedData, "$")
 	if len(vals) != 6 {
-		return false
+		return nil, nil, nil, false
 	}
 
 	// Check if the hash is argon2id
 	if vals[1] != "argon2id" {
-		return false
+		return nil, nil, nil, false
 	}
 
 	// Check if the version is 19
 	_, err := fmt.Sscanf(vals[2], "v=%d", &version)
 	if err != nil || version != 19 {
-		return false
+		return nil, nil, nil, false
 	}
 
 	// Parse memory, time, and threads
-	_, err = fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	_, err = fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads)
 	if err != nil {
-		return false
+		return nil, nil, nil, false
 	}
 
 	// Decode the salt
 	salt, err := base64.RawStdEncoding.DecodeString(vals[4])
 	if err != nil {
-		return false
+		return nil, nil, nil, false
 	}
 
 	// Decode the hash
 	hash, err := base64.RawStdEncoding.DecodeString(vals[5])
-	if err != nil {
-		return false
-	}
-
-	//nolint:gosec
-	calculatedHash := argon2.IDKey([]byte(data), salt, time, memory, threads, uint32(len(hash)))
-
-	if len(hash) != len(calculatedHash) {
-		return false
-	}
-
-	for i := 0; i < len(hash); i++ {
-		if hash[i] != calculatedHash[i] {
-			return false
-		}
+	if err != nil || len(hash) == 0 {
+		return nil, nil, nil, false
 	}
 
-	return true
+	return &params, salt, hash, true
 }
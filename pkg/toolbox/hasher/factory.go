@@ -1,10 +1,15 @@
 package hasher
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"errors"
+)
 
 type Hasher interface {
 	Hash(data string) (string, error)
 	Verify(data, hashedData string) bool
+	// NeedsRehash tells whether a hash that verified must be replaced by a new one from Hash
+	NeedsRehash(hashedData string) bool
 }
 
 // NewHmacSHA256 Creates a new HMAC-SHA256 hasher instance based on the given base64 encoded secret
@@ -17,6 +22,29 @@ func NewHmacSHA256(encodedSecret string) (Hasher, error) {
 	return &HmacSHA256{secret: decoded}, nil
 }
 
+// NewArgon2IdHasher Creates an Argon2id hasher, memory is expressed in KiB
+func NewArgon2IdHasher(time, memory uint32, threads uint8, keyLen uint32) (*Argon2IdHasher, error) {
+	if time == 0 || threads == 0 {
+		return nil, errors.New("argon2id time and threads must be positive")
+	}
+
+	// Argon2 requires at least 8 KiB per thread
+	if memory < 8*uint32(threads) {
+		return nil, errors.New("argon2id memory is too low for the number of threads")
+	}
+
+	if keyLen < 16 {
+		return nil, errors.New("argon2id key length must be at least 16 bytes")
+	}
+
+	return &Argon2IdHasher{
+		time:    time,
+		memory:  memory,
+		threads: threads,
+		keyLen:  keyLen,
+	}, nil
+}
+
 func NewArgon2IdHasherWithSaneDefaults() *Argon2IdHasher {
 	return &Argon2IdHasher{
 		time:    1,
@@ -1,9 +1,17 @@
 package hasher
 
 import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeusito/toci/pkg/config"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
 )
 
 //nolint:gosec
@@ -81,3 +89,158 @@ func TestVerifyArgon2IdHash(t *testing.T) {
 
 	assert.True(t, result)
 }
+
+func TestNewArgon2IdHasher(t *testing.T) {
+	hasher, err := NewArgon2IdHasher(2, 19*1024, 1, 32)
+	assert.NoError(t, err)
+
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	assert.Containsf(t, hash, "$argon2id$v=19$m=19456,t=2,p=1$", "hash should use the given parameters")
+
+	_, err = NewArgon2IdHasher(0, 19*1024, 1, 32)
+	assert.Error(t, err, "time must be positive")
+
+	_, err = NewArgon2IdHasher(1, 8, 4, 32)
+	assert.Error(t, err, "memory must cover the threads")
+
+	_, err = NewArgon2IdHasher(1, 19*1024, 1, 8)
+	assert.Error(t, err, "key length must be at least 16 bytes")
+}
+
+//nolint:gosec
+func TestArgon2IdNeedsRehash(t *testing.T) {
+	hasher := NewArgon2IdHasherWithSaneDefaults()
+	hash, err := hasher.Hash("password")
+	require.NoError(t, err)
+
+	assert.False(t, hasher.NeedsRehash(hash), "current parameters should not need a rehash")
+	assert.True(t, hasher.NeedsRehash("$argon2id$v=19$m=16,t=2,p=1$N1FkeWl6S0RaTzZPVkpTdQ$NZwZ/YEeSD+oKh3TOMqDDg"),
+		"outdated parameters should need a rehash")
+	assert.True(t, hasher.NeedsRehash("$2a$10$abcdefghijklmnopqrstuu"), "legacy hashes should need a rehash")
+}
+
+//nolint:gosec
+func TestVerifyLegacyBcryptHash(t *testing.T) {
+	hasher := NewArgon2IdHasherWithSaneDefaults()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("1234567890"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	assert.True(t, hasher.Verify("1234567890", string(hashed)))
+	assert.False(t, hasher.Verify("wrong", string(hashed)))
+}
+
+//nolint:gosec
+func TestVerifyLegacyScryptHash(t *testing.T) {
+	hasher := NewArgon2IdHasherWithSaneDefaults()
+	salt := []byte("legacy-salt")
+	key, err := scrypt.Key([]byte("1234567890"), salt, 1<<10, 8, 1, 32)
+	require.NoError(t, err)
+
+	hashed := "$scrypt$ln=10,r=8,p=1$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+
+	assert.True(t, hasher.Verify("1234567890", hashed))
+	assert.False(t, hasher.Verify("wrong", hashed))
+	assert.False(t, hasher.Verify("1234567890", "$scrypt$ln=99,r=8,p=1$c2FsdA$aGFzaA"))
+}
+
+func TestHmacSHA256KeyringRotation(t *testing.T) {
+	legacySecret := base64.StdEncoding.EncodeToString([]byte("legacy-secret"))
+	oldSecret := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32)))
+	newSecret := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("n", 32)))
+
+	legacyHasher, err := NewHmacSHA256(legacySecret)
+	require.NoError(t, err)
+	legacyHash, err := legacyHasher.Hash("data")
+	require.NoError(t, err)
+
+	oldKeyring, err := NewHmacSHA256KeyringFromConfig(config.HasherConfigurations{
+		PrimaryKey: "k1",
+		Keys:       map[string]config.HmacKeyConfigurations{"k1": {SecretSource: config.SecretSource{Secret: oldSecret}}},
+	})
+	require.NoError(t, err)
+	oldHash, err := oldKeyring.Hash("data")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(oldHash, "k1$"), "hashes should be prefixed with the key ID")
+
+	keyring, err := NewHmacSHA256KeyringFromConfig(config.HasherConfigurations{
+		SHASecret:  legacySecret,
+		PrimaryKey: "k2",
+		Keys: map[string]config.HmacKeyConfigurations{
+			"k1": {SecretSource: config.SecretSource{Secret: oldSecret}, Retired: true},
+			"k2": {SecretSource: config.SecretSource{Secret: newSecret}},
+		},
+	})
+	require.NoError(t, err)
+
+	newHash, err := keyring.Hash("data")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newHash, "k2$"), "new hashes should use the primary key")
+
+	for _, hash := range []string{legacyHash, oldHash, newHash} {
+		assert.True(t, keyring.Verify("data", hash), "every configured key should verify")
+		assert.False(t, keyring.Verify("other", hash))
+	}
+	assert.False(t, keyring.Verify("data", "k3$"+strings.TrimPrefix(newHash, "k2$")), "unknown keys should not verify")
+
+	assert.False(t, keyring.NeedsRehash(newHash))
+	assert.True(t, keyring.NeedsRehash(oldHash))
+	assert.True(t, keyring.NeedsRehash(legacyHash))
+
+	all, err := keyring.HashAll("data")
+	require.NoError(t, err)
+	assert.Equal(t, []string{newHash, legacyHash, oldHash}, all, "the primary key should come first")
+}
+
+func TestHmacSHA256KeyringSecretSources(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32)))
+	file := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(file, []byte(secret+"\n"), 0o600))
+	t.Setenv("TEST_HASHER_KEY", secret)
+
+	for _, keyCfg := range []config.SecretSource{{SecretEnv: "TEST_HASHER_KEY"}, {SecretFile: file}} {
+		keyring, err := NewHmacSHA256KeyringFromConfig(config.HasherConfigurations{
+			PrimaryKey: "k1",
+			Keys:       map[string]config.HmacKeyConfigurations{"k1": {SecretSource: keyCfg}},
+		})
+		require.NoError(t, err)
+
+		hash, err := keyring.Hash("data")
+		require.NoError(t, err)
+		assert.True(t, keyring.Verify("data", hash))
+	}
+}
+
+func TestHmacSHA256KeyringInvalidConfig(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32)))
+
+	tests := map[string]config.HasherConfigurations{
+		"no keys":          {},
+		"missing primary":  {PrimaryKey: "k2", Keys: map[string]config.HmacKeyConfigurations{"k1": {SecretSource: config.SecretSource{Secret: secret}}}},
+		"retired primary":  {PrimaryKey: "k1", Keys: map[string]config.HmacKeyConfigurations{"k1": {SecretSource: config.SecretSource{Secret: secret}, Retired: true}}},
+		"short secret":     {PrimaryKey: "k1", Keys: map[string]config.HmacKeyConfigurations{"k1": {SecretSource: config.SecretSource{Secret: "c2hvcnQ="}}}},
+		"invalid key ID":   {PrimaryKey: "k$1", Keys: map[string]config.HmacKeyConfigurations{"k$1": {SecretSource: config.SecretSource{Secret: secret}}}},
+		"no secret source": {PrimaryKey: "k1", Keys: map[string]config.HmacKeyConfigurations{"k1": {}}},
+		"unset env":        {PrimaryKey: "k1", Keys: map[string]config.HmacKeyConfigurations{"k1": {SecretSource: config.SecretSource{SecretEnv: "TEST_UNSET_HASHER_KEY"}}}},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewHmacSHA256KeyringFromConfig(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHmacSHA256KeyringFallsBackToLegacySecret(t *testing.T) {
+	legacySecret := base64.StdEncoding.EncodeToString([]byte("legacy-secret"))
+
+	keyring, err := NewHmacSHA256KeyringFromConfig(config.HasherConfigurations{SHASecret: legacySecret})
+	require.NoError(t, err)
+
+	hash, err := keyring.Hash("data")
+	require.NoError(t, err)
+	assert.NotContains(t, hash, keyIDSeparator, "legacy hashes should carry no key ID")
+	assert.True(t, keyring.Verify("data", hash))
+	assert.False(t, keyring.NeedsRehash(hash))
+}
@@ -32,3 +32,8 @@ func (s *HmacSHA256) Verify(data, hashedData string) bool {
 
 	return hmac.Equal([]byte(computedHash), []byte(hashedData))
 }
+
+// NeedsRehash the key is fixed, so a hash never has to be replaced
+func (s *HmacSHA256) NeedsRehash(hashedData string) bool {
+	return false
+}
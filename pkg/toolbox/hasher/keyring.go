@@ -0,0 +1,156 @@
+package hasher
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zeusito/toci/pkg/config"
+)
+
+// keyIDSeparator splits the key ID from the hex encoded HMAC
+const keyIDSeparator = "$"
+
+var keyIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// Keyring a Hasher backed by several keys. Hashes used as lookup keys must be searched under all of them.
+type Keyring interface {
+	Hasher
+	// HashAll returns the hash of data under every key, the one of the primary key first
+	HashAll(data string) ([]string, error)
+}
+
+// HmacSHA256Keyring writes with the primary key and verifies with any key, retired ones included.
+// Hashes are prefixed with the key ID, except the ones of the legacy sha-secret that have no prefix.
+type HmacSHA256Keyring struct {
+	primary string
+	keys    map[string]*HmacSHA256
+	// the key IDs, primary first, used to keep HashAll deterministic
+	order []string
+}
+
+// NewHmacSHA256KeyringFromConfig Creates a keyring from the configured keys. Without keys, the legacy
+// sha-secret is the primary key and hashes keep their historical unprefixed format.
+func NewHmacSHA256KeyringFromConfig(cfg config.HasherConfigurations) (Keyring, error) {
+	keyring := &HmacSHA256Keyring{keys: map[string]*HmacSHA256{}}
+
+	if cfg.SHASecret != "" {
+		secret, err := base64.StdEncoding.DecodeString(cfg.SHASecret)
+		if err != nil {
+			return nil, fmt.Errorf("decoding sha-secret: %w", err)
+		}
+		keyring.keys[""] = &HmacSHA256{secret: secret}
+	}
+
+	for id, keyCfg := range cfg.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+
+		encoded, err := keyCfg.Load()
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s: %w", id, err)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding key %s: %w", id, err)
+		}
+
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("key %s must be at least 32 bytes", id)
+		}
+
+		keyring.keys[id] = &HmacSHA256{secret: secret}
+
+		if id == cfg.PrimaryKey && keyCfg.Retired {
+			return nil, fmt.Errorf("primary key %s is retired", id)
+		}
+	}
+
+	if len(cfg.Keys) > 0 {
+		if _, ok := cfg.Keys[cfg.PrimaryKey]; !ok {
+			return nil, fmt.Errorf("primary key %q is not configured", cfg.PrimaryKey)
+		}
+		keyring.primary = cfg.PrimaryKey
+	}
+
+	if len(keyring.keys) == 0 {
+		return nil, errors.New("no hashing key is configured")
+	}
+
+	keyring.order = append(keyring.order, keyring.primary)
+	for id := range keyring.keys {
+		if id != keyring.primary {
+			keyring.order = append(keyring.order, id)
+		}
+	}
+	sort.Strings(keyring.order[1:])
+
+	return keyring, nil
+}
+
+// Hash hashes with the primary key
+func (k *HmacSHA256Keyring) Hash(data string) (string, error) {
+	return k.hashWith(k.primary, data)
+}
+
+// Verify verifies with the key the hash was produced with
+func (k *HmacSHA256Keyring) Verify(data, hashedData string) bool {
+	id, hash := splitKeyID(hashedData)
+
+	key, ok := k.keys[id]
+	if !ok {
+		return false
+	}
+
+	return key.Verify(data, hash)
+}
+
+// NeedsRehash tells whether the hash was produced by another key than the primary one
+func (k *HmacSHA256Keyring) NeedsRehash(hashedData string) bool {
+	id, _ := splitKeyID(hashedData)
+
+	return id != k.primary
+}
+
+// HashAll returns the hash of data under every key, the one of the primary key first
+func (k *HmacSHA256Keyring) HashAll(data string) ([]string, error) {
+	hashes := make([]string, 0, len(k.order))
+
+	for _, id := range k.order {
+		hash, err := k.hashWith(id, data)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+func (k *HmacSHA256Keyring) hashWith(id, data string) (string, error) {
+	hash, err := k.keys[id].Hash(data)
+	if err != nil {
+		return "", err
+	}
+
+	if id == "" {
+		return hash, nil
+	}
+
+	return id + keyIDSeparator + hash, nil
+}
+
+// splitKeyID returns the key ID and the HMAC, unprefixed hashes belong to the legacy key
+func splitKeyID(hashedData string) (string, string) {
+	id, hash, found := strings.Cut(hashedData, keyIDSeparator)
+	if !found {
+		return "", hashedData
+	}
+
+	return id, hash
+}
@@ -0,0 +1,55 @@
+package hasher
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// verifyLegacy verifies hashes imported from systems that used bcrypt ("$2a$", "$2b$" or "$2y$") or
+// scrypt, the latter in the PHC format "$scrypt$ln=15,r=8,p=1$<base64 salt>$<base64 hash>"
+func verifyLegacy(data, hashedData string) bool {
+	switch {
+	case strings.HasPrefix(hashedData, "$2a$"), strings.HasPrefix(hashedData, "$2b$"), strings.HasPrefix(hashedData, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hashedData), []byte(data)) == nil
+	case strings.HasPrefix(hashedData, "$scrypt$"):
+		return verifyScrypt(data, hashedData)
+	default:
+		return false
+	}
+}
+
+func verifyScrypt(data, hashedData string) bool {
+	var logN, r, p int
+
+	vals := strings.Split(hashedData, "$")
+	if len(vals) != 5 {
+		return false
+	}
+
+	_, err := fmt.Sscanf(vals[2], "ln=%d,r=%d,p=%d", &logN, &r, &p)
+	if err != nil || logN <= 0 || logN > 30 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(vals[3])
+	if err != nil {
+		return false
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(vals[4])
+	if err != nil || len(hash) == 0 {
+		return false
+	}
+
+	calculatedHash, err := scrypt.Key([]byte(data), salt, 1<<logN, r, p, len(hash))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(hash, calculatedHash) == 1
+}
@@ -95,6 +95,57 @@ func (_c *MockHasher_Hash_Call) RunAndReturn(run func(data string) (string, erro
 	return _c
 }
 
+// NeedsRehash provides a mock function for the type MockHasher
+func (_mock *MockHasher) NeedsRehash(hashedData string) bool {
+	ret := _mock.Called(hashedData)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NeedsRehash")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(hashedData)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockHasher_NeedsRehash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NeedsRehash'
+type MockHasher_NeedsRehash_Call struct {
+	*mock.Call
+}
+
+// NeedsRehash is a helper method to define mock.On call
+//   - hashedData string
+func (_e *MockHasher_Expecter) NeedsRehash(hashedData interface{}) *MockHasher_NeedsRehash_Call {
+	return &MockHasher_NeedsRehash_Call{Call: _e.mock.On("NeedsRehash", hashedData)}
+}
+
+func (_c *MockHasher_NeedsRehash_Call) Run(run func(hashedData string)) *MockHasher_NeedsRehash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockHasher_NeedsRehash_Call) Return(b bool) *MockHasher_NeedsRehash_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockHasher_NeedsRehash_Call) RunAndReturn(run func(hashedData string) bool) *MockHasher_NeedsRehash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Verify provides a mock function for the type MockHasher
 func (_mock *MockHasher) Verify(data string, hashedData string) bool {
 	ret := _mock.Called(data, hashedData)
@@ -151,3 +202,260 @@ func (_c *MockHasher_Verify_Call) RunAndReturn(run func(data string, hashedData
 	_c.Call.Return(run)
 	return _c
 }
+
+// NewMockKeyring creates a new instance of MockKeyring. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockKeyring(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockKeyring {
+	mock := &MockKeyring{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockKeyring is an autogenerated mock type for the Keyring type
+type MockKeyring struct {
+	mock.Mock
+}
+
+type MockKeyring_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockKeyring) EXPECT() *MockKeyring_Expecter {
+	return &MockKeyring_Expecter{mock: &_m.Mock}
+}
+
+// Hash provides a mock function for the type MockKeyring
+func (_mock *MockKeyring) Hash(data string) (string, error) {
+	ret := _mock.Called(data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Hash")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return returnFunc(data)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(data)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(data)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockKeyring_Hash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Hash'
+type MockKeyring_Hash_Call struct {
+	*mock.Call
+}
+
+// Hash is a helper method to define mock.On call
+//   - data string
+func (_e *MockKeyring_Expecter) Hash(data interface{}) *MockKeyring_Hash_Call {
+	return &MockKeyring_Hash_Call{Call: _e.mock.On("Hash", data)}
+}
+
+func (_c *MockKeyring_Hash_Call) Run(run func(data string)) *MockKeyring_Hash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockKeyring_Hash_Call) Return(s string, err error) *MockKeyring_Hash_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockKeyring_Hash_Call) RunAndReturn(run func(data string) (string, error)) *MockKeyring_Hash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HashAll provides a mock function for the type MockKeyring
+func (_mock *MockKeyring) HashAll(data string) ([]string, error) {
+	ret := _mock.Called(data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HashAll")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return returnFunc(data)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = returnFunc(data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(data)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockKeyring_HashAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HashAll'
+type MockKeyring_HashAll_Call struct {
+	*mock.Call
+}
+
+// HashAll is a helper method to define mock.On call
+//   - data string
+func (_e *MockKeyring_Expecter) HashAll(data interface{}) *MockKeyring_HashAll_Call {
+	return &MockKeyring_HashAll_Call{Call: _e.mock.On("HashAll", data)}
+}
+
+func (_c *MockKeyring_HashAll_Call) Run(run func(data string)) *MockKeyring_HashAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockKeyring_HashAll_Call) Return(ss []string, err error) *MockKeyring_HashAll_Call {
+	_c.Call.Return(ss, err)
+	return _c
+}
+
+func (_c *MockKeyring_HashAll_Call) RunAndReturn(run func(data string) ([]string, error)) *MockKeyring_HashAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NeedsRehash provides a mock function for the type MockKeyring
+func (_mock *MockKeyring) NeedsRehash(hashedData string) bool {
+	ret := _mock.Called(hashedData)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NeedsRehash")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(hashedData)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockKeyring_NeedsRehash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NeedsRehash'
+type MockKeyring_NeedsRehash_Call struct {
+	*mock.Call
+}
+
+// NeedsRehash is a helper method to define mock.On call
+//   - hashedData string
+func (_e *MockKeyring_Expecter) NeedsRehash(hashedData interface{}) *MockKeyring_NeedsRehash_Call {
+	return &MockKeyring_NeedsRehash_Call{Call: _e.mock.On("NeedsRehash", hashedData)}
+}
+
+func (_c *MockKeyring_NeedsRehash_Call) Run(run func(hashedData string)) *MockKeyring_NeedsRehash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockKeyring_NeedsRehash_Call) Return(b bool) *MockKeyring_NeedsRehash_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockKeyring_NeedsRehash_Call) RunAndReturn(run func(hashedData string) bool) *MockKeyring_NeedsRehash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function for the type MockKeyring
+func (_mock *MockKeyring) Verify(data string, hashedData string) bool {
+	ret := _mock.Called(data, hashedData)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = returnFunc(data, hashedData)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockKeyring_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type MockKeyring_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - data string
+//   - hashedData string
+func (_e *MockKeyring_Expecter) Verify(data interface{}, hashedData interface{}) *MockKeyring_Verify_Call {
+	return &MockKeyring_Verify_Call{Call: _e.mock.On("Verify", data, hashedData)}
+}
+
+func (_c *MockKeyring_Verify_Call) Run(run func(data string, hashedData string)) *MockKeyring_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockKeyring_Verify_Call) Return(b bool) *MockKeyring_Verify_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockKeyring_Verify_Call) RunAndReturn(run func(data string, hashedData string) bool) *MockKeyring_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
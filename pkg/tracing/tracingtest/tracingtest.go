@@ -0,0 +1,34 @@
+// Package tracingtest records the spans of the tests in memory
+package tracingtest
+
+import (
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	once     sync.Once
+	exporter *tracetest.InMemoryExporter
+)
+
+// Exporter installs a global tracer provider exporting synchronously to memory and returns its exporter, emptied.
+// The provider is installed once per test binary since the tracers of the instrumented packages only follow the
+// first global provider.
+func Exporter(t testing.TB) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	once.Do(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	exporter.Reset()
+
+	return exporter
+}